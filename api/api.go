@@ -23,7 +23,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/kord-network/go-kord/graph"
@@ -31,7 +34,17 @@ import (
 )
 
 type API struct {
-	schema *graphql.Schema
+	// Limits bounds the cost of requests, defaulting to DefaultLimits
+	Limits Limits
+
+	// Auth authenticates requests, which must be authenticated to run
+	// mutations and to query restricted graphs
+	Auth Auth
+
+	schema    *graphql.Schema
+	fields    *schemaFields
+	schemaMtx sync.RWMutex
+	resolver  *Resolver
 }
 
 func NewAPI(driver *graph.Driver) (*API, error) {
@@ -41,36 +54,180 @@ func NewAPI(driver *graph.Driver) (*API, error) {
 		return nil, err
 	}
 	return &API{
-		schema: schema,
+		Limits:   DefaultLimits,
+		schema:   schema,
+		fields:   newSchemaFields(schema),
+		resolver: resolver,
 	}, nil
 }
 
+// SetRDFSchema serves the types generated from the RDF schema alongside the
+// built-in types, with the generated query fields added to the Query type
+// and resolved by the Resolver's ResolveField.
+func (a *API) SetRDFSchema(s *RDFSchema) error {
+	schemaString := GraphQLSchema
+	if s != nil {
+		schemaString = s.mergeSchema(GraphQLSchema)
+	}
+	schema, err := graphql.ParseSchema(schemaString, a.resolver)
+	if err != nil {
+		return fmt.Errorf("error parsing generated GraphQL schema: %s", err)
+	}
+	a.resolver.SetRDFSchema(s)
+	a.schemaMtx.Lock()
+	defer a.schemaMtx.Unlock()
+	a.schema = schema
+	a.fields = newSchemaFields(schema)
+	return nil
+}
+
+// currentSchema returns the schema being served and its field types.
+func (a *API) currentSchema() (*graphql.Schema, *schemaFields) {
+	a.schemaMtx.RLock()
+	defer a.schemaMtx.RUnlock()
+	return a.schema, a.fields
+}
+
 func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	limits := a.Limits
+	if limits.MaxBodySize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBodySize)
+	}
 	var params struct {
 		Query         string                 `json:"query"`
 		OperationName string                 `json:"operationName"`
 		Variables     map[string]interface{} `json:"variables"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// http.MaxBytesReader does not return a typed error
+		if err.Error() == "http: request body too large" {
+			writeResponse(w, http.StatusRequestEntityTooLarge, &Response{
+				Errors: []*QueryError{newQueryError(ErrCodeRequestTooLarge, map[string]interface{}{
+					"maxBodySize": limits.MaxBodySize,
+				}, "request body exceeds the maximum size of %d bytes", limits.MaxBodySize)},
+			})
+			return
+		}
+		http.Error(w, fmt.Sprintf("error reading request: %s", err), http.StatusBadRequest)
+		return
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
 		http.Error(w, fmt.Sprintf("error decoding request: %s", err), http.StatusBadRequest)
 		return
 	}
+	start := time.Now()
+	operation := params.OperationName
+	failed := true
+	defer func() { observeRequest(operation, start, failed) }()
+
+	principal, err := a.Auth.authenticate(r, body)
+	if err != nil {
+		writeResponse(w, http.StatusUnauthorized, &Response{
+			Errors: []*QueryError{newQueryError(ErrCodeUnauthenticated, nil, "%s", err)},
+		})
+		return
+	}
 
+	// select the fields of the operation before executing it, so that its
+	// cost can be checked and mutations can require authentication
+	schema, fields := a.currentSchema()
+	q, qErr := graphql.ParseQuery(params.Query)
+	if qErr != nil {
+		qerr := newQueryError(ErrCodeParseFailed, nil, "%s", qErr.Message)
+		qerr.Locations = qErr.Locations
+		writeResponse(w, http.StatusOK, &Response{Errors: []*QueryError{qerr}})
+		return
+	}
+	op, errs := schema.Select(q, params.OperationName, params.Variables)
+	if len(errs) > 0 {
+		res := &Response{Errors: make([]*QueryError, len(errs))}
+		for i, err := range errs {
+			res.Errors[i] = &QueryError{QueryError: err}
+		}
+		writeResponse(w, http.StatusOK, res)
+		return
+	}
+	operation = op.Name
+	if qerr := limits.check(op, fields); qerr != nil {
+		writeResponse(w, http.StatusOK, &Response{Errors: []*QueryError{qerr}})
+		return
+	}
+	if op.Type == "mutation" && principal == nil {
+		writeResponse(w, http.StatusUnauthorized, &Response{
+			Errors: []*QueryError{newQueryError(ErrCodeUnauthenticated, nil, "%s", errUnauthenticated)},
+		})
+		return
+	}
+
+	ctx := r.Context()
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
 	swarmHash := common.Hash{}
-	ctx := context.WithValue(r.Context(), "swarmHash", &swarmHash)
-	response := a.schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
+	ctx = context.WithValue(ctx, "swarmHash", &swarmHash)
+	ctx = context.WithValue(ctx, "principal", principal)
+	graphs := &requestGraphs{driver: a.resolver.driver}
+	defer graphs.release()
+	ctx = context.WithValue(ctx, "graphs", graphs)
+	response := schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
 
-	if response.Extensions == nil {
-		response.Extensions = make(map[string]interface{})
+	res := &Response{
+		Data:       response.Data,
+		Errors:     make([]*QueryError, len(response.Errors)),
+		Extensions: response.Extensions,
+	}
+	for i, err := range response.Errors {
+		res.Errors[i] = &QueryError{QueryError: err}
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		res.Errors = append(res.Errors, newQueryError(ErrCodeQueryTimeout, map[string]interface{}{
+			"timeout": limits.Timeout.String(),
+		}, "query exceeded the execution timeout of %s", limits.Timeout))
+	}
+	if res.Extensions == nil {
+		res.Extensions = make(map[string]interface{})
 	}
-	response.Extensions["kord"] = map[string]interface{}{"swarmHash": swarmHash}
+	res.Extensions["kord"] = map[string]interface{}{"swarmHash": swarmHash}
 
-	responseJSON, err := json.Marshal(response)
+	failed = len(res.Errors) > 0
+	writeResponse(w, http.StatusOK, res)
+}
+
+func writeResponse(w http.ResponseWriter, status int, res *Response) {
+	responseJSON, err := json.Marshal(res)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	w.Write(responseJSON)
 }
+
+// requestGraphs records the graphs opened while serving a request, which
+// are released once the request has been served so that they can be
+// evicted.
+type requestGraphs struct {
+	driver *graph.Driver
+	names  []string
+	mtx    sync.Mutex
+}
+
+func (g *requestGraphs) add(name string) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	g.names = append(g.names, name)
+}
+
+func (g *requestGraphs) release() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	for _, name := range g.names {
+		g.driver.Release(name)
+	}
+	g.names = nil
+}
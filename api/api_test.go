@@ -24,30 +24,35 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/graphql"
 	"github.com/kord-network/go-kord/testutil"
 )
 
 func TestAPI(t *testing.T) {
 	// create a test API
-	dpa, err := testutil.NewTestDPA()
+	ts, err := testutil.NewTestStore()
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer dpa.Cleanup()
+	defer ts.Cleanup()
 	registry := testutil.NewTestRegistry()
-	driver := graph.NewDriver("kord-id-test", dpa.DPA, registry, dpa.Dir)
+	driver := graph.NewDriver("kord-id-test", ts, registry, ts.Dir)
 	api, err := NewAPI(driver)
 	if err != nil {
 		t.Fatal(err)
 	}
+	api.Auth.Tokens = []string{testAPIToken}
 	srv := httptest.NewServer(api)
 	defer srv.Close()
 
 	// create a graph
 	client := NewClient(srv.URL)
+	client.SetToken(testAPIToken)
 	hash, err := client.CreateGraph(testKordID.Hex())
 	if err != nil {
 		t.Fatal(err)
@@ -76,11 +81,12 @@ func TestAPI(t *testing.T) {
 
 	// get the claim
 	id := testKordID.Hex()
+	value := "test"
 	claims, err := client.Claim(testKordID.Hex(), &ClaimFilter{
 		Issuer:   &id,
 		Subject:  &id,
 		Property: &claim.Property,
-		Claim:    &claim.Claim,
+		Claim:    &value,
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -95,6 +101,148 @@ func TestAPI(t *testing.T) {
 	if !bytes.Equal(gotClaim.Signature, claim.Signature) {
 		t.Fatalf("expected claim to have signature %s, got %s", hexutil.Encode(claim.Signature), hexutil.Encode(gotClaim.Signature))
 	}
+
+	// get the claim by ID
+	gotClaim, err = client.ClaimByID(claim.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotClaim == nil || gotClaim.ID() != claim.ID() {
+		t.Fatalf("expected claim with ID %s, got %v", claim.ID().String(), gotClaim)
+	}
+	gotClaim, err = client.ClaimByID(common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotClaim != nil {
+		t.Fatalf("expected unknown claim to be nil, got %v", gotClaim)
+	}
+
+	// get claims by subject across graphs
+	claims, err = client.Claims(testKordID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) != 1 || claims[0].ID() != claim.ID() {
+		t.Fatalf("expected 1 claim about %s, got %d", testKordID.Hex(), len(claims))
+	}
+
+	// get claims about the issuer of the claim
+	var v struct {
+		Claim struct {
+			Graph        string   `json:"graph"`
+			IssuerClaims []*Claim `json:"issuerClaims"`
+		} `json:"claimByID"`
+	}
+	query := `
+query IssuerClaims($id: String!) {
+  claimByID(id: $id) {
+    graph
+    issuerClaims {
+      id
+      issuer
+      subject
+      property
+      claim
+      signature
+    }
+  }
+}
+`
+	if _, err := client.Do(query, graphql.Variables{"id": claim.ID().String()}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Claim.Graph != testKordID.Hex() {
+		t.Fatalf("expected claim graph %s, got %s", testKordID.Hex(), v.Claim.Graph)
+	}
+	if len(v.Claim.IssuerClaims) != 1 || v.Claim.IssuerClaims[0].ID() != claim.ID() {
+		t.Fatalf("expected 1 issuer claim, got %d", len(v.Claim.IssuerClaims))
+	}
+}
+
+func TestCreateClaims(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	registry := testutil.NewTestRegistry()
+	driver := graph.NewDriver("kord-batch-test", ts, registry, ts.Dir)
+	api, err := NewAPI(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Auth.Tokens = []string{testAPIToken}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	client := NewClient(srv.URL)
+	client.SetToken(testAPIToken)
+	if _, err := client.CreateGraph(testKordID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	existing := newTestClaim(t, "username", "existing")
+	if _, err := client.CreateClaim(testKordID.Hex(), existing); err != nil {
+		t.Fatal(err)
+	}
+
+	// create a batch containing valid claims, a claim with an invalid
+	// signature, a duplicate and an existing claim
+	valid := []*Claim{
+		newTestClaim(t, "username", "test"),
+		newTestClaim(t, "email", "test@example.com"),
+		newTestClaim(t, "name", "Test"),
+	}
+	invalid := newTestClaim(t, "username", "invalid")
+	invalid.Claim = quad.String("tampered")
+	claims := append(valid, invalid, valid[0], existing)
+	hash, errs, err := client.CreateClaims(testKordID.Hex(), claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == (common.Hash{}) {
+		t.Fatal("expected non-zero hash")
+	}
+	if len(errs) != len(claims) {
+		t.Fatalf("expected %d errors, got %d", len(claims), len(errs))
+	}
+	for i, err := range errs {
+		if i < len(valid) && err != nil {
+			t.Fatalf("unexpected error for claim %d: %s", i, err)
+		}
+		if i >= len(valid) && err == nil {
+			t.Fatalf("expected error for claim %d", i)
+		}
+	}
+
+	// check only the valid claims were created
+	for _, claim := range valid {
+		property := claim.Property
+		gotClaims, err := client.Claim(testKordID.Hex(), &ClaimFilter{Property: &property})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if property == "username" {
+			if len(gotClaims) != 2 {
+				t.Fatalf("expected 2 username claims, got %d", len(gotClaims))
+			}
+			continue
+		}
+		if len(gotClaims) != 1 || gotClaims[0].ID() != claim.ID() {
+			t.Fatalf("expected claim %s, got %v", claim.ID().Hex(), gotClaims)
+		}
+	}
+
+	// check a batch of only invalid claims does not commit
+	hash, errs, err = client.CreateClaims(testKordID.Hex(), []*Claim{invalid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != (common.Hash{}) {
+		t.Fatalf("expected zero hash, got %s", hash.Hex())
+	}
+	if len(errs) != 1 || errs[0] == nil {
+		t.Fatalf("expected an error for the invalid claim, got %v", errs)
+	}
 }
 
 var (
@@ -102,12 +250,14 @@ var (
 	testKordID = NewID(crypto.PubkeyToAddress(testKey.PublicKey))
 )
 
+const testAPIToken = "test-token"
+
 func newTestClaim(t *testing.T, property, claim string) *Claim {
 	c := &Claim{
 		Issuer:   testKordID,
 		Subject:  testKordID,
 		Property: property,
-		Claim:    claim,
+		Claim:    quad.String(claim),
 	}
 	id := c.ID()
 	signature, err := crypto.Sign(id[:], testKey)
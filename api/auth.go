@@ -0,0 +1,286 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/registry"
+)
+
+// Headers of requests signed by a KORD ID.
+const (
+	HeaderKordID        = "X-Kord-Id"
+	HeaderKordTimestamp = "X-Kord-Timestamp"
+	HeaderKordNonce     = "X-Kord-Nonce"
+	HeaderKordSignature = "X-Kord-Signature"
+)
+
+// MaxRequestAge is the maximum difference between the timestamp of a
+// signed request and the node's clock.
+const MaxRequestAge = 5 * time.Minute
+
+// maxNonceLen is the maximum length of the nonce of a signed request.
+const maxNonceLen = 64
+
+// ErrCodeUnauthenticated is the error code of mutations which are rejected
+// because the request is not authenticated.
+const ErrCodeUnauthenticated = "UNAUTHENTICATED"
+
+// delegatePredicate links the KORD ID which owns a graph to the KORD IDs
+// which can also mutate it.
+const delegatePredicate = quad.IRI("kord:delegate")
+
+var errUnauthenticated = errors.New("mutations require an API token or a request signed by a KORD ID")
+
+// Auth authenticates requests to the API.
+//
+// Requests are either authenticated by the node operator using an API token
+// in a bearer Authorization header, or signed by a KORD ID using the
+// X-Kord-* headers. Mutations must be authenticated, and can only be run by
+// the node operator or by the KORD ID which owns the graph being mutated or
+// one of its delegates. Queries of graphs with a restricted read policy
+// must also be authenticated, see authorizeRead.
+type Auth struct {
+	// Tokens are the API tokens of the node operator
+	Tokens []string
+
+	// nonces records when the nonces of signed requests can be forgotten,
+	// which is once their timestamp is too old for them to be replayed
+	nonces   map[string]time.Time
+	nonceMtx sync.Mutex
+}
+
+// Principal is the authenticated sender of a request.
+type Principal struct {
+	// Operator is whether the request was authenticated with an API token
+	Operator bool
+
+	// ID is the KORD ID which signed the request
+	ID common.Address
+}
+
+// RequestHash returns the hash which a KORD ID signs to authenticate a
+// request, which is the EIP-191 hash of a message containing the request
+// method, host and path, the request timestamp (in seconds since the Unix
+// epoch), a nonce which is unique to the request and the hash of the body.
+func RequestHash(method, host, path string, timestamp int64, nonce string, body []byte) common.Hash {
+	if path == "" {
+		path = "/"
+	}
+	msg := fmt.Sprintf("KORD API request %s %s%s %d %s %s", method, host, path, timestamp, nonce, crypto.Keccak256Hash(body).Hex())
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(msg), msg)))
+}
+
+// authenticate returns the sender of the request, or nil if the request
+// has no authentication headers.
+func (a *Auth) authenticate(r *http.Request, body []byte) (*Principal, error) {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if !strings.HasPrefix(h, "Bearer ") {
+			return nil, errors.New("unsupported Authorization header, expected a bearer token")
+		}
+		token := []byte(strings.TrimPrefix(h, "Bearer "))
+		for _, t := range a.Tokens {
+			if t != "" && subtle.ConstantTimeCompare([]byte(t), token) == 1 {
+				return &Principal{Operator: true}, nil
+			}
+		}
+		return nil, errors.New("invalid API token")
+	}
+
+	id := r.Header.Get(HeaderKordID)
+	if id == "" {
+		return nil, nil
+	}
+	if !common.IsHexAddress(id) {
+		return nil, fmt.Errorf("invalid %s header, must be a hex string: %s", HeaderKordID, id)
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(HeaderKordTimestamp), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %s", HeaderKordTimestamp, err)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > MaxRequestAge || age < -MaxRequestAge {
+		return nil, fmt.Errorf("request timestamp differs from the node's clock by more than %s", MaxRequestAge)
+	}
+	nonce := r.Header.Get(HeaderKordNonce)
+	if nonce == "" || len(nonce) > maxNonceLen {
+		return nil, fmt.Errorf("invalid %s header, must be between 1 and %d characters", HeaderKordNonce, maxNonceLen)
+	}
+	sig, err := hexutil.Decode(r.Header.Get(HeaderKordSignature))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %s", HeaderKordSignature, err)
+	}
+	hash := RequestHash(r.Method, r.Host, r.URL.Path, timestamp, nonce, body)
+	signer, err := registry.RecoverKordID(hash, sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request signature: %s", err)
+	}
+	if signer != common.HexToAddress(id) {
+		return nil, errors.New("invalid request signature")
+	}
+	if !a.useNonce(signer, nonce, timestamp) {
+		return nil, errors.New("request nonce has already been used")
+	}
+	return &Principal{ID: signer}, nil
+}
+
+// useNonce records the nonce of a signed request, returning false if the
+// KORD ID has already sent a request with the nonce. Nonces are forgotten
+// once the timestamp of the request is older than MaxRequestAge, since the
+// request would then be rejected anyway.
+func (a *Auth) useNonce(id common.Address, nonce string, timestamp int64) bool {
+	a.nonceMtx.Lock()
+	defer a.nonceMtx.Unlock()
+	now := time.Now()
+	if a.nonces == nil {
+		a.nonces = make(map[string]time.Time)
+	}
+	for key, expiry := range a.nonces {
+		if now.After(expiry) {
+			delete(a.nonces, key)
+		}
+	}
+	key := id.Hex() + "/" + nonce
+	if _, ok := a.nonces[key]; ok {
+		return false
+	}
+	a.nonces[key] = time.Unix(timestamp, 0).Add(MaxRequestAge)
+	return true
+}
+
+// authorizeOwner returns an error unless the request was sent by the node
+// operator or the KORD ID which owns the graph.
+func (r *Resolver) authorizeOwner(ctx context.Context, id string) error {
+	return r.authorize(ctx, id, false)
+}
+
+// authorizeWrite returns an error unless the request was sent by the node
+// operator, the KORD ID which owns the graph or one of its delegates.
+func (r *Resolver) authorizeWrite(ctx context.Context, id string) error {
+	return r.authorize(ctx, id, true)
+}
+
+func (r *Resolver) authorize(ctx context.Context, id string, delegates bool) error {
+	p, _ := ctx.Value("principal").(*Principal)
+	if p == nil {
+		return errUnauthenticated
+	}
+	if p.Operator {
+		return nil
+	}
+	if !common.IsHexAddress(id) {
+		return fmt.Errorf("graph %s can only be mutated by the node operator", id)
+	}
+	owner := common.HexToAddress(id)
+	if p.ID == owner {
+		return nil
+	}
+	if !delegates {
+		return fmt.Errorf("%s is not the owner of graph %s", p.ID.Hex(), id)
+	}
+	qs, err := openGraph(ctx, r.driver, id)
+	if err != nil {
+		return err
+	}
+	ids, err := loadDelegates(ctx, qs, owner)
+	if err != nil {
+		return err
+	}
+	for _, delegate := range ids {
+		if delegate == p.ID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not the owner or a delegate of graph %s", p.ID.Hex(), id)
+}
+
+// loadDelegates loads the delegates of the owner of a graph.
+func loadDelegates(ctx context.Context, qs graph.QuadStore, owner common.Address) ([]common.Address, error) {
+	values, err := path.StartPath(qs, quad.IRI(owner.Hex())).Out(delegatePredicate).Iterate(ctx).AllValues(qs)
+	if err != nil {
+		return nil, err
+	}
+	var delegates []common.Address
+	for _, v := range values {
+		if iri, ok := v.(quad.IRI); ok && common.IsHexAddress(string(iri)) {
+			delegates = append(delegates, common.HexToAddress(string(iri)))
+		}
+	}
+	return delegates, nil
+}
+
+// Delegates returns the KORD IDs which can mutate the graph on behalf of
+// its owner.
+func (r *GraphResolver) Delegates(ctx context.Context) ([]string, error) {
+	if !common.IsHexAddress(r.id) {
+		return []string{}, nil
+	}
+	delegates, err := loadDelegates(ctx, r.qs, common.HexToAddress(r.id))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(delegates))
+	for i, delegate := range delegates {
+		ids[i] = delegate.Hex()
+	}
+	return ids, nil
+}
+
+// DelegateArgs are the arguments for GraphQL addDelegate and removeDelegate
+// mutations.
+type DelegateArgs struct {
+	Input DelegateInput
+}
+
+// AddDelegate allows the delegate to mutate the graph, and can only be run
+// by the owner of the graph.
+func (r *Resolver) AddDelegate(ctx context.Context, args DelegateArgs) (*GraphResolver, error) {
+	q, err := args.Input.quad()
+	if err != nil {
+		return nil, err
+	}
+	deltas := []graph.Delta{{Quad: q, Action: graph.Add}}
+	return r.applyDeltas(ctx, args.Input.Graph, deltas, graph.IgnoreOpts{IgnoreDup: true})
+}
+
+// RemoveDelegate stops the delegate from mutating the graph, and can only
+// be run by the owner of the graph.
+func (r *Resolver) RemoveDelegate(ctx context.Context, args DelegateArgs) (*GraphResolver, error) {
+	q, err := args.Input.quad()
+	if err != nil {
+		return nil, err
+	}
+	deltas := []graph.Delta{{Quad: q, Action: graph.Delete}}
+	return r.applyDeltas(ctx, args.Input.Graph, deltas, graph.IgnoreOpts{})
+}
@@ -0,0 +1,197 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cayleygraph "github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+func TestAuth(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	driver := graph.NewDriver("kord-auth-test", ts, testutil.NewTestRegistry(), ts.Dir)
+	api, err := NewAPI(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Auth.Tokens = []string{testAPIToken}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	id := testKordID.Hex()
+
+	// check mutations are rejected without a valid token
+	if _, err := NewClient(srv.URL).CreateGraph(id); err == nil || !strings.Contains(err.Error(), ErrCodeUnauthenticated) {
+		t.Fatalf("expected unauthenticated error, got %v", err)
+	}
+	client := NewClient(srv.URL)
+	client.SetToken("invalid")
+	if _, err := client.CreateGraph(id); err == nil || !strings.Contains(err.Error(), "invalid API token") {
+		t.Fatalf("expected invalid token error, got %v", err)
+	}
+
+	// check the operator can create the graph
+	client.SetToken(testAPIToken)
+	if _, err := client.CreateGraph(id); err != nil {
+		t.Fatal(err)
+	}
+
+	// check queries do not need to be authenticated
+	if _, err := NewClient(srv.URL).Delegates(id); err != nil {
+		t.Fatal(err)
+	}
+
+	newSigner := func(key *ecdsa.PrivateKey) *Client {
+		client := NewClient(srv.URL)
+		client.SetSigner(crypto.PubkeyToAddress(key.PublicKey), func(hash common.Hash) ([]byte, error) {
+			return crypto.Sign(hash[:], key)
+		})
+		return client
+	}
+	owner := newSigner(testKey)
+	delegateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	delegateID := crypto.PubkeyToAddress(delegateKey.PublicKey)
+	delegate := newSigner(delegateKey)
+	addQuad := func(client *Client, object string) error {
+		q := quad.Make(quad.IRI("http://example.com/alice"), quad.IRI("http://xmlns.com/foaf/0.1/name"), quad.String(object), nil)
+		_, err := client.ApplyDeltas(id, []cayleygraph.Delta{{Quad: q, Action: cayleygraph.Add}}, cayleygraph.IgnoreOpts{})
+		return err
+	}
+
+	// check the owner can mutate the graph but other KORD IDs cannot
+	if err := addQuad(owner, "owner"); err != nil {
+		t.Fatal(err)
+	}
+	if err := addQuad(delegate, "delegate"); err == nil || !strings.Contains(err.Error(), "is not the owner or a delegate") {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+
+	// check delegates can mutate the graph once added by the owner
+	if _, err := delegate.AddDelegate(id, delegateID); err == nil {
+		t.Fatal("expected error adding delegate as non-owner")
+	}
+	if _, err := owner.AddDelegate(id, delegateID); err != nil {
+		t.Fatal(err)
+	}
+	delegates, err := client.Delegates(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(delegates) != 1 || delegates[0] != delegateID {
+		t.Fatalf("expected delegates to be [%s], got %v", delegateID.Hex(), delegates)
+	}
+	if err := addQuad(delegate, "delegate"); err != nil {
+		t.Fatal(err)
+	}
+
+	// check delegates cannot change the delegates of the graph
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := delegate.AddDelegate(id, crypto.PubkeyToAddress(otherKey.PublicKey)); err == nil || !strings.Contains(err.Error(), "is not the owner") {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+
+	// check removed delegates can no longer mutate the graph
+	if _, err := owner.RemoveDelegate(id, delegateID); err != nil {
+		t.Fatal(err)
+	}
+	if err := addQuad(delegate, "removed"); err == nil {
+		t.Fatal("expected error mutating graph as removed delegate")
+	}
+
+	// check requests with stale timestamps, modified bodies, reused nonces
+	// or which were signed for another endpoint are rejected
+	srvURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type signedRequest struct {
+		host      string
+		path      string
+		timestamp int64
+		nonce     string
+		body      []byte
+	}
+	post := func(signed *signedRequest, body []byte) int {
+		hash := RequestHash("POST", signed.host, signed.path, signed.timestamp, signed.nonce, signed.body)
+		sig, err := crypto.Sign(hash[:], testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest("POST", srv.URL, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(HeaderKordID, id)
+		req.Header.Set(HeaderKordTimestamp, strconv.FormatInt(signed.timestamp, 10))
+		req.Header.Set(HeaderKordNonce, signed.nonce)
+		req.Header.Set(HeaderKordSignature, hexutil.Encode(sig))
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	body := []byte(`{"query":"mutation { createGraph(input: {id: \"` + id + `\"}) { id } }"}`)
+	now := time.Now().Unix()
+	newRequest := func(nonce string) *signedRequest {
+		return &signedRequest{host: srvURL.Host, path: "/", timestamp: now, nonce: nonce, body: body}
+	}
+	if status := post(newRequest("1"), body); status != http.StatusOK {
+		t.Fatalf("expected HTTP status %d, got %d", http.StatusOK, status)
+	}
+	for name, req := range map[string]*signedRequest{
+		"replayed":       newRequest("1"),
+		"stale":          {host: srvURL.Host, path: "/", timestamp: time.Now().Add(-2 * MaxRequestAge).Unix(), nonce: "2", body: body},
+		"modified":       {host: srvURL.Host, path: "/", timestamp: now, nonce: "3", body: []byte("{}")},
+		"other path":     {host: srvURL.Host, path: "/other", timestamp: now, nonce: "4", body: body},
+		"other host":     {host: "example.com", path: "/", timestamp: now, nonce: "5", body: body},
+		"missing nonce":  newRequest(""),
+		"oversize nonce": newRequest(strings.Repeat("0", maxNonceLen+1)),
+	} {
+		if status := post(req, body); status != http.StatusUnauthorized {
+			t.Fatalf("expected HTTP status %d for %s request, got %d", http.StatusUnauthorized, name, status)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/schema"
+)
+
+// Value types of claim schemas which are not datatype IRIs.
+const (
+	StringValueType     = "string"
+	IRIValueType        = "iri"
+	LangStringValueType = "langString"
+)
+
+// ClaimSchema constrains the values of claims with a particular property
+// in a graph.
+type ClaimSchema struct {
+	// Property is the claim property the schema applies to.
+	Property string
+
+	// ValueType is either StringValueType, IRIValueType,
+	// LangStringValueType or the datatype IRI of typed literal values.
+	ValueType string
+
+	// Pattern is an optional regular expression which the lexical form
+	// of values must match.
+	Pattern string
+}
+
+// Validate checks that the schema is well formed.
+func (s *ClaimSchema) Validate() error {
+	if s.Property == "" {
+		return errors.New("invalid claim schema: missing property")
+	}
+	if s.ValueType == "" {
+		return errors.New("invalid claim schema: missing value type")
+	}
+	if s.Pattern != "" {
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("invalid claim schema pattern: %s", err)
+		}
+	}
+	return nil
+}
+
+// Check returns an error if the claim value does not conform to the
+// schema.
+func (s *ClaimSchema) Check(v quad.Value) error {
+	value, typ, lang := ClaimValueParts(v)
+	switch s.ValueType {
+	case StringValueType:
+		if typ != "" || lang != "" {
+			return fmt.Errorf("invalid %q claim: expected a plain string", s.Property)
+		}
+	case IRIValueType:
+		if typ != IRIType {
+			return fmt.Errorf("invalid %q claim: expected an IRI", s.Property)
+		}
+	case LangStringValueType:
+		if lang == "" {
+			return fmt.Errorf("invalid %q claim: expected a language string", s.Property)
+		}
+	default:
+		if typ != string(quad.IRI(s.ValueType).Full()) {
+			return fmt.Errorf("invalid %q claim: expected a value of type %s", s.Property, s.ValueType)
+		}
+		if err := checkLexicalForm(value, typ); err != nil {
+			return fmt.Errorf("invalid %q claim: %s", s.Property, err)
+		}
+	}
+	if s.Pattern != "" {
+		if !regexp.MustCompile(s.Pattern).MatchString(value) {
+			return fmt.Errorf("invalid %q claim: %q does not match %s", s.Property, value, s.Pattern)
+		}
+	}
+	return nil
+}
+
+// checkLexicalForm checks the lexical form of values with common XSD
+// datatypes.
+func checkLexicalForm(value, typ string) error {
+	var err error
+	switch typ {
+	case nsXSD + "integer", nsXSD + "long", nsXSD + "int":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case nsXSD + "decimal", nsXSD + "double", nsXSD + "float":
+		_, err = strconv.ParseFloat(value, 64)
+	case nsXSD + "boolean":
+		_, err = strconv.ParseBool(value)
+	case nsXSD + "dateTime":
+		_, err = time.Parse(time.RFC3339, value)
+	case nsXSD + "date":
+		_, err = time.Parse("2006-01-02", value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q", typ, value)
+	}
+	return nil
+}
+
+type claimSchemaQuad struct {
+	rdfType struct{} `quad:"@type > kord:ClaimSchema"`
+
+	ID        quad.IRI `quad:"@id"`
+	Property  string   `quad:"kord:schemaProperty"`
+	ValueType string   `quad:"kord:valueType"`
+	Pattern   string   `quad:"kord:pattern,optional"`
+}
+
+func claimSchemaID(property string) quad.IRI {
+	return quad.IRI("urn:kord:claim-schema:" + url.PathEscape(property))
+}
+
+// loadClaimSchema loads the schema for the property from the graph,
+// returning nil if there is none.
+func loadClaimSchema(ctx context.Context, qs graph.QuadStore, property string) (*ClaimSchema, error) {
+	schemas, err := loadClaimSchemas(ctx, qs, path.StartPath(qs, claimSchemaID(property)))
+	if err != nil || len(schemas) == 0 {
+		return nil, err
+	}
+	return schemas[0], nil
+}
+
+func loadClaimSchemas(ctx context.Context, qs graph.QuadStore, p *path.Path) ([]*ClaimSchema, error) {
+	var quads []claimSchemaQuad
+	if err := schema.LoadPathTo(ctx, qs, &quads, p); err != nil {
+		return nil, err
+	}
+	schemas := make([]*ClaimSchema, len(quads))
+	for i, v := range quads {
+		schemas[i] = &ClaimSchema{
+			Property:  v.Property,
+			ValueType: v.ValueType,
+			Pattern:   v.Pattern,
+		}
+	}
+	return schemas, nil
+}
+
+// writeClaimSchema registers a claim schema in the graph, returning an
+// error if the property already has a schema.
+func writeClaimSchema(ctx context.Context, qs graph.QuadStore, s *ClaimSchema) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	existing, err := loadClaimSchema(ctx, qs, s.Property)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("claim schema already registered for %q", s.Property)
+	}
+	qw, err := graph.NewQuadWriter("single", qs, nil)
+	if err != nil {
+		return err
+	}
+	w := graph.NewWriter(qw)
+	if _, err := schema.WriteAsQuads(w, &claimSchemaQuad{
+		ID:        claimSchemaID(s.Property),
+		Property:  s.Property,
+		ValueType: s.ValueType,
+		Pattern:   s.Pattern,
+	}); err != nil {
+		return err
+	}
+	return w.Flush()
+}
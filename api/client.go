@@ -20,11 +20,19 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"time"
 
+	cayleygraph "github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kord-network/go-kord/dapp"
 	"github.com/kord-network/go-kord/graphql"
 )
 
@@ -36,6 +44,40 @@ func NewClient(url string) *Client {
 	return &Client{graphql.NewClient(url)}
 }
 
+// SetToken authenticates requests using an API token of the node operator.
+func (c *Client) SetToken(token string) {
+	c.Authorize = func(req *http.Request, body []byte) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// SetSigner authenticates requests by signing them as the KORD ID, using
+// the sign function to sign the request hash with the KORD ID's key.
+func (c *Client) SetSigner(id common.Address, sign func(common.Hash) ([]byte, error)) {
+	c.Authorize = func(req *http.Request, body []byte) error {
+		timestamp := time.Now().Unix()
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		host := req.Host
+		if host == "" {
+			host = req.URL.Host
+		}
+		hash := RequestHash(req.Method, host, req.URL.Path, timestamp, hex.EncodeToString(nonce), body)
+		sig, err := sign(hash)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(HeaderKordID, id.Hex())
+		req.Header.Set(HeaderKordTimestamp, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(HeaderKordNonce, hex.EncodeToString(nonce))
+		req.Header.Set(HeaderKordSignature, hexutil.Encode(sig))
+		return nil
+	}
+}
+
 func (c *Client) CreateGraph(graph string) (common.Hash, error) {
 	query := `
 mutation CreateGraph($input: GraphInput!) {
@@ -80,23 +122,89 @@ mutation CreateClaim($input: ClaimInput!) {
     subject
     property
     claim
+    claimType
+    claimLanguage
     signature
   }
 }
 `
-	variables := graphql.Variables{"input": &ClaimInput{
+	input := newClaimInput(graph, claim)
+	variables := graphql.Variables{"input": input}
+	res, err := c.Do(query, variables, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return swarmHash(res)
+}
+
+// CreateClaims creates a batch of claims in the graph with a single commit,
+// returning the resulting Swarm hash of the graph along with an error for
+// each claim which was not created (nil for those which were). The hash is
+// zero if no claims were created.
+func (c *Client) CreateClaims(graph string, claims []*Claim) (common.Hash, []error, error) {
+	query := `
+mutation CreateClaims($input: [ClaimInput!]!) {
+  createClaims(input: $input) {
+    claim {
+      id
+    }
+    error
+  }
+}
+`
+	inputs := make([]*ClaimInput, len(claims))
+	for i, claim := range claims {
+		inputs[i] = newClaimInput(graph, claim)
+	}
+	variables := graphql.Variables{"input": inputs}
+	var v struct {
+		Results []struct {
+			Error *string `json:"error"`
+		} `json:"createClaims"`
+	}
+	res, err := c.Do(query, variables, &v)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	if len(v.Results) != len(claims) {
+		return common.Hash{}, nil, fmt.Errorf("expected %d claim results, got %d", len(claims), len(v.Results))
+	}
+	errs := make([]error, len(claims))
+	created := false
+	for i, result := range v.Results {
+		if result.Error != nil {
+			errs[i] = errors.New(*result.Error)
+		} else {
+			created = true
+		}
+	}
+	if !created {
+		return common.Hash{}, errs, nil
+	}
+	hash, err := swarmHash(res)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	return hash, errs, nil
+}
+
+func newClaimInput(graph string, claim *Claim) *ClaimInput {
+	value, typ, lang := ClaimValueParts(claim.Claim)
+	input := &ClaimInput{
 		Graph:     graph,
 		Issuer:    claim.Issuer.Hex(),
 		Subject:   claim.Subject.Hex(),
 		Property:  claim.Property,
-		Claim:     claim.Claim,
+		Claim:     value,
 		Signature: hexutil.Encode(claim.Signature),
-	}}
-	res, err := c.Do(query, variables, nil)
-	if err != nil {
-		return common.Hash{}, err
 	}
-	return swarmHash(res)
+	if typ != "" {
+		input.ClaimType = &typ
+	}
+	if lang != "" {
+		input.ClaimLanguage = &lang
+	}
+	return input
 }
 
 func (c *Client) Claim(graph string, filter *ClaimFilter) ([]*Claim, error) {
@@ -109,6 +217,8 @@ query GetClaim($id: String!, $filter: ClaimFilter!) {
       subject
       property
       claim
+      claimType
+      claimLanguage
       signature
     }
   }
@@ -126,6 +236,401 @@ query GetClaim($id: String!, $filter: ClaimFilter!) {
 	return v.Graph.Claims, nil
 }
 
+// ClaimByID returns the claim with the given ID from any graph the node has
+// opened, or nil if the claim is not found.
+func (c *Client) ClaimByID(id common.Hash) (*Claim, error) {
+	query := `
+query ClaimByID($id: String!) {
+  claimByID(id: $id) {
+    id
+    issuer
+    subject
+    property
+    claim
+    claimType
+    claimLanguage
+    signature
+  }
+}
+`
+	variables := graphql.Variables{"id": id.String()}
+	var v struct {
+		Claim *Claim `json:"claimByID"`
+	}
+	if _, err := c.Do(query, variables, &v); err != nil {
+		return nil, err
+	}
+	return v.Claim, nil
+}
+
+// Claims returns the claims about the given subject from all the graphs the
+// node has opened.
+func (c *Client) Claims(subject string) ([]*Claim, error) {
+	query := `
+query Claims($subject: String!) {
+  claims(subject: $subject) {
+    id
+    issuer
+    subject
+    property
+    claim
+    claimType
+    claimLanguage
+    signature
+  }
+}
+`
+	variables := graphql.Variables{"subject": subject}
+	var v struct {
+		Claims []*Claim `json:"claims"`
+	}
+	if _, err := c.Do(query, variables, &v); err != nil {
+		return nil, err
+	}
+	return v.Claims, nil
+}
+
+// RegisterClaimSchema registers a claim schema in the graph, returning the
+// resulting Swarm hash of the graph.
+func (c *Client) RegisterClaimSchema(graph string, schema *ClaimSchema) (common.Hash, error) {
+	query := `
+mutation RegisterClaimSchema($input: ClaimSchemaInput!) {
+  registerClaimSchema(input: $input) {
+    property
+  }
+}
+`
+	input := &ClaimSchemaInput{
+		Graph:     graph,
+		Property:  schema.Property,
+		ValueType: schema.ValueType,
+	}
+	if schema.Pattern != "" {
+		input.Pattern = &schema.Pattern
+	}
+	res, err := c.Do(query, graphql.Variables{"input": input}, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return swarmHash(res)
+}
+
+// Quads returns a page of at most first quads in the graph which match the
+// filter, starting after the given cursor (which is empty for the first
+// page).
+func (c *Client) Quads(graph string, filter *QuadFilter, first int, after string) (*QuadPage, error) {
+	query := `
+query GetQuads($id: String!, $subject: String, $predicate: String, $object: String, $label: String, $first: Int, $after: String) {
+  graph(id: $id) {
+    quads(subject: $subject, predicate: $predicate, object: $object, label: $label, first: $first, after: $after) {
+      quads {
+        subject
+        predicate
+        object
+        label
+      }
+      endCursor
+      hasNextPage
+    }
+  }
+}
+`
+	if filter == nil {
+		filter = &QuadFilter{}
+	}
+	variables := graphql.Variables{
+		"id":        graph,
+		"subject":   filter.Subject,
+		"predicate": filter.Predicate,
+		"object":    filter.Object,
+		"label":     filter.Label,
+		"first":     first,
+	}
+	if after != "" {
+		variables["after"] = after
+	}
+	var v struct {
+		Graph struct {
+			Quads struct {
+				Quads       []QuadInput `json:"quads"`
+				EndCursor   string      `json:"endCursor"`
+				HasNextPage bool        `json:"hasNextPage"`
+			} `json:"quads"`
+		} `json:"graph"`
+	}
+	if _, err := c.Do(query, variables, &v); err != nil {
+		return nil, err
+	}
+	page := &QuadPage{
+		Quads:       make([]quad.Quad, len(v.Graph.Quads.Quads)),
+		EndCursor:   v.Graph.Quads.EndCursor,
+		HasNextPage: v.Graph.Quads.HasNextPage,
+	}
+	for i, q := range v.Graph.Quads.Quads {
+		var err error
+		page.Quads[i], err = q.ToQuad()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// ApplyDeltas adds and deletes quads in the graph, returning the resulting
+// Swarm hash of the graph.
+func (c *Client) ApplyDeltas(graph string, deltas []cayleygraph.Delta, opts cayleygraph.IgnoreOpts) (common.Hash, error) {
+	query := `
+mutation ApplyDeltas($input: DeltasInput!) {
+  applyDeltas(input: $input) {
+    id
+  }
+}
+`
+	input := &DeltasInput{
+		Graph:            graph,
+		Deltas:           make([]DeltaInput, len(deltas)),
+		IgnoreDuplicates: &opts.IgnoreDup,
+		IgnoreMissing:    &opts.IgnoreMissing,
+	}
+	for i, d := range deltas {
+		action := AddAction
+		if d.Action == cayleygraph.Delete {
+			action = DeleteAction
+		}
+		input.Deltas[i] = DeltaInput{Action: action, Quad: NewQuadInput(d.Quad)}
+	}
+	res, err := c.Do(query, graphql.Variables{"input": input}, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return swarmHash(res)
+}
+
+// DeleteQuads deletes quads from the graph, returning the resulting Swarm
+// hash of the graph.
+func (c *Client) DeleteQuads(graph string, quads []quad.Quad) (common.Hash, error) {
+	query := `
+mutation DeleteQuads($input: QuadsInput!) {
+  deleteQuads(input: $input) {
+    id
+  }
+}
+`
+	input := &QuadsInput{
+		Graph: graph,
+		Quads: make([]QuadInput, len(quads)),
+	}
+	for i, q := range quads {
+		input.Quads[i] = NewQuadInput(q)
+	}
+	res, err := c.Do(query, graphql.Variables{"input": input}, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return swarmHash(res)
+}
+
+// AddDelegate allows the delegate to mutate the graph on behalf of its
+// owner.
+func (c *Client) AddDelegate(graph string, delegate common.Address) (common.Hash, error) {
+	return c.setDelegate("addDelegate", graph, delegate)
+}
+
+// RemoveDelegate stops the delegate from mutating the graph.
+func (c *Client) RemoveDelegate(graph string, delegate common.Address) (common.Hash, error) {
+	return c.setDelegate("removeDelegate", graph, delegate)
+}
+
+func (c *Client) setDelegate(mutation, graph string, delegate common.Address) (common.Hash, error) {
+	query := fmt.Sprintf(`
+mutation SetDelegate($input: DelegateInput!) {
+  %s(input: $input) {
+    id
+  }
+}
+`, mutation)
+	variables := graphql.Variables{"input": &DelegateInput{
+		Graph:    graph,
+		Delegate: delegate.Hex(),
+	}}
+	res, err := c.Do(query, variables, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return swarmHash(res)
+}
+
+// Delegates returns the delegates of the graph.
+func (c *Client) Delegates(graph string) ([]common.Address, error) {
+	query := `
+query Delegates($graph: String!) {
+  graph(id: $graph) {
+    delegates
+  }
+}
+`
+	var v struct {
+		Graph struct {
+			Delegates []common.Address `json:"delegates"`
+		} `json:"graph"`
+	}
+	if _, err := c.Do(query, graphql.Variables{"graph": graph}, &v); err != nil {
+		return nil, err
+	}
+	return v.Graph.Delegates, nil
+}
+
+// SetReadPolicy sets the read policy of the graph, either ReadPolicyPublic
+// or ReadPolicyRestricted.
+func (c *Client) SetReadPolicy(graph, policy string) (common.Hash, error) {
+	query := `
+mutation SetReadPolicy($input: ReadPolicyInput!) {
+  setReadPolicy(input: $input) {
+    id
+  }
+}
+`
+	variables := graphql.Variables{"input": &ReadPolicyInput{
+		Graph:  graph,
+		Policy: policy,
+	}}
+	res, err := c.Do(query, variables, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return swarmHash(res)
+}
+
+// ReadPolicy returns the read policy of the graph.
+func (c *Client) ReadPolicy(graph string) (string, error) {
+	query := `
+query ReadPolicy($graph: String!) {
+  graph(id: $graph) {
+    readPolicy
+  }
+}
+`
+	var v struct {
+		Graph struct {
+			ReadPolicy string `json:"readPolicy"`
+		} `json:"graph"`
+	}
+	if _, err := c.Do(query, graphql.Variables{"graph": graph}, &v); err != nil {
+		return "", err
+	}
+	return v.Graph.ReadPolicy, nil
+}
+
+// AddReader allows the reader to read the graph if it is restricted.
+func (c *Client) AddReader(graph string, reader common.Address) (common.Hash, error) {
+	return c.setReader("addReader", graph, reader)
+}
+
+// RemoveReader stops the reader from reading the graph if it is
+// restricted.
+func (c *Client) RemoveReader(graph string, reader common.Address) (common.Hash, error) {
+	return c.setReader("removeReader", graph, reader)
+}
+
+func (c *Client) setReader(mutation, graph string, reader common.Address) (common.Hash, error) {
+	query := fmt.Sprintf(`
+mutation SetReader($input: ReaderInput!) {
+  %s(input: $input) {
+    id
+  }
+}
+`, mutation)
+	variables := graphql.Variables{"input": &ReaderInput{
+		Graph:  graph,
+		Reader: reader.Hex(),
+	}}
+	res, err := c.Do(query, variables, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return swarmHash(res)
+}
+
+// Readers returns the readers of the graph.
+func (c *Client) Readers(graph string) ([]common.Address, error) {
+	query := `
+query Readers($graph: String!) {
+  graph(id: $graph) {
+    readers
+  }
+}
+`
+	var v struct {
+		Graph struct {
+			Readers []common.Address `json:"readers"`
+		} `json:"graph"`
+	}
+	if _, err := c.Do(query, graphql.Variables{"graph": graph}, &v); err != nil {
+		return nil, err
+	}
+	return v.Graph.Readers, nil
+}
+
+const dappFields = `
+  uri
+  manifestHash
+  name
+  description
+  version
+  publisher
+  icon
+  permissions
+`
+
+// Dapps returns the deployed version of the dapps in the graph.
+func (c *Client) Dapps(graph string) ([]*dapp.Dapp, error) {
+	query := `
+query Dapps($graph: String!) {
+  graph(id: $graph) {
+    dapps {` + dappFields + `}
+  }
+}
+`
+	var v struct {
+		Graph struct {
+			Dapps []*dapp.Dapp `json:"dapps"`
+		} `json:"graph"`
+	}
+	if _, err := c.Do(query, graphql.Variables{"graph": graph}, &v); err != nil {
+		return nil, err
+	}
+	return v.Graph.Dapps, nil
+}
+
+// DappVersions returns the versions of the dapp with the given URI,
+// starting with the deployed version, or dapp.ErrNotFound if the dapp does
+// not exist.
+func (c *Client) DappVersions(graph, uri string) ([]*dapp.Dapp, error) {
+	query := `
+query DappVersions($graph: String!, $uri: String!) {
+  graph(id: $graph) {
+    dapp(uri: $uri) {
+      versions {` + dappFields + `}
+    }
+  }
+}
+`
+	var v struct {
+		Graph struct {
+			Dapp *struct {
+				Versions []*dapp.Dapp `json:"versions"`
+			} `json:"dapp"`
+		} `json:"graph"`
+	}
+	if _, err := c.Do(query, graphql.Variables{"graph": graph, "uri": uri}, &v); err != nil {
+		return nil, err
+	}
+	if v.Graph.Dapp == nil {
+		return nil, dapp.ErrNotFound
+	}
+	return v.Graph.Dapp.Versions, nil
+}
+
 func swarmHash(res *graphql.Response) (common.Hash, error) {
 	extension, ok := res.Extensions["kord"]
 	if !ok {
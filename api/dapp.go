@@ -0,0 +1,132 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/kord-network/go-kord/dapp"
+)
+
+// Dapps returns the deployed version of the dapps in the graph.
+func (r *GraphResolver) Dapps(ctx context.Context) ([]*DappResolver, error) {
+	dapps, err := dapp.List(ctx, r.qs)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*DappResolver, len(dapps))
+	for i, d := range dapps {
+		resolvers[i] = &DappResolver{r.qs, d}
+	}
+	return resolvers, nil
+}
+
+// DappArgs are the arguments for the GraphQL dapp field.
+type DappArgs struct {
+	URI string
+}
+
+// Dapp returns the deployed version of the dapp with the given URI.
+func (r *GraphResolver) Dapp(ctx context.Context, args DappArgs) (*DappResolver, error) {
+	d, err := dapp.Load(ctx, r.qs, quad.IRI(args.URI))
+	if err == dapp.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &DappResolver{r.qs, d}, nil
+}
+
+// DappResolver defines GraphQL resolver functions for Dapp fields.
+type DappResolver struct {
+	qs   graph.QuadStore
+	dapp *dapp.Dapp
+}
+
+func (d *DappResolver) URI() string {
+	return string(d.dapp.URI())
+}
+
+func (d *DappResolver) ManifestHash() string {
+	return d.dapp.ManifestHash
+}
+
+func (d *DappResolver) Name() *string {
+	return optionalString(d.dapp.Name)
+}
+
+func (d *DappResolver) Description() *string {
+	return optionalString(d.dapp.Description)
+}
+
+func (d *DappResolver) Version() *string {
+	return optionalString(d.dapp.Version)
+}
+
+func (d *DappResolver) Publisher() *string {
+	return optionalString(string(d.dapp.Publisher))
+}
+
+func (d *DappResolver) Icon() *string {
+	return optionalString(d.dapp.Icon)
+}
+
+func (d *DappResolver) Permissions() []string {
+	if d.dapp.Permissions == nil {
+		return []string{}
+	}
+	return d.dapp.Permissions
+}
+
+// Previous returns the version of the dapp which this version replaced.
+func (d *DappResolver) Previous(ctx context.Context) (*DappResolver, error) {
+	v, err := dapp.LoadPrevious(ctx, d.qs, d.dapp)
+	if err == dapp.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &DappResolver{d.qs, v}, nil
+}
+
+// Versions returns the versions of the dapp, starting with the deployed
+// version.
+func (d *DappResolver) Versions(ctx context.Context) ([]*DappResolver, error) {
+	versions, err := dapp.Versions(ctx, d.qs, d.dapp.URI())
+	if err == dapp.ErrNotFound {
+		return []*DappResolver{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*DappResolver, len(versions))
+	for i, v := range versions {
+		resolvers[i] = &DappResolver{d.qs, v}
+	}
+	return resolvers, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
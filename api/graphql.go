@@ -23,6 +23,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/path"
@@ -33,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	kordgraph "github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/registry"
 )
 
 const GraphQLSchema = `
@@ -43,6 +47,12 @@ schema {
 
 type Query {
   graph(id: String!): Graph!
+
+  claimByID(id: String!): Claim
+
+  claims(subject: String!): [Claim]!
+
+  generatedSchema: String
 }
 
 type Mutation {
@@ -51,12 +61,156 @@ type Mutation {
   setGraph(input: SetGraphInput!): Graph!
 
   createClaim(input: ClaimInput!): Claim!
+
+  createClaims(input: [ClaimInput!]!): [ClaimResult!]!
+
+  registerClaimSchema(input: ClaimSchemaInput!): ClaimSchema!
+
+  applyDeltas(input: DeltasInput!): Graph!
+
+  deleteQuads(input: QuadsInput!): Graph!
+
+  addDelegate(input: DelegateInput!): Graph!
+
+  removeDelegate(input: DelegateInput!): Graph!
+
+  setReadPolicy(input: ReadPolicyInput!): Graph!
+
+  addReader(input: ReaderInput!): Graph!
+
+  removeReader(input: ReaderInput!): Graph!
 }
 
 type Graph {
   id: String!
 
   claim(filter: ClaimFilter!): [Claim]!
+
+  trustPaths(from: String!, to: String!, depth: Int, first: Int): [TrustPath]!
+
+  trustScores(roots: [String!]!, damping: Float, iterations: Int): [TrustScore]!
+
+  claimSchemas: [ClaimSchema]!
+
+  quads(subject: String, predicate: String, object: String, label: String, first: Int, after: String): QuadConnection!
+
+  node(iri: String!): Node
+
+  delegates: [String!]!
+
+  readPolicy: ReadPolicy!
+
+  readers: [String!]!
+
+  dapps: [Dapp!]!
+
+  dapp(uri: String!): Dapp
+}
+
+type Dapp {
+  uri:          String!
+  manifestHash: String!
+  name:         String
+  description:  String
+  version:      String
+  publisher:    String
+  icon:         String
+  permissions:  [String!]!
+
+  previous: Dapp
+  versions: [Dapp!]!
+}
+
+type Quad {
+  subject:   String!
+  predicate: String!
+  object:    String!
+  label:     String
+}
+
+type QuadConnection {
+  quads:       [Quad]!
+  endCursor:   String
+  hasNextPage: Boolean!
+}
+
+type Node {
+  iri: String!
+
+  out(first: Int, after: String): QuadConnection!
+
+  in(first: Int, after: String): QuadConnection!
+}
+
+input QuadInput {
+  subject:   String!
+  predicate: String!
+  object:    String!
+  label:     String
+}
+
+enum DeltaAction {
+  ADD
+  DELETE
+}
+
+input DeltaInput {
+  action: DeltaAction!
+  quad:   QuadInput!
+}
+
+input DeltasInput {
+  graph:            String!
+  deltas:           [DeltaInput!]!
+  ignoreDuplicates: Boolean
+  ignoreMissing:    Boolean
+}
+
+input QuadsInput {
+  graph: String!
+  quads: [QuadInput!]!
+}
+
+input DelegateInput {
+  graph:    String!
+  delegate: String!
+}
+
+enum ReadPolicy {
+  PUBLIC
+  RESTRICTED
+}
+
+input ReadPolicyInput {
+  graph:  String!
+  policy: ReadPolicy!
+}
+
+input ReaderInput {
+  graph:  String!
+  reader: String!
+}
+
+type ClaimSchema {
+  property:  String!
+  valueType: String!
+  pattern:   String
+}
+
+input ClaimSchemaInput {
+  graph:     String!
+  property:  String!
+  valueType: String!
+  pattern:   String
+}
+
+type TrustPath {
+  claims: [Claim]!
+}
+
+type TrustScore {
+  id:    String!
+  score: Float!
 }
 
 input GraphInput {
@@ -67,15 +221,32 @@ input SetGraphInput {
   id:        String!
   hash:      String!
   signature: String!
+
+  # nonce is the decimal nonce covered by the signature for registries
+  # which store signed records, which the registry contract and the
+  # off-chain registry both do
+  nonce: String
 }
 
 type Claim {
   id:        String!
+  graph:     String!
   issuer:    String!
   subject:   String!
   property:  String!
   claim:     String!
   signature: String!
+
+  claimType:     String
+  claimLanguage: String
+
+  issuerClaims:  [Claim]!
+  subjectClaims: [Claim]!
+}
+
+type ClaimResult {
+  claim: Claim
+  error: String
 }
 
 input ClaimInput {
@@ -85,6 +256,9 @@ input ClaimInput {
   property:  String!
   claim:     String!
   signature: String!
+
+  claimType:     String
+  claimLanguage: String
 }
 
 input ClaimFilter {
@@ -92,32 +266,148 @@ input ClaimFilter {
   subject:  String
   property: String
   claim:    String
+
+  claimType:     String
+  claimLanguage: String
 }
 `
 
 type Resolver struct {
 	driver *kordgraph.Driver
+
+	rdfSchema    *RDFSchema
+	rdfSchemaMtx sync.RWMutex
 }
 
 func NewResolver(driver *kordgraph.Driver) *Resolver {
-	return &Resolver{driver}
+	return &Resolver{driver: driver}
+}
+
+// openGraph opens the graph until the request being served has finished,
+// or releases it straight away if the context is not that of a request
+// served by the API.
+func openGraph(ctx context.Context, driver *kordgraph.Driver, id string) (graph.QuadStore, error) {
+	qs, err := driver.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if graphs, ok := ctx.Value("graphs").(*requestGraphs); ok {
+		graphs.add(id)
+	} else {
+		driver.Release(id)
+	}
+	return qs, nil
+}
+
+// GeneratedSchema returns the GraphQL schema generated from the node's RDF
+// schema graph, or nil if the node does not have one.
+func (r *Resolver) GeneratedSchema() *string {
+	s := r.RDFSchema()
+	if s == nil {
+		return nil
+	}
+	schema := s.GraphQLSchema()
+	return &schema
+}
+
+// RDFSchema returns the schema used to generate GraphQL types.
+func (r *Resolver) RDFSchema() *RDFSchema {
+	r.rdfSchemaMtx.RLock()
+	defer r.rdfSchemaMtx.RUnlock()
+	return r.rdfSchema
+}
+
+// SetRDFSchema sets the schema used to generate GraphQL types.
+func (r *Resolver) SetRDFSchema(s *RDFSchema) {
+	r.rdfSchemaMtx.Lock()
+	defer r.rdfSchemaMtx.Unlock()
+	r.rdfSchema = s
 }
 
 type GraphArgs struct {
 	ID string
 }
 
-func (r *Resolver) Graph(args GraphArgs) (*GraphResolver, error) {
-	qs, err := r.driver.Get(args.ID)
+// Graph returns the graph with the given ID if the sender of the request is
+// allowed to read it.
+func (r *Resolver) Graph(ctx context.Context, args GraphArgs) (*GraphResolver, error) {
+	if err := authorizeRead(ctx, r.driver, args.ID); err != nil {
+		return nil, err
+	}
+	qs, err := openGraph(ctx, r.driver, args.ID)
 	if err != nil {
 		return nil, err
 	}
-	return &GraphResolver{args.ID, qs}, nil
+	return &GraphResolver{r, args.ID, qs}, nil
+}
+
+// ClaimByIDArgs are the arguments for a GraphQL claimByID query.
+type ClaimByIDArgs struct {
+	ID string
+}
+
+// ClaimByID searches the graphs the node has opened for the claim with the
+// given ID, returning nil if it is not found.
+func (r *Resolver) ClaimByID(ctx context.Context, args ClaimByIDArgs) (*ClaimResolver, error) {
+	claims, err := r.searchClaims(ctx, func(qs graph.QuadStore) *path.Path {
+		return path.StartPath(qs, quad.IRI(args.ID))
+	})
+	if err != nil || len(claims) == 0 {
+		return nil, err
+	}
+	return claims[0], nil
+}
+
+// ClaimsArgs are the arguments for a GraphQL claims query.
+type ClaimsArgs struct {
+	Subject string
+}
+
+// Claims searches the graphs the node has opened for claims about the given
+// subject.
+func (r *Resolver) Claims(ctx context.Context, args ClaimsArgs) ([]*ClaimResolver, error) {
+	return r.claimsAbout(ctx, args.Subject)
+}
+
+func (r *Resolver) claimsAbout(ctx context.Context, subject string) ([]*ClaimResolver, error) {
+	return r.searchClaims(ctx, func(qs graph.QuadStore) *path.Path {
+		return claimPath(qs, &ClaimFilter{Subject: &subject})
+	})
+}
+
+// searchClaims loads the claims matching the path returned by pathFn from
+// each graph the node has opened which the sender of the request is allowed
+// to read, ignoring duplicate claims stored in more than one graph.
+func (r *Resolver) searchClaims(ctx context.Context, pathFn func(graph.QuadStore) *path.Path) ([]*ClaimResolver, error) {
+	var resolvers []*ClaimResolver
+	seen := make(map[common.Hash]struct{})
+	for _, id := range r.driver.Graphs() {
+		if authorizeRead(ctx, r.driver, id) != nil {
+			continue
+		}
+		qs, err := openGraph(ctx, r.driver, id)
+		if err != nil {
+			return nil, err
+		}
+		claims, err := loadClaims(ctx, qs, pathFn(qs))
+		if err != nil {
+			return nil, err
+		}
+		for _, claim := range claims {
+			if _, ok := seen[claim.ID()]; ok {
+				continue
+			}
+			seen[claim.ID()] = struct{}{}
+			resolvers = append(resolvers, &ClaimResolver{r, id, claim})
+		}
+	}
+	return resolvers, nil
 }
 
 type GraphResolver struct {
-	id string
-	qs graph.QuadStore
+	resolver *Resolver
+	id       string
+	qs       graph.QuadStore
 }
 
 func (r *GraphResolver) ID() string {
@@ -130,12 +420,15 @@ type CreateGraphArgs struct {
 }
 
 func (r *Resolver) CreateGraph(ctx context.Context, args CreateGraphArgs) (*GraphResolver, error) {
+	if err := r.authorizeOwner(ctx, args.Input.ID); err != nil {
+		return nil, err
+	}
 	hash, err := r.driver.Create(args.Input.ID)
 	if err != nil {
 		return nil, err
 	}
 	ctx.Value("swarmHash").(*common.Hash).Set(hash)
-	return r.Graph(GraphArgs{ID: args.Input.ID})
+	return r.Graph(ctx, GraphArgs{ID: args.Input.ID})
 }
 
 type SetGraphArgs struct {
@@ -143,16 +436,33 @@ type SetGraphArgs struct {
 }
 
 func (r *Resolver) SetGraph(ctx context.Context, args SetGraphArgs) (*GraphResolver, error) {
+	if err := r.authorizeWrite(ctx, args.Input.ID); err != nil {
+		return nil, err
+	}
 	hash := common.HexToHash(args.Input.Hash)
 	sig, err := hexutil.Decode(args.Input.Signature)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding signature: %s", err)
 	}
-	if err := r.driver.SetGraph(hash, sig); err != nil {
+	if args.Input.Nonce != nil {
+		nonce, err := strconv.ParseUint(*args.Input.Nonce, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding nonce: %s", err)
+		}
+		err = r.driver.SetRecord(&registry.Record{
+			KordID: common.HexToAddress(args.Input.ID),
+			Hash:   hash,
+			Nonce:  nonce,
+			Sig:    sig,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err := r.driver.SetGraph(hash, sig); err != nil {
 		return nil, err
 	}
 	ctx.Value("swarmHash").(*common.Hash).Set(hash)
-	return r.Graph(GraphArgs{ID: args.Input.ID})
+	return r.Graph(ctx, GraphArgs{ID: args.Input.ID})
 }
 
 // ClaimArgs are the arguments for a GraphQL claim query.
@@ -160,40 +470,157 @@ type ClaimArgs struct {
 	Filter ClaimFilter
 }
 
-func (r *GraphResolver) Claim(args ClaimArgs) ([]*ClaimResolver, error) {
-	path := path.NewPath(r.qs)
-	if v := args.Filter.Issuer; v != nil {
+func (r *GraphResolver) Claim(ctx context.Context, args ClaimArgs) ([]*ClaimResolver, error) {
+	claims, err := loadClaims(ctx, r.qs, claimPath(r.qs, &args.Filter))
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*ClaimResolver, len(claims))
+	for i, claim := range claims {
+		resolvers[i] = &ClaimResolver{r.resolver, r.id, claim}
+	}
+	return resolvers, nil
+}
+
+// TrustPathsArgs are the arguments for a GraphQL trustPaths query.
+type TrustPathsArgs struct {
+	From  string
+	To    string
+	Depth *int32
+	First *int32
+}
+
+func (r *GraphResolver) TrustPaths(ctx context.Context, args TrustPathsArgs) ([]*TrustPathResolver, error) {
+	depth := DefaultTrustDepth
+	if args.Depth != nil {
+		depth = int(*args.Depth)
+	}
+	first := DefaultTrustPaths
+	if args.First != nil {
+		first = int(*args.First)
+	}
+	paths, err := TrustPaths(ctx, r.qs, HexToID(args.From), HexToID(args.To), depth, first)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*TrustPathResolver, len(paths))
+	for i, path := range paths {
+		resolvers[i] = &TrustPathResolver{r, path}
+	}
+	return resolvers, nil
+}
+
+// TrustScoresArgs are the arguments for a GraphQL trustScores query.
+type TrustScoresArgs struct {
+	Roots      []string
+	Damping    *float64
+	Iterations *int32
+}
+
+func (r *GraphResolver) TrustScores(ctx context.Context, args TrustScoresArgs) ([]*TrustScoreResolver, error) {
+	roots := make([]ID, len(args.Roots))
+	for i, root := range args.Roots {
+		roots[i] = HexToID(root)
+	}
+	config := DefaultTrustConfig(roots...)
+	if args.Damping != nil {
+		config.Damping = *args.Damping
+	}
+	if args.Iterations != nil {
+		config.Iterations = int(*args.Iterations)
+	}
+	scores, err := TrustScores(ctx, r.qs, config)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*TrustScoreResolver, len(scores))
+	for i, score := range scores {
+		resolvers[i] = &TrustScoreResolver{score}
+	}
+	return resolvers, nil
+}
+
+// TrustPathResolver defines GraphQL resolver functions for TrustPath fields.
+type TrustPathResolver struct {
+	graph *GraphResolver
+	path  TrustPath
+}
+
+func (t *TrustPathResolver) Claims() []*ClaimResolver {
+	resolvers := make([]*ClaimResolver, len(t.path))
+	for i, claim := range t.path {
+		resolvers[i] = &ClaimResolver{t.graph.resolver, t.graph.id, claim}
+	}
+	return resolvers
+}
+
+// TrustScoreResolver defines GraphQL resolver functions for TrustScore
+// fields.
+type TrustScoreResolver struct {
+	score TrustScore
+}
+
+func (t *TrustScoreResolver) ID() string {
+	return t.score.ID.Hex()
+}
+
+func (t *TrustScoreResolver) Score() float64 {
+	return t.score.Score
+}
+
+// claimPath returns a path to the claims in qs which match the filter.
+func claimPath(qs graph.QuadStore, filter *ClaimFilter) *path.Path {
+	path := path.NewPath(qs)
+	if v := filter.Issuer; v != nil {
 		path = path.Has(quad.IRI("kord:issuer"), quad.IRI(*v))
 	}
-	if v := args.Filter.Subject; v != nil {
+	if v := filter.Subject; v != nil {
 		path = path.Has(quad.IRI("kord:subject"), quad.IRI(*v))
 	}
-	if v := args.Filter.Property; v != nil {
+	if v := filter.Property; v != nil {
 		path = path.Has(quad.IRI("kord:property"), quad.StringToValue(*v))
 	}
-	if v := args.Filter.Claim; v != nil {
-		path = path.Has(quad.IRI("kord:claim"), quad.StringToValue(*v))
+	if v := filter.Claim; v != nil {
+		value := quad.StringToValue(*v)
+		if filter.ClaimType != nil || filter.ClaimLanguage != nil {
+			input := &ClaimInput{Claim: *v, ClaimType: filter.ClaimType, ClaimLanguage: filter.ClaimLanguage}
+			if typed, err := input.Value(); err == nil {
+				value = typed
+			}
+		}
+		path = path.Has(quad.IRI("kord:claim"), value)
 	}
-	var claims []claimQuad
-	if err := schema.LoadPathTo(context.Background(), r.qs, &claims, path); err != nil {
+	return path
+}
+
+func loadClaims(ctx context.Context, qs graph.QuadStore, path *path.Path) ([]*Claim, error) {
+	var quads []claimQuad
+	if err := schema.LoadPathTo(ctx, qs, &quads, path); err != nil {
 		return nil, err
 	}
-	resolvers := make([]*ClaimResolver, len(claims))
-	for i, v := range claims {
-		resolvers[i] = &ClaimResolver{v.ToClaim()}
+	claims := make([]*Claim, len(quads))
+	for i, v := range quads {
+		claims[i] = v.ToClaim()
 	}
-	return resolvers, nil
+	return claims, nil
 }
 
 // ClaimResolver defines GraphQL resolver functions for Claim fields.
 type ClaimResolver struct {
-	claim *Claim
+	resolver *Resolver
+	graph    string
+	claim    *Claim
 }
 
 func (c *ClaimResolver) ID() string {
 	return c.claim.ID().String()
 }
 
+// Graph returns the ID of the graph the claim is stored in.
+func (c *ClaimResolver) Graph() string {
+	return c.graph
+}
+
 func (c *ClaimResolver) Issuer() string {
 	return c.claim.Issuer.String()
 }
@@ -206,30 +633,61 @@ func (c *ClaimResolver) Property() string {
 	return c.claim.Property
 }
 
+// Claim returns the lexical form of the claim value.
 func (c *ClaimResolver) Claim() string {
-	return c.claim.Claim
+	value, _, _ := ClaimValueParts(c.claim.Claim)
+	return value
+}
+
+// ClaimType returns the datatype IRI of the claim value, or "@id" if the
+// value is an IRI.
+func (c *ClaimResolver) ClaimType() *string {
+	if _, typ, _ := ClaimValueParts(c.claim.Claim); typ != "" {
+		return &typ
+	}
+	return nil
+}
+
+// ClaimLanguage returns the language tag of the claim value.
+func (c *ClaimResolver) ClaimLanguage() *string {
+	if _, _, lang := ClaimValueParts(c.claim.Claim); lang != "" {
+		return &lang
+	}
+	return nil
 }
 
 func (c *ClaimResolver) Signature() string {
 	return hexutil.Encode(c.claim.Signature)
 }
 
+// IssuerClaims returns claims about the issuer of the claim from all the
+// graphs the node has opened.
+func (c *ClaimResolver) IssuerClaims(ctx context.Context) ([]*ClaimResolver, error) {
+	return c.resolver.claimsAbout(ctx, c.claim.Issuer.Hex())
+}
+
+// SubjectClaims returns claims about the subject of the claim from all the
+// graphs the node has opened.
+func (c *ClaimResolver) SubjectClaims(ctx context.Context) ([]*ClaimResolver, error) {
+	return c.resolver.claimsAbout(ctx, c.claim.Subject.Hex())
+}
+
 // CreateClaimArgs are the arguments for a GraphQL CreateClaim mutation.
 type CreateClaimArgs struct {
 	Input ClaimInput
 }
 
 func (r *Resolver) CreateClaim(ctx context.Context, args CreateClaimArgs) (*ClaimResolver, error) {
-	claim := &Claim{
-		Issuer:    HexToID(args.Input.Issuer),
-		Subject:   HexToID(args.Input.Subject),
-		Property:  args.Input.Property,
-		Claim:     args.Input.Claim,
-		Signature: common.FromHex(args.Input.Signature),
+	claim, err := args.Input.ToClaim()
+	if err != nil {
+		return nil, err
 	}
 
 	graph := args.Input.Graph
-	if err := r.writeClaim(graph, claim); err != nil {
+	if err := r.authorizeWrite(ctx, graph); err != nil {
+		return nil, err
+	}
+	if err := r.writeClaim(ctx, graph, claim); err != nil {
 		return nil, err
 	}
 
@@ -239,17 +697,17 @@ func (r *Resolver) CreateClaim(ctx context.Context, args CreateClaimArgs) (*Clai
 	}
 	ctx.Value("swarmHash").(*common.Hash).Set(hash)
 
-	return &ClaimResolver{claim: claim}, nil
+	return &ClaimResolver{r, graph, claim}, nil
 }
 
-func (r *Resolver) writeClaim(id string, claim *Claim) error {
-	if !verifyClaim(claim) {
-		return errors.New("invalid claim signature")
-	}
-	qs, err := r.driver.Get(id)
+func (r *Resolver) writeClaim(ctx context.Context, id string, claim *Claim) error {
+	qs, err := openGraph(ctx, r.driver, id)
 	if err != nil {
 		return err
 	}
+	if err := checkClaim(ctx, qs, claim); err != nil {
+		return err
+	}
 	qw, err := graph.NewQuadWriter("single", qs, nil)
 	if err != nil {
 		return err
@@ -262,7 +720,199 @@ func (r *Resolver) writeClaim(id string, claim *Claim) error {
 	return w.Flush()
 }
 
-func verifyClaim(claim *Claim) bool {
+// checkClaim checks that the claim has a valid value and signature and that
+// the value conforms to any schema registered for the claim property.
+func checkClaim(ctx context.Context, qs graph.QuadStore, claim *Claim) error {
+	if claim.Claim == nil {
+		return errors.New("missing claim value")
+	}
+	if s, ok := claim.Claim.(quad.String); ok && strings.HasPrefix(string(s), "\x00") {
+		return errors.New("invalid claim value: leading NUL byte")
+	}
+	if !VerifyClaim(claim) {
+		return errors.New("invalid claim signature")
+	}
+	claimSchema, err := loadClaimSchema(ctx, qs, claim.Property)
+	if err != nil {
+		return err
+	}
+	if claimSchema != nil {
+		if err := claimSchema.Check(claim.Claim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateClaimsArgs are the arguments for a GraphQL createClaims mutation.
+type CreateClaimsArgs struct {
+	Input []ClaimInput
+}
+
+// CreateClaims checks a batch of claims for a single graph, writes the valid
+// ones in one transaction and then commits the graph once. Claims which are
+// invalid or already exist are skipped and reported in the error field of
+// their result.
+func (r *Resolver) CreateClaims(ctx context.Context, args CreateClaimsArgs) ([]*ClaimResultResolver, error) {
+	if len(args.Input) == 0 {
+		return nil, errors.New("missing claims")
+	}
+	id := args.Input[0].Graph
+	for _, input := range args.Input {
+		if input.Graph != id {
+			return nil, fmt.Errorf("all claims must be created in the same graph, got %q and %q", id, input.Graph)
+		}
+	}
+	if err := r.authorizeWrite(ctx, id); err != nil {
+		return nil, err
+	}
+	qs, err := openGraph(ctx, r.driver, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := graph.NewTransaction()
+	w := graph.NewTxWriter(tx, graph.Add)
+	results := make([]*ClaimResultResolver, len(args.Input))
+	seen := make(map[common.Hash]struct{}, len(args.Input))
+	for i, input := range args.Input {
+		claim, err := prepareClaim(ctx, qs, &input, seen)
+		if err == nil {
+			_, err = schema.WriteAsQuads(w, claim.Quad())
+		}
+		if err != nil {
+			results[i] = &ClaimResultResolver{err: err}
+			continue
+		}
+		results[i] = &ClaimResultResolver{claim: &ClaimResolver{r, id, claim}}
+	}
+	if len(tx.Deltas) == 0 {
+		return results, nil
+	}
+
+	qw, err := graph.NewQuadWriter("single", qs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := qw.ApplyTransaction(tx); err != nil {
+		return nil, err
+	}
+	hash, err := r.driver.Commit(id)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Value("swarmHash").(*common.Hash).Set(hash)
+	return results, nil
+}
+
+// prepareClaim converts a claim input into a claim, checks it and ensures it
+// is neither already stored in the graph nor a duplicate of a claim earlier
+// in the same batch.
+func prepareClaim(ctx context.Context, qs graph.QuadStore, input *ClaimInput, seen map[common.Hash]struct{}) (*Claim, error) {
+	claim, err := input.ToClaim()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkClaim(ctx, qs, claim); err != nil {
+		return nil, err
+	}
+	id := claim.ID()
+	if _, ok := seen[id]; ok {
+		return nil, fmt.Errorf("duplicate claim: %s", id.Hex())
+	}
+	existing, err := loadClaims(ctx, qs, path.StartPath(qs, quad.IRI(id.Hex())))
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("claim already exists: %s", id.Hex())
+	}
+	seen[id] = struct{}{}
+	return claim, nil
+}
+
+// ClaimResultResolver defines GraphQL resolver functions for the result of
+// creating a claim in a batch.
+type ClaimResultResolver struct {
+	claim *ClaimResolver
+	err   error
+}
+
+func (c *ClaimResultResolver) Claim() *ClaimResolver {
+	return c.claim
+}
+
+func (c *ClaimResultResolver) Error() *string {
+	if c.err == nil {
+		return nil
+	}
+	msg := c.err.Error()
+	return &msg
+}
+
+// RegisterClaimSchemaArgs are the arguments for a GraphQL
+// registerClaimSchema mutation.
+type RegisterClaimSchemaArgs struct {
+	Input ClaimSchemaInput
+}
+
+// RegisterClaimSchema registers a schema which values of claims with the
+// given property must conform to when created in the graph.
+func (r *Resolver) RegisterClaimSchema(ctx context.Context, args RegisterClaimSchemaArgs) (*ClaimSchemaResolver, error) {
+	if err := r.authorizeWrite(ctx, args.Input.Graph); err != nil {
+		return nil, err
+	}
+	s := args.Input.ClaimSchema()
+	qs, err := openGraph(ctx, r.driver, args.Input.Graph)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeClaimSchema(ctx, qs, s); err != nil {
+		return nil, err
+	}
+	hash, err := r.driver.Commit(args.Input.Graph)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Value("swarmHash").(*common.Hash).Set(hash)
+	return &ClaimSchemaResolver{s}, nil
+}
+
+func (r *GraphResolver) ClaimSchemas(ctx context.Context) ([]*ClaimSchemaResolver, error) {
+	schemas, err := loadClaimSchemas(ctx, r.qs, path.NewPath(r.qs))
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*ClaimSchemaResolver, len(schemas))
+	for i, s := range schemas {
+		resolvers[i] = &ClaimSchemaResolver{s}
+	}
+	return resolvers, nil
+}
+
+// ClaimSchemaResolver defines GraphQL resolver functions for ClaimSchema
+// fields.
+type ClaimSchemaResolver struct {
+	schema *ClaimSchema
+}
+
+func (c *ClaimSchemaResolver) Property() string {
+	return c.schema.Property
+}
+
+func (c *ClaimSchemaResolver) ValueType() string {
+	return c.schema.ValueType
+}
+
+func (c *ClaimSchemaResolver) Pattern() *string {
+	if c.schema.Pattern == "" {
+		return nil
+	}
+	return &c.schema.Pattern
+}
+
+// VerifyClaim checks that the claim signature was made by the claim issuer.
+func VerifyClaim(claim *Claim) bool {
 	id := claim.ID()
 	recoveredPub, err := crypto.Ecrecover(id[:], claim.Signature)
 	if err != nil {
@@ -0,0 +1,277 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	graphql "github.com/neelance/graphql-go"
+	"github.com/neelance/graphql-go/errors"
+)
+
+// Error codes set in the extensions of errors for rejected queries.
+const (
+	ErrCodeParseFailed     = "GRAPHQL_PARSE_FAILED"
+	ErrCodeQueryTooDeep    = "QUERY_TOO_DEEP"
+	ErrCodeQueryTooComplex = "QUERY_TOO_COMPLEX"
+	ErrCodeQueryTimeout    = "QUERY_TIMEOUT"
+	ErrCodeRequestTooLarge = "REQUEST_TOO_LARGE"
+)
+
+// Limits bounds the cost of the GraphQL requests served by the API, with a
+// zero value disabling the corresponding limit.
+type Limits struct {
+	// MaxDepth is the maximum nesting of fields in a query
+	MaxDepth int
+
+	// MaxComplexity is the maximum complexity score of a query (see
+	// queryCost)
+	MaxComplexity int
+
+	// Timeout is the maximum time spent executing a query, after which
+	// graph iterators are cancelled and a QUERY_TIMEOUT error is returned
+	Timeout time.Duration
+
+	// MaxBodySize is the maximum size in bytes of a request body
+	MaxBodySize int64
+}
+
+var DefaultLimits = Limits{
+	MaxDepth:      15,
+	MaxComplexity: 5000,
+	Timeout:       30 * time.Second,
+	MaxBodySize:   10 * 1024 * 1024,
+}
+
+// QueryError is a GraphQL error with extensions, which for rejected queries
+// contain the error code and the limit which was exceeded.
+type QueryError struct {
+	*errors.QueryError
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func newQueryError(code string, extensions map[string]interface{}, format string, args ...interface{}) *QueryError {
+	if extensions == nil {
+		extensions = make(map[string]interface{}, 1)
+	}
+	extensions["code"] = code
+	return &QueryError{
+		QueryError: errors.Errorf(format, args...),
+		Extensions: extensions,
+	}
+}
+
+// Response is a GraphQL response.
+type Response struct {
+	Data       json.RawMessage        `json:"data,omitempty"`
+	Errors     []*QueryError          `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// check returns an error if the selected fields of an operation exceed the
+// depth or complexity limits, using the field types of the schema the
+// operation is executed against.
+func (l *Limits) check(op *graphql.Operation, fields *schemaFields) *QueryError {
+	if l.MaxDepth <= 0 && l.MaxComplexity <= 0 {
+		return nil
+	}
+	c := &queryCost{fields: fields}
+	depth, complexity := c.measure(op.Fields, false)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return newQueryError(ErrCodeQueryTooDeep, map[string]interface{}{
+			"depth":    depth,
+			"maxDepth": l.MaxDepth,
+		}, "query has depth %d, exceeding the maximum depth of %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return newQueryError(ErrCodeQueryTooComplex, map[string]interface{}{
+			"complexity":    complexity,
+			"maxComplexity": l.MaxComplexity,
+		}, "query has complexity %d, exceeding the maximum complexity of %d", complexity, l.MaxComplexity)
+	}
+	return nil
+}
+
+// defaultPageSize is the number of items assumed to be returned by fields
+// which have a "first" argument when it is not given, which is the default
+// page size of each of those fields.
+const defaultPageSize = DefaultQuadPageSize
+
+// unboundedListSize is the number of items assumed to be returned by list
+// fields which are not paginated, since they return every matching item.
+const unboundedListSize = 100
+
+// schemaFields records the type of each field of the object types of a
+// schema, which is used to measure the cost of queries.
+type schemaFields struct {
+	types map[string]map[string]*fieldType
+}
+
+// fieldType is the type of a field.
+type fieldType struct {
+	// name is the name of the named type of the field, with any list and
+	// non-null wrappers removed
+	name string
+
+	// list is whether the field returns a list
+	list bool
+
+	// paginated is whether the field has a "first" argument
+	paginated bool
+
+	// iterated is whether the field has an "iterations" argument
+	iterated bool
+}
+
+// newSchemaFields loads the field types of a schema, leaving out the
+// introspection types so that introspection queries, whose size is bounded
+// by the schema, are costed as if none of their fields were lists.
+func newSchemaFields(schema *graphql.Schema) *schemaFields {
+	fields := &schemaFields{
+		types: make(map[string]map[string]*fieldType),
+	}
+	for _, t := range schema.Inspect().Types() {
+		list := t.Fields(&struct{ IncludeDeprecated bool }{true})
+		if t.Name() == nil || list == nil || strings.HasPrefix(*t.Name(), "__") {
+			continue
+		}
+		types := make(map[string]*fieldType, len(*list))
+		for _, f := range *list {
+			ft := &fieldType{}
+			typ := f.Type()
+			for typ.OfType() != nil {
+				if typ.Kind() == "LIST" {
+					ft.list = true
+				}
+				typ = typ.OfType()
+			}
+			if typ.Name() != nil {
+				ft.name = *typ.Name()
+			}
+			for _, arg := range f.Args() {
+				switch arg.Name() {
+				case "first":
+					ft.paginated = true
+				case "iterations":
+					ft.iterated = true
+				}
+			}
+			types[f.Name()] = ft
+		}
+		fields.types[*t.Name()] = types
+	}
+	return fields
+}
+
+// field returns the type of the field of the named type, or nil if either
+// is unknown.
+func (s *schemaFields) field(typeName, name string) *fieldType {
+	if s == nil {
+		return nil
+	}
+	return s.types[typeName][name]
+}
+
+// queryCost measures the depth and complexity of a query.
+//
+// Each field costs 1 plus the cost of its selections, with the cost of the
+// selections of list fields multiplied by the number of items they can
+// return since they are resolved for each item. For paginated fields this is
+// the "first" argument or the default page size, with the lists of a
+// connection returned by a paginated field counted by the field's page size,
+// and for other list fields it is unboundedListSize. Fields which run an
+// iterative computation, such as trust scores, also cost the number of
+// iterations they run. Fragments are expanded where they are spread, and do
+// not add to the depth.
+type queryCost struct {
+	fields *schemaFields
+}
+
+// maxCost is the value at which complexity scores saturate.
+const maxCost = math.MaxInt32
+
+// measure measures the selected fields, with page being whether they are
+// selected on a connection returned by a paginated field.
+func (c *queryCost) measure(selections []*graphql.SelectedField, page bool) (depth, cost int) {
+	for _, sel := range selections {
+		f := c.fields.field(sel.TypeName, sel.Name)
+		d, n := c.measure(sel.Fields, f != nil && f.paginated && !f.list)
+		d++
+		switch {
+		case f == nil:
+		case f.paginated:
+			n = mulCost(n, pageSize(sel))
+		case f.list && !page:
+			n = mulCost(n, unboundedListSize)
+		}
+		if f != nil && f.iterated {
+			n = addCost(n, iterations(sel))
+		}
+		n = addCost(n, 1)
+		if d > depth {
+			depth = d
+		}
+		cost = addCost(cost, n)
+	}
+	return depth, cost
+}
+
+// pageSize returns the "first" argument of the field, or the default page
+// size if it is not given.
+func pageSize(sel *graphql.SelectedField) int {
+	first, ok := sel.Args["first"]
+	if !ok || first == nil {
+		return defaultPageSize
+	}
+	if m := toInt(first); m > 1 {
+		return m
+	}
+	return 1
+}
+
+// iterations returns the "iterations" argument of the field, or the
+// default number of trust iterations if it is not given.
+func iterations(sel *graphql.SelectedField) int {
+	n, ok := sel.Args["iterations"]
+	if !ok || n == nil {
+		return DefaultTrustIterations
+	}
+	if m := toInt(n); m > 0 {
+		return m
+	}
+	return 0
+}
+
+func addCost(a, b int) int {
+	if a > maxCost-b {
+		return maxCost
+	}
+	return a + b
+}
+
+func mulCost(a, b int) int {
+	if a > 0 && b > maxCost/a {
+		return maxCost
+	}
+	return a * b
+}
@@ -0,0 +1,312 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/testutil"
+	graphql "github.com/neelance/graphql-go"
+)
+
+func TestLimits(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	driver := graph.NewDriver("kord-limits-test", ts, testutil.NewTestRegistry(), ts.Dir)
+	api, err := NewAPI(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Auth.Tokens = []string{testAPIToken}
+	api.Limits = Limits{
+		MaxDepth:      4,
+		MaxComplexity: 25,
+		MaxBodySize:   1024,
+	}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	id := testKordID.Hex()
+	client := NewClient(srv.URL)
+	client.SetToken(testAPIToken)
+	if _, err := client.CreateGraph(id); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(url, query string, variables map[string]interface{}) (int, *Response) {
+		body, err := json.Marshal(map[string]interface{}{
+			"query":     query,
+			"variables": variables,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		var response Response
+		if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, &response
+	}
+	expectCode := func(response *Response, code string) {
+		for _, err := range response.Errors {
+			if err.Extensions["code"] == code {
+				return
+			}
+		}
+		t.Fatalf("expected %s error, got %v", code, response.Errors)
+	}
+
+	// check a query within the limits succeeds (its depth is 4 and its
+	// complexity is 1 + (1 + 10 * (1 + 1)) = 22)
+	query := `
+query Quads($id: String!, $first: Int) {
+  graph(id: $id) {
+    quads(first: $first) {
+      quads {
+        subject
+      }
+    }
+  }
+}`
+	vars := map[string]interface{}{"id": id, "first": 10}
+	if status, res := post(srv.URL, query, vars); status != http.StatusOK || len(res.Errors) > 0 {
+		t.Fatalf("unexpected response: %d %v", status, res.Errors)
+	}
+
+	// check increasing the page size exceeds the complexity limit
+	vars["first"] = 20
+	_, res := post(srv.URL, query, vars)
+	expectCode(res, ErrCodeQueryTooComplex)
+	if res.Data != nil {
+		t.Fatalf("expected no data for rejected query, got %s", res.Data)
+	}
+
+	// check list fields count as their default page size when first is
+	// not given, and that list fields which are not paginated are costed
+	// as unbounded
+	_, res = post(srv.URL, `
+query Quads($id: String!) {
+  graph(id: $id) {
+    quads {
+      endCursor
+    }
+  }
+}`, vars)
+	expectCode(res, ErrCodeQueryTooComplex)
+	_, res = post(srv.URL, `
+query Dapps($id: String!) {
+  graph(id: $id) {
+    dapps {
+      uri
+    }
+  }
+}`, vars)
+	expectCode(res, ErrCodeQueryTooComplex)
+
+	// check each alias of a field is counted (its complexity is
+	// 1 + 2 * (1 + 10 * (1 + 1)) = 43)
+	_, res = post(srv.URL, `
+query Quads($id: String!, $first: Int) {
+  graph(id: $id) {
+    a: quads(first: $first) {
+      quads {
+        subject
+      }
+    }
+    b: quads(first: $first) {
+      quads {
+        subject
+      }
+    }
+  }
+}`, vars)
+	expectCode(res, ErrCodeQueryTooComplex)
+
+	// check nesting fields through a fragment exceeds the depth limit
+	_, res = post(srv.URL, `
+query Node($id: String!) {
+  graph(id: $id) {
+    node(iri: "http://example.com/alice") {
+      ...Out
+    }
+  }
+}
+fragment Out on Node {
+  out {
+    quads {
+      subject
+    }
+  }
+}`, vars)
+	expectCode(res, ErrCodeQueryTooDeep)
+
+	// check recursive fragments are rejected rather than looping
+	_, res = post(srv.URL, `
+{
+  graph(id: "x") {
+    ...A
+  }
+}
+fragment A on Graph {
+  ...A
+}`, nil)
+	if len(res.Errors) == 0 {
+		t.Fatal("expected error for recursive fragment")
+	}
+
+	// check queries which do not parse are rejected
+	_, res = post(srv.URL, `{ graph(`, nil)
+	expectCode(res, ErrCodeParseFailed)
+
+	// check large requests are rejected
+	status, res := post(srv.URL, query+strings.Repeat(" ", 1024), vars)
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected HTTP status %d, got %d", http.StatusRequestEntityTooLarge, status)
+	}
+	expectCode(res, ErrCodeRequestTooLarge)
+
+	// check queries which exceed the timeout return a timeout error
+	timeoutAPI := &API{Limits: api.Limits, schema: api.schema, fields: api.fields, resolver: api.resolver}
+	timeoutAPI.Limits.Timeout = time.Nanosecond
+	timeoutSrv := httptest.NewServer(timeoutAPI)
+	defer timeoutSrv.Close()
+	vars["first"] = 10
+	_, res = post(timeoutSrv.URL, query, vars)
+	expectCode(res, ErrCodeQueryTimeout)
+}
+
+func TestQueryCost(t *testing.T) {
+	api, err := NewAPI(graph.NewDriver("kord-cost-test", nil, nil, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, fields := api.currentSchema()
+	for _, x := range []struct {
+		query      string
+		variables  map[string]interface{}
+		depth      int
+		complexity int
+	}{
+		{
+			query:      `{ graph(id: "x") { id } }`,
+			depth:      2,
+			complexity: 2,
+		},
+		{
+			// aliases of the same field are each counted
+			query:      `{ a: graph(id: "x") { id } b: graph(id: "x") { id } }`,
+			depth:      2,
+			complexity: 4,
+		},
+		{
+			// fragment spreads are expanded with their variables
+			// resolved
+			query: `
+query Quads($first: Int) {
+  graph(id: "x") {
+    ...Quads
+  }
+}
+fragment Quads on Graph {
+  quads(first: $first) {
+    quads {
+      subject
+    }
+  }
+}`,
+			// variables are decoded from JSON as float64
+			variables:  map[string]interface{}{"first": float64(5)},
+			depth:      4,
+			complexity: 1 + (1 + 5*(1+1)),
+		},
+		{
+			// the default page size is used if a variable is not given
+			query: `
+query Quads($first: Int) {
+  graph(id: "x") {
+    quads(first: $first) {
+      quads {
+        subject
+      }
+    }
+  }
+}`,
+			depth:      4,
+			complexity: 1 + (1 + defaultPageSize*(1+1)),
+		},
+		{
+			// inline fragments are expanded
+			query:      `{ graph(id: "x") { ... on Graph { dapps { uri } } } }`,
+			depth:      3,
+			complexity: 1 + (1 + unboundedListSize*1),
+		},
+		{
+			// skipped fields are not counted
+			query:      `query Dapps($skip: Boolean!) { graph(id: "x") { id dapps @skip(if: $skip) { uri } } }`,
+			variables:  map[string]interface{}{"skip": true},
+			depth:      2,
+			complexity: 2,
+		},
+		{
+			// trust scores cost their number of iterations
+			query:      `{ graph(id: "x") { trustScores(roots: ["x"], iterations: 50) { score } } }`,
+			depth:      3,
+			complexity: 1 + (1 + unboundedListSize*1 + 50),
+		},
+		{
+			query:      `{ graph(id: "x") { trustScores(roots: ["x"]) { score } } }`,
+			depth:      3,
+			complexity: 1 + (1 + unboundedListSize*1 + DefaultTrustIterations),
+		},
+		{
+			// introspection lists are not multiplied
+			query:      `{ __schema { types { name } } }`,
+			depth:      3,
+			complexity: 3,
+		},
+	} {
+		q, qErr := graphql.ParseQuery(x.query)
+		if qErr != nil {
+			t.Fatal(qErr)
+		}
+		op, errs := schema.Select(q, "", x.variables)
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		depth, complexity := (&queryCost{fields: fields}).measure(op.Fields, false)
+		if depth != x.depth || complexity != x.complexity {
+			t.Fatalf("expected depth %d and complexity %d for %s, got %d and %d", x.depth, x.complexity, x.query, depth, complexity)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kord-network/go-kord/metrics"
+)
+
+var (
+	requestTimer  = metrics.NewTimerVec("kord_graphql_request_duration_seconds", "Time taken to serve GraphQL requests.", "operation")
+	requestErrors = metrics.NewCounterVec("kord_graphql_request_errors_total", "Number of GraphQL requests which returned errors.", "operation")
+)
+
+// maxOperationLabels limits the number of distinct operation names which
+// metrics are recorded for, since operation names are chosen by clients.
+const maxOperationLabels = 256
+
+var operationLabels = struct {
+	sync.Mutex
+	names map[string]struct{}
+}{names: make(map[string]struct{})}
+
+// observeRequest records the duration of a GraphQL request for the named
+// operation, and whether it returned errors.
+func observeRequest(operation string, start time.Time, failed bool) {
+	label := operationLabel(operation)
+	requestTimer.With(label).UpdateSince(start)
+	if failed {
+		requestErrors.With(label).Inc(1)
+	}
+}
+
+// operationLabel returns the metrics label for the operation name, which is
+// "anonymous" for unnamed operations and "other" once maxOperationLabels
+// distinct names have been seen.
+func operationLabel(name string) string {
+	if name == "" {
+		return "anonymous"
+	}
+	operationLabels.Lock()
+	defer operationLabels.Unlock()
+	if _, ok := operationLabels.names[name]; ok {
+		return name
+	}
+	if len(operationLabels.names) >= maxOperationLabels {
+		return "other"
+	}
+	operationLabels.names[name] = struct{}{}
+	return name
+}
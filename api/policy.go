@@ -0,0 +1,229 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/common"
+	kordgraph "github.com/kord-network/go-kord/graph"
+)
+
+// Read policies of graphs, which are either readable by anyone or
+// restricted to the node operator, the KORD ID which owns the graph, its
+// delegates and its readers.
+const (
+	ReadPolicyPublic     = "PUBLIC"
+	ReadPolicyRestricted = "RESTRICTED"
+)
+
+// readPolicyPredicate links the KORD ID which owns a graph to the graph's
+// read policy, and readerPredicate links it to the KORD IDs which can read
+// the graph if the policy is ReadPolicyRestricted.
+const (
+	readPolicyPredicate = quad.IRI("kord:readPolicy")
+	readerPredicate     = quad.IRI("kord:reader")
+)
+
+var errReadUnauthenticated = errors.New("graph is restricted, reading it requires an API token or a request signed by a KORD ID")
+
+// authorizeRead returns an error unless the request was sent by someone
+// allowed to read the graph by its read policy. The policy is stored in the
+// graph, so a graph which is not stored locally is fetched before its
+// policy is checked, though nothing is read from it for a request which
+// cannot read it. The operator and the owner can read the graph whatever its
+// policy, and graphs which are not owned by a KORD ID are always public.
+func authorizeRead(ctx context.Context, driver *kordgraph.Driver, id string) error {
+	if !common.IsHexAddress(id) {
+		return nil
+	}
+	owner := common.HexToAddress(id)
+	p, _ := ctx.Value("principal").(*Principal)
+	if p != nil && (p.Operator || p.ID == owner) {
+		return nil
+	}
+	qs, err := openGraph(ctx, driver, id)
+	if err != nil {
+		return err
+	}
+	policy, err := loadReadPolicy(ctx, qs, owner)
+	if err != nil {
+		return err
+	}
+	if policy == ReadPolicyPublic {
+		return nil
+	}
+	if p == nil {
+		return errReadUnauthenticated
+	}
+	for _, load := range []func(context.Context, graph.QuadStore, common.Address) ([]common.Address, error){
+		loadDelegates,
+		loadReaders,
+	} {
+		ids, err := load(ctx, qs, owner)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if id == p.ID {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s is not allowed to read graph %s", p.ID.Hex(), id)
+}
+
+// AuthorizeRead returns an error unless the principal is allowed to read
+// the graph by its read policy, with a nil principal being an
+// unauthenticated request.
+func AuthorizeRead(ctx context.Context, driver *kordgraph.Driver, id string, p *Principal) error {
+	return authorizeRead(context.WithValue(ctx, "principal", p), driver, id)
+}
+
+// loadReadPolicy loads the read policy of a graph, defaulting to
+// ReadPolicyPublic.
+func loadReadPolicy(ctx context.Context, qs graph.QuadStore, owner common.Address) (string, error) {
+	values, err := path.StartPath(qs, quad.IRI(owner.Hex())).Out(readPolicyPredicate).Iterate(ctx).AllValues(qs)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range values {
+		if s, ok := v.(quad.String); ok && string(s) == ReadPolicyRestricted {
+			return ReadPolicyRestricted, nil
+		}
+	}
+	return ReadPolicyPublic, nil
+}
+
+// loadReaders loads the readers of a restricted graph.
+func loadReaders(ctx context.Context, qs graph.QuadStore, owner common.Address) ([]common.Address, error) {
+	values, err := path.StartPath(qs, quad.IRI(owner.Hex())).Out(readerPredicate).Iterate(ctx).AllValues(qs)
+	if err != nil {
+		return nil, err
+	}
+	var readers []common.Address
+	for _, v := range values {
+		if iri, ok := v.(quad.IRI); ok && common.IsHexAddress(string(iri)) {
+			readers = append(readers, common.HexToAddress(string(iri)))
+		}
+	}
+	return readers, nil
+}
+
+// ReadPolicy returns the read policy of the graph.
+func (r *GraphResolver) ReadPolicy(ctx context.Context) (string, error) {
+	if !common.IsHexAddress(r.id) {
+		return ReadPolicyPublic, nil
+	}
+	return loadReadPolicy(ctx, r.qs, common.HexToAddress(r.id))
+}
+
+// Readers returns the KORD IDs which can read the graph if it is
+// restricted, in addition to its owner and delegates.
+func (r *GraphResolver) Readers(ctx context.Context) ([]string, error) {
+	if !common.IsHexAddress(r.id) {
+		return []string{}, nil
+	}
+	readers, err := loadReaders(ctx, r.qs, common.HexToAddress(r.id))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(readers))
+	for i, reader := range readers {
+		ids[i] = reader.Hex()
+	}
+	return ids, nil
+}
+
+// ReadPolicyArgs are the arguments for a GraphQL setReadPolicy mutation.
+type ReadPolicyArgs struct {
+	Input ReadPolicyInput
+}
+
+// SetReadPolicy sets the read policy of the graph, and can only be run by
+// the owner of the graph.
+func (r *Resolver) SetReadPolicy(ctx context.Context, args ReadPolicyArgs) (*GraphResolver, error) {
+	input := args.Input
+	if !common.IsHexAddress(input.Graph) {
+		return nil, fmt.Errorf("graph %s is not owned by a KORD ID", input.Graph)
+	}
+	if input.Policy != ReadPolicyPublic && input.Policy != ReadPolicyRestricted {
+		return nil, fmt.Errorf("invalid read policy: %s", input.Policy)
+	}
+	if err := r.authorizeOwner(ctx, input.Graph); err != nil {
+		return nil, err
+	}
+	qs, err := openGraph(ctx, r.driver, input.Graph)
+	if err != nil {
+		return nil, err
+	}
+
+	// replace any existing policy
+	owner := quad.IRI(common.HexToAddress(input.Graph).Hex())
+	values, err := path.StartPath(qs, owner).Out(readPolicyPredicate).Iterate(ctx).AllValues(qs)
+	if err != nil {
+		return nil, err
+	}
+	var deltas []graph.Delta
+	for _, v := range values {
+		deltas = append(deltas, graph.Delta{
+			Quad:   quad.Make(owner, readPolicyPredicate, v, nil),
+			Action: graph.Delete,
+		})
+	}
+	deltas = append(deltas, graph.Delta{
+		Quad:   quad.Make(owner, readPolicyPredicate, quad.String(input.Policy), nil),
+		Action: graph.Add,
+	})
+	return r.applyDeltas(ctx, input.Graph, deltas, graph.IgnoreOpts{})
+}
+
+// ReaderArgs are the arguments for GraphQL addReader and removeReader
+// mutations.
+type ReaderArgs struct {
+	Input ReaderInput
+}
+
+// AddReader allows the reader to read the graph if it is restricted, and
+// can only be run by the owner of the graph.
+func (r *Resolver) AddReader(ctx context.Context, args ReaderArgs) (*GraphResolver, error) {
+	q, err := args.Input.quad()
+	if err != nil {
+		return nil, err
+	}
+	deltas := []graph.Delta{{Quad: q, Action: graph.Add}}
+	return r.applyDeltas(ctx, args.Input.Graph, deltas, graph.IgnoreOpts{IgnoreDup: true})
+}
+
+// RemoveReader stops the reader from reading the graph if it is
+// restricted, and can only be run by the owner of the graph.
+func (r *Resolver) RemoveReader(ctx context.Context, args ReaderArgs) (*GraphResolver, error) {
+	q, err := args.Input.quad()
+	if err != nil {
+		return nil, err
+	}
+	deltas := []graph.Delta{{Quad: q, Action: graph.Delete}}
+	return r.applyDeltas(ctx, args.Input.Graph, deltas, graph.IgnoreOpts{})
+}
@@ -0,0 +1,214 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	cayleygraph "github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+func TestReadPolicy(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	reg := testutil.NewTestRegistry()
+	driver := graph.NewDriver("kord-policy-test", ts, reg, ts.Dir)
+	api, err := NewAPI(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Auth.Tokens = []string{testAPIToken}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	var srvs []*httptest.Server
+	var dirs []string
+	defer func() {
+		for _, srv := range srvs {
+			srv.Close()
+		}
+		for _, dir := range dirs {
+			os.RemoveAll(dir)
+		}
+	}()
+	id := testKordID.Hex()
+
+	operator := NewClient(srv.URL)
+	operator.SetToken(testAPIToken)
+	if _, err := operator.CreateGraph(id); err != nil {
+		t.Fatal(err)
+	}
+	newSigner := func(key *ecdsa.PrivateKey) *Client {
+		client := NewClient(srv.URL)
+		client.SetSigner(crypto.PubkeyToAddress(key.PublicKey), func(hash common.Hash) ([]byte, error) {
+			return crypto.Sign(hash[:], key)
+		})
+		return client
+	}
+	owner := newSigner(testKey)
+	readerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	readerID := crypto.PubkeyToAddress(readerKey.PublicKey)
+	reader := newSigner(readerKey)
+	anonymous := NewClient(srv.URL)
+	q := quad.Make(quad.IRI("http://example.com/alice"), quad.IRI("http://xmlns.com/foaf/0.1/name"), quad.String("Alice"), nil)
+	if _, err := owner.ApplyDeltas(id, []cayleygraph.Delta{{Quad: q, Action: cayleygraph.Add}}, cayleygraph.IgnoreOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owner.CreateClaim(id, newTestClaim(t, "name", "Alice")); err != nil {
+		t.Fatal(err)
+	}
+	checkClaims := func(client *Client, expected int) {
+		t.Helper()
+		claims, err := client.Claims(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(claims) != expected {
+			t.Fatalf("expected %d claims, got %d", expected, len(claims))
+		}
+	}
+	checkRead := func(client *Client, expectedErr string) {
+		t.Helper()
+		page, err := client.Quads(id, &QuadFilter{Predicate: termString(q.Predicate)}, 10, "")
+		if expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", expectedErr, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Quads) != 1 {
+			t.Fatalf("expected 1 quad, got %d", len(page.Quads))
+		}
+	}
+
+	// check graphs are public by default
+	if policy, err := anonymous.ReadPolicy(id); err != nil || policy != ReadPolicyPublic {
+		t.Fatalf("expected read policy %s, got %q (err: %v)", ReadPolicyPublic, policy, err)
+	}
+	checkRead(anonymous, "")
+	checkClaims(anonymous, 1)
+
+	// check only the owner can restrict the graph
+	if _, err := reader.SetReadPolicy(id, ReadPolicyRestricted); err == nil || !strings.Contains(err.Error(), "is not the owner") {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+	if _, err := owner.SetReadPolicy(id, ReadPolicyRestricted); err != nil {
+		t.Fatal(err)
+	}
+	if policy, err := owner.ReadPolicy(id); err != nil || policy != ReadPolicyRestricted {
+		t.Fatalf("expected read policy %s, got %q (err: %v)", ReadPolicyRestricted, policy, err)
+	}
+
+	// check only the operator and owner can read the restricted graph
+	checkRead(anonymous, "graph is restricted")
+	checkRead(reader, "is not allowed to read graph")
+	checkRead(owner, "")
+	checkRead(operator, "")
+
+	// check claims in the restricted graph are only found by searches
+	// sent by those who can read it
+	checkClaims(anonymous, 0)
+	checkClaims(owner, 1)
+
+	// check readers can read the graph once added by the owner
+	if _, err := reader.AddReader(id, readerID); err == nil || !strings.Contains(err.Error(), "is not the owner") {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+	if _, err := owner.AddReader(id, readerID); err != nil {
+		t.Fatal(err)
+	}
+	readers, err := owner.Readers(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readers) != 1 || readers[0] != readerID {
+		t.Fatalf("expected readers to be [%s], got %v", readerID.Hex(), readers)
+	}
+	checkRead(reader, "")
+
+	// check removed readers can no longer read the graph, and that making
+	// the graph public allows anyone to read it again
+	if _, err := owner.RemoveReader(id, readerID); err != nil {
+		t.Fatal(err)
+	}
+	checkRead(reader, "is not allowed to read graph")
+	if _, err := owner.SetReadPolicy(id, ReadPolicyPublic); err != nil {
+		t.Fatal(err)
+	}
+	checkRead(anonymous, "")
+
+	// check a node which has not fetched the graph fetches it to check its
+	// read policy, so that anyone can read it while it is public but only
+	// those allowed by the policy can once it is restricted
+	newNode := func(name string) *Client {
+		t.Helper()
+		dir, err := ioutil.TempDir("", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+		api, err := NewAPI(graph.NewDriver(name, ts, reg, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := httptest.NewServer(api)
+		srvs = append(srvs, srv)
+		return NewClient(srv.URL)
+	}
+	publish := func() {
+		t.Helper()
+		hash, err := driver.Commit(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := crypto.Sign(hash[:], testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reg.SetGraph(hash, sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+	publish()
+	checkRead(newNode("kord-policy-test-public"), "")
+	if _, err := owner.SetReadPolicy(id, ReadPolicyRestricted); err != nil {
+		t.Fatal(err)
+	}
+	publish()
+	checkRead(newNode("kord-policy-test-restricted"), "graph is restricted")
+}
@@ -0,0 +1,408 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// DefaultQuadPageSize is the number of quads returned by a quads
+	// query if the page size is not given.
+	DefaultQuadPageSize = 100
+
+	// MaxQuadPageSize is the maximum number of quads returned by a quads
+	// query.
+	MaxQuadPageSize = 1000
+)
+
+// ParseTerm parses an RDF term in N-Quads syntax, which is either an IRI
+// (<http://example.com>), a blank node (_:b0) or a literal with an optional
+// language tag ("chat"@fr) or datatype ("42"^^<http://www.w3.org/2001/XMLSchema#integer>).
+func ParseTerm(s string) (quad.Value, error) {
+	switch {
+	case len(s) > 1 && s[0] == '<' && s[len(s)-1] == '>':
+		return quad.IRI(s[1 : len(s)-1]), nil
+	case strings.HasPrefix(s, "_:") && len(s) > 2:
+		return quad.BNode(s[2:]), nil
+	case strings.HasPrefix(s, `"`):
+		end := closingQuote(s)
+		if end < 0 {
+			return nil, fmt.Errorf("invalid N-Quads literal: %s", s)
+		}
+		value, err := strconv.Unquote(strings.Replace(s[:end+1], `\'`, `'`, -1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid N-Quads literal: %s", s)
+		}
+		switch suffix := s[end+1:]; {
+		case suffix == "":
+			return quad.String(value), nil
+		case strings.HasPrefix(suffix, "@") && len(suffix) > 1:
+			return quad.LangString{Value: quad.String(value), Lang: suffix[1:]}, nil
+		case strings.HasPrefix(suffix, "^^<") && strings.HasSuffix(suffix, ">"):
+			return quad.TypedString{Value: quad.String(value), Type: quad.IRI(suffix[3 : len(suffix)-1])}, nil
+		default:
+			return nil, fmt.Errorf("invalid N-Quads literal: %s", s)
+		}
+	default:
+		return nil, fmt.Errorf("invalid N-Quads term: %s", s)
+	}
+}
+
+// closingQuote returns the index of the quote which closes the literal at
+// the start of s, or -1 if it is not closed.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// termString returns the N-Quads representation of a term, which is the
+// inverse of ParseTerm.
+func termString(v quad.Value) *string {
+	if v == nil {
+		return nil
+	}
+	s := v.String()
+	return &s
+}
+
+// QuadFilter selects quads by their terms, each of which is in N-Quads
+// syntax.
+type QuadFilter struct {
+	Subject   *string `json:"subject"`
+	Predicate *string `json:"predicate"`
+	Object    *string `json:"object"`
+	Label     *string `json:"label"`
+}
+
+// iterator returns an iterator over the quads in the store which match the
+// filter.
+func (f *QuadFilter) iterator(qs graph.QuadStore) (graph.Iterator, error) {
+	and := iterator.NewAnd(qs)
+	for _, t := range []struct {
+		dir  quad.Direction
+		term *string
+	}{
+		{quad.Subject, f.Subject},
+		{quad.Predicate, f.Predicate},
+		{quad.Object, f.Object},
+		{quad.Label, f.Label},
+	} {
+		if t.term == nil {
+			continue
+		}
+		v, err := ParseTerm(*t.term)
+		if err != nil {
+			return nil, err
+		}
+		node := qs.ValueOf(v)
+		if node == nil {
+			return iterator.NewNull(), nil
+		}
+		and.AddSubIterator(qs.QuadIterator(t.dir, node))
+	}
+	if len(and.SubIterators()) == 0 {
+		return qs.QuadsAllIterator(), nil
+	}
+	return and, nil
+}
+
+// QuadPage is a page of quads returned by a quads query.
+type QuadPage struct {
+	Quads []quad.Quad
+
+	// EndCursor is passed as the after argument of a quads query to
+	// return the next page.
+	EndCursor string
+
+	HasNextPage bool
+}
+
+// loadQuads loads a page of at most first quads which match the filter,
+// starting after the given cursor.
+func loadQuads(ctx context.Context, qs graph.QuadStore, filter *QuadFilter, first *int32, after *string) (*QuadPage, error) {
+	limit := DefaultQuadPageSize
+	if first != nil {
+		limit = int(*first)
+	}
+	if limit < 0 || limit > MaxQuadPageSize {
+		return nil, fmt.Errorf("invalid page size %d, must be between 0 and %d", limit, MaxQuadPageSize)
+	}
+	offset := 0
+	if after != nil {
+		n, err := strconv.Atoi(*after)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid cursor: %q", *after)
+		}
+		offset = n
+	}
+
+	it, err := filter.iterator(qs)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	page := &QuadPage{}
+	for i := 0; it.Next(ctx); i++ {
+		if i < offset {
+			continue
+		}
+		if len(page.Quads) == limit {
+			page.HasNextPage = true
+			break
+		}
+		page.Quads = append(page.Quads, qs.Quad(it.Result()))
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	page.EndCursor = strconv.Itoa(offset + len(page.Quads))
+	return page, nil
+}
+
+// QuadsArgs are the arguments for a GraphQL quads query.
+type QuadsArgs struct {
+	Subject   *string
+	Predicate *string
+	Object    *string
+	Label     *string
+	First     *int32
+	After     *string
+}
+
+// Quads returns a page of quads in the graph matching the given terms.
+func (r *GraphResolver) Quads(ctx context.Context, args QuadsArgs) (*QuadConnectionResolver, error) {
+	filter := &QuadFilter{
+		Subject:   args.Subject,
+		Predicate: args.Predicate,
+		Object:    args.Object,
+		Label:     args.Label,
+	}
+	page, err := loadQuads(ctx, r.qs, filter, args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	return &QuadConnectionResolver{page}, nil
+}
+
+// NodeArgs are the arguments for a GraphQL node query.
+type NodeArgs struct {
+	IRI string
+}
+
+// Node returns the node in the graph with the given IRI, or nil if no quads
+// reference it as either a subject or an object.
+func (r *GraphResolver) Node(ctx context.Context, args NodeArgs) (*NodeResolver, error) {
+	node := &NodeResolver{r.qs, quad.IRI(args.IRI)}
+	one := int32(1)
+	for _, filter := range []*QuadFilter{node.filter(quad.Subject), node.filter(quad.Object)} {
+		page, err := loadQuads(ctx, r.qs, filter, &one, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Quads) > 0 {
+			return node, nil
+		}
+	}
+	return nil, nil
+}
+
+// NodeResolver defines GraphQL resolver functions for Node fields.
+type NodeResolver struct {
+	qs  graph.QuadStore
+	iri quad.IRI
+}
+
+func (n *NodeResolver) IRI() string {
+	return string(n.iri)
+}
+
+// PageArgs are the paging arguments for GraphQL quad connections.
+type PageArgs struct {
+	First *int32
+	After *string
+}
+
+// Out returns the quads which have the node as their subject.
+func (n *NodeResolver) Out(ctx context.Context, args PageArgs) (*QuadConnectionResolver, error) {
+	page, err := loadQuads(ctx, n.qs, n.filter(quad.Subject), args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	return &QuadConnectionResolver{page}, nil
+}
+
+// In returns the quads which have the node as their object.
+func (n *NodeResolver) In(ctx context.Context, args PageArgs) (*QuadConnectionResolver, error) {
+	page, err := loadQuads(ctx, n.qs, n.filter(quad.Object), args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	return &QuadConnectionResolver{page}, nil
+}
+
+func (n *NodeResolver) filter(dir quad.Direction) *QuadFilter {
+	term := n.iri.String()
+	switch dir {
+	case quad.Subject:
+		return &QuadFilter{Subject: &term}
+	default:
+		return &QuadFilter{Object: &term}
+	}
+}
+
+// QuadConnectionResolver defines GraphQL resolver functions for
+// QuadConnection fields.
+type QuadConnectionResolver struct {
+	page *QuadPage
+}
+
+func (c *QuadConnectionResolver) Quads() []*QuadResolver {
+	resolvers := make([]*QuadResolver, len(c.page.Quads))
+	for i, q := range c.page.Quads {
+		resolvers[i] = &QuadResolver{q}
+	}
+	return resolvers
+}
+
+func (c *QuadConnectionResolver) EndCursor() *string {
+	return &c.page.EndCursor
+}
+
+func (c *QuadConnectionResolver) HasNextPage() bool {
+	return c.page.HasNextPage
+}
+
+// QuadResolver defines GraphQL resolver functions for Quad fields, each of
+// which is in N-Quads syntax.
+type QuadResolver struct {
+	quad quad.Quad
+}
+
+func (q *QuadResolver) Subject() string {
+	return q.quad.Subject.String()
+}
+
+func (q *QuadResolver) Predicate() string {
+	return q.quad.Predicate.String()
+}
+
+func (q *QuadResolver) Object() string {
+	return q.quad.Object.String()
+}
+
+func (q *QuadResolver) Label() *string {
+	return termString(q.quad.Label)
+}
+
+// ApplyDeltasArgs are the arguments for a GraphQL applyDeltas mutation.
+type ApplyDeltasArgs struct {
+	Input DeltasInput
+}
+
+// ApplyDeltas adds and deletes quads in the graph in a single transaction
+// and commits the graph.
+func (r *Resolver) ApplyDeltas(ctx context.Context, args ApplyDeltasArgs) (*GraphResolver, error) {
+	deltas := make([]graph.Delta, len(args.Input.Deltas))
+	for i, d := range args.Input.Deltas {
+		delta, err := d.ToDelta()
+		if err != nil {
+			return nil, fmt.Errorf("invalid delta %d: %s", i, err)
+		}
+		deltas[i] = delta
+	}
+	opts := graph.IgnoreOpts{}
+	if args.Input.IgnoreDuplicates != nil {
+		opts.IgnoreDup = *args.Input.IgnoreDuplicates
+	}
+	if args.Input.IgnoreMissing != nil {
+		opts.IgnoreMissing = *args.Input.IgnoreMissing
+	}
+	return r.applyDeltas(ctx, args.Input.Graph, deltas, opts)
+}
+
+// DeleteQuadsArgs are the arguments for a GraphQL deleteQuads mutation.
+type DeleteQuadsArgs struct {
+	Input QuadsInput
+}
+
+// DeleteQuads deletes quads from the graph in a single transaction and
+// commits the graph.
+func (r *Resolver) DeleteQuads(ctx context.Context, args DeleteQuadsArgs) (*GraphResolver, error) {
+	deltas := make([]graph.Delta, len(args.Input.Quads))
+	for i, q := range args.Input.Quads {
+		v, err := q.ToQuad()
+		if err != nil {
+			return nil, fmt.Errorf("invalid quad %d: %s", i, err)
+		}
+		deltas[i] = graph.Delta{Quad: v, Action: graph.Delete}
+	}
+	return r.applyDeltas(ctx, args.Input.Graph, deltas, graph.IgnoreOpts{})
+}
+
+func (r *Resolver) applyDeltas(ctx context.Context, id string, deltas []graph.Delta, opts graph.IgnoreOpts) (*GraphResolver, error) {
+	if len(deltas) == 0 {
+		return nil, errors.New("missing quads")
+	}
+	authorize := r.authorizeWrite
+	for _, d := range deltas {
+		switch d.Quad.Predicate {
+		case delegatePredicate, readPolicyPredicate, readerPredicate:
+			// only the owner can change the delegates and read policy
+			// of a graph
+			authorize = r.authorizeOwner
+		}
+	}
+	if err := authorize(ctx, id); err != nil {
+		return nil, err
+	}
+	qs, err := openGraph(ctx, r.driver, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := qs.ApplyDeltas(deltas, opts); err != nil {
+		return nil, err
+	}
+	hash, err := r.driver.Commit(id)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Value("swarmHash").(*common.Hash).Set(hash)
+	return &GraphResolver{r, id, qs}, nil
+}
@@ -0,0 +1,186 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	cayleygraph "github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/graphql"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+func TestParseTerm(t *testing.T) {
+	for _, v := range []quad.Value{
+		quad.IRI("http://example.com/test"),
+		quad.BNode("b0"),
+		quad.String("test"),
+		quad.String("quote \" backslash \\ newline \n"),
+		quad.LangString{Value: "chat", Lang: "fr"},
+		quad.TypedString{Value: "42", Type: nsXSD + "integer"},
+		quad.Int(42),
+	} {
+		got, err := ParseTerm(v.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != v.String() {
+			t.Fatalf("expected %s, got %s", v, got)
+		}
+	}
+	for _, s := range []string{"", "test", "<test", `"test`, `"test"@`, `"test"^^test`} {
+		if _, err := ParseTerm(s); err == nil {
+			t.Fatalf("expected error parsing %q", s)
+		}
+	}
+}
+
+func TestQuads(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	driver := graph.NewDriver("kord-quads-test", ts, testutil.NewTestRegistry(), ts.Dir)
+	api, err := NewAPI(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Auth.Tokens = []string{testAPIToken}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	client := NewClient(srv.URL)
+	client.SetToken(testAPIToken)
+	id := testKordID.Hex()
+	if _, err := client.CreateGraph(id); err != nil {
+		t.Fatal(err)
+	}
+
+	// add some quads in separate transactions so that nodes are shared
+	alice := quad.IRI("http://example.com/alice")
+	bob := quad.IRI("http://example.com/bob")
+	knows := quad.IRI("http://xmlns.com/foaf/0.1/knows")
+	name := quad.IRI("http://xmlns.com/foaf/0.1/name")
+	quads := []quad.Quad{
+		quad.Make(alice, knows, bob, nil),
+		quad.Make(alice, name, quad.String("Alice"), nil),
+		quad.Make(bob, name, quad.LangString{Value: "Bob", Lang: "en"}, nil),
+	}
+	for _, q := range quads {
+		if _, err := client.ApplyDeltas(id, []cayleygraph.Delta{{Quad: q, Action: cayleygraph.Add}}, cayleygraph.IgnoreOpts{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// check adding a duplicate quad fails unless duplicates are ignored
+	dup := []cayleygraph.Delta{{Quad: quads[0], Action: cayleygraph.Add}}
+	if _, err := client.ApplyDeltas(id, dup, cayleygraph.IgnoreOpts{}); err == nil {
+		t.Fatal("expected error adding duplicate quad")
+	}
+	if _, err := client.ApplyDeltas(id, dup, cayleygraph.IgnoreOpts{IgnoreDup: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// check filtering quads
+	subject := alice.String()
+	page, err := client.Quads(id, &QuadFilter{Subject: &subject}, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Quads) != 2 || page.HasNextPage {
+		t.Fatalf("expected 2 quads about alice, got %v", page.Quads)
+	}
+	predicate, object := name.String(), `"Bob"@en`
+	page, err = client.Quads(id, &QuadFilter{Predicate: &predicate, Object: &object}, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Quads) != 1 || page.Quads[0] != quads[2] {
+		t.Fatalf("expected %v, got %v", quads[2], page.Quads)
+	}
+
+	// check paging through all the quads
+	var all []quad.Quad
+	cursor := ""
+	for {
+		page, err := client.Quads(id, nil, 2, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, page.Quads...)
+		if !page.HasNextPage {
+			break
+		}
+		cursor = page.EndCursor
+	}
+	if len(all) != len(quads) {
+		t.Fatalf("expected %d quads, got %d", len(quads), len(all))
+	}
+
+	// check the edges of a node
+	query := `
+query GetNode($id: String!, $iri: String!) {
+  graph(id: $id) {
+    node(iri: $iri) {
+      out { quads { object } }
+      in  { quads { subject } }
+    }
+  }
+}
+`
+	type quadsResult struct {
+		Quads []QuadInput `json:"quads"`
+	}
+	var v struct {
+		Graph struct {
+			Node *struct {
+				Out quadsResult `json:"out"`
+				In  quadsResult `json:"in"`
+			} `json:"node"`
+		} `json:"graph"`
+	}
+	if _, err := client.Do(query, graphql.Variables{"id": id, "iri": string(bob)}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Graph.Node == nil {
+		t.Fatal("expected node for bob")
+	}
+	if len(v.Graph.Node.Out.Quads) != 1 || len(v.Graph.Node.In.Quads) != 1 || v.Graph.Node.In.Quads[0].Subject != alice.String() {
+		t.Fatalf("unexpected edges for bob: %+v", v.Graph.Node)
+	}
+
+	// check deleting a quad leaves the quads sharing its nodes intact
+	if _, err := client.DeleteQuads(id, quads[:1]); err != nil {
+		t.Fatal(err)
+	}
+	page, err = client.Quads(id, &QuadFilter{Subject: &subject}, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Quads) != 1 || page.Quads[0] != quads[1] {
+		t.Fatalf("expected %v, got %v", quads[1], page.Quads)
+	}
+	if _, err := client.DeleteQuads(id, quads[:1]); err == nil {
+		t.Fatal("expected error deleting missing quad")
+	}
+}
@@ -0,0 +1,258 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/voc/rdf"
+)
+
+// ResolveField resolves the query fields generated from the RDF schema,
+// which either load a resource of a generated type from a graph by IRI or
+// list the resources of the type in a graph.
+func (r *Resolver) ResolveField(ctx context.Context, field string, args map[string]interface{}) (interface{}, error) {
+	s := r.RDFSchema()
+	if s == nil || s.rootFields[field] == nil {
+		return nil, fmt.Errorf("unknown query field %s", field)
+	}
+	t := s.rootFields[field]
+	id := fmt.Sprint(args["graph"])
+	if err := authorizeRead(ctx, r.driver, id); err != nil {
+		return nil, err
+	}
+	qs, err := openGraph(ctx, r.driver, id)
+	if err != nil {
+		return nil, err
+	}
+	if field == t.listField() {
+		nodes, err := listResources(ctx, qs, t, args)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, len(nodes))
+		for i, node := range nodes {
+			list[i] = &rdfObject{schema: s, qs: qs, typ: t, node: node}
+		}
+		return list, nil
+	}
+	node := quad.IRI(fmt.Sprint(args["id"]))
+	if ok, err := hasType(ctx, qs, node, t); err != nil || !ok {
+		return nil, err
+	}
+	return &rdfObject{schema: s, qs: qs, typ: t, node: node}, nil
+}
+
+// listResources returns the resources of the type in the graph which match
+// the filter argument, paged using the first and skip arguments.
+func listResources(ctx context.Context, qs graph.QuadStore, t *RDFType, args map[string]interface{}) ([]quad.Value, error) {
+	p := path.StartPath(qs).Has(iriForms(rdf.Type), iriForms(string(t.Class))...)
+	if filter, ok := args["filter"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(filter))
+		for name := range filter {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v := filter[name]
+			if v == nil {
+				continue
+			}
+			if name == "id" {
+				p = p.Is(quad.IRI(fmt.Sprint(v)))
+				continue
+			}
+			f := t.Field(name)
+			if f == nil {
+				return nil, fmt.Errorf("unknown filter field %s", name)
+			}
+			p = p.Has(iriForms(string(f.Property)), f.FilterValue(v))
+		}
+	}
+	first := DefaultQuadPageSize
+	if v, ok := args["first"]; ok && v != nil {
+		first = toInt(v)
+	}
+	if first < 0 || first > MaxQuadPageSize {
+		return nil, fmt.Errorf("invalid page size %d, must be between 0 and %d", first, MaxQuadPageSize)
+	}
+	skip := 0
+	if v, ok := args["skip"]; ok && v != nil {
+		skip = toInt(v)
+	}
+	if skip < 0 {
+		return nil, fmt.Errorf("invalid skip %d", skip)
+	}
+	values, err := p.Iterate(ctx).AllValues(qs)
+	if err != nil {
+		return nil, err
+	}
+	sortValues(values)
+	if skip >= len(values) {
+		return nil, nil
+	}
+	values = values[skip:]
+	if len(values) > first {
+		values = values[:first]
+	}
+	return values, nil
+}
+
+// rdfObject resolves the fields of a resource of a generated type.
+type rdfObject struct {
+	schema *RDFSchema
+	qs     graph.QuadStore
+	typ    *RDFType
+	node   quad.Value
+}
+
+// ResolveField returns the IRI of the resource for the id field, and the
+// values of the field's property otherwise, with resources returned as
+// objects of the field's type and literals converted to its scalar type.
+func (o *rdfObject) ResolveField(ctx context.Context, field string, args map[string]interface{}) (interface{}, error) {
+	if field == "id" {
+		return termValue(o.node), nil
+	}
+	f := o.typ.Field(field)
+	if f == nil {
+		return nil, fmt.Errorf("unknown field %s.%s", o.typ.Name, field)
+	}
+	values, err := loadValues(ctx, o.qs, o.node, f.Property)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if f.Object {
+			switch v.(type) {
+			case quad.IRI, quad.BNode:
+				results = append(results, &rdfObject{schema: o.schema, qs: o.qs, typ: o.schema.types[f.Type], node: v})
+			}
+			continue
+		}
+		result, err := scalarValue(f.Type, v)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	switch {
+	case f.List:
+		return results, nil
+	case len(results) == 0:
+		return nil, nil
+	default:
+		return results[0], nil
+	}
+}
+
+// hasType returns whether the node is a resource of the type.
+func hasType(ctx context.Context, qs graph.QuadStore, node quad.Value, t *RDFType) (bool, error) {
+	p := path.StartPath(qs, node).Has(iriForms(rdf.Type), iriForms(string(t.Class))...)
+	v, err := p.Iterate(ctx).FirstValue(qs)
+	return v != nil, err
+}
+
+// loadValues returns the sorted values of the node's property.
+func loadValues(ctx context.Context, qs graph.QuadStore, node quad.Value, property quad.IRI) ([]quad.Value, error) {
+	values, err := path.StartPath(qs, node).Out(iriForms(string(property))).Iterate(ctx).AllValues(qs)
+	if err != nil {
+		return nil, err
+	}
+	sortValues(values)
+	return values, nil
+}
+
+// iriForms returns the full and prefixed forms of an IRI, either of which
+// may be used in a graph.
+func iriForms(iri string) []quad.Value {
+	full, short := quad.IRI(iri).Full(), quad.IRI(iri).Short()
+	if full == short {
+		return []quad.Value{full}
+	}
+	return []quad.Value{full, short}
+}
+
+func sortValues(values []quad.Value) {
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].String() < values[j].String()
+	})
+}
+
+// termValue returns the lexical form of a term.
+func termValue(v quad.Value) string {
+	switch v := v.(type) {
+	case quad.IRI:
+		return string(v)
+	case quad.BNode:
+		return v.String()
+	default:
+		value, _, _ := ClaimValueParts(v)
+		return value
+	}
+}
+
+// scalarValue converts a literal to the given GraphQL scalar type.
+func scalarValue(typ string, v quad.Value) (interface{}, error) {
+	s := termValue(v)
+	switch typ {
+	case "Int":
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Int value %s", v)
+		}
+		return int32(n), nil
+	case "Float":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Float value %s", v)
+		}
+		return f, nil
+	case "Boolean":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Boolean value %s", v)
+		}
+		return b, nil
+	default:
+		return s, nil
+	}
+}
+
+func toInt(v interface{}) int {
+	switch v := v.(type) {
+	case int32:
+		return int(v)
+	case float64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,513 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/voc/rdf"
+	"github.com/cayleygraph/cayley/voc/rdfs"
+	"github.com/cayleygraph/cayley/voc/schema"
+	graphql "github.com/neelance/graphql-go"
+)
+
+// Terms used to describe classes and properties which are not in the
+// vendored vocabulary packages.
+const (
+	nsOWL = "http://www.w3.org/2002/07/owl#"
+
+	owlClass              = nsOWL + "Class"
+	owlObjectProperty     = nsOWL + "ObjectProperty"
+	owlDatatypeProperty   = nsOWL + "DatatypeProperty"
+	owlFunctionalProperty = nsOWL + "FunctionalProperty"
+
+	schemaDomainIncludes = schema.NS + "domainIncludes"
+	schemaRangeIncludes  = schema.NS + "rangeIncludes"
+)
+
+// GraphQL scalar types of generated fields.
+var (
+	intDatatypes = []string{
+		nsXSD + "integer", nsXSD + "int", nsXSD + "long", nsXSD + "short",
+		nsXSD + "byte", nsXSD + "nonNegativeInteger", nsXSD + "positiveInteger",
+		nsXSD + "nonPositiveInteger", nsXSD + "negativeInteger",
+		nsXSD + "unsignedInt", nsXSD + "unsignedShort", nsXSD + "unsignedByte",
+		schema.Integer,
+	}
+	floatDatatypes = []string{
+		nsXSD + "decimal", nsXSD + "double", nsXSD + "float",
+		schema.Float, schema.Number,
+	}
+	booleanDatatypes = []string{
+		nsXSD + "boolean", schema.Boolean,
+	}
+	stringDatatypes = []string{
+		XSDString, rdfs.Literal, rdf.NS + "langString", schema.Text,
+	}
+)
+
+// RDFSchema is a set of GraphQL object types generated from the classes and
+// properties described in a schema graph using RDFS, OWL or Schema.org
+// terms.
+//
+// Each class becomes an object type with an id field containing the IRI of
+// the resource and a field for each property whose domain is the class or
+// one of its superclasses. Properties are list fields unless they are
+// declared as an owl:FunctionalProperty, and their type depends on their
+// range, which is either another class, a datatype (mapped to a GraphQL
+// scalar) or anything else (an IRI returned as a String).
+type RDFSchema struct {
+	Types []*RDFType
+
+	types      map[string]*RDFType
+	rootFields map[string]*RDFType
+}
+
+// RDFType is a GraphQL object type generated from an RDF class.
+type RDFType struct {
+	Name    string
+	Class   quad.IRI
+	Comment string
+	Fields  []*RDFField
+}
+
+// Field returns the field with the given name, or nil if it does not exist.
+func (t *RDFType) Field(name string) *RDFField {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// listField is the name of the root query field which lists resources of
+// the type.
+func (t *RDFType) listField() string {
+	return lowerFirst(t.Name) + "s"
+}
+
+// RDFField is a field of a generated type which contains the values of an
+// RDF property.
+type RDFField struct {
+	Name     string
+	Property quad.IRI
+	Comment  string
+
+	// Type is the name of either a GraphQL scalar or a generated type.
+	Type string
+
+	// Object is whether the field contains resources of a generated type.
+	Object bool
+
+	// IRI is whether the values of the field are IRIs rather than
+	// literals.
+	IRI bool
+
+	// Datatype is the datatype of literal values, and is empty for plain
+	// string values.
+	Datatype quad.IRI
+
+	// List is whether the field contains all values of the property
+	// rather than a single value.
+	List bool
+}
+
+// FilterValue returns the RDF term for a filter argument of the field.
+func (f *RDFField) FilterValue(v interface{}) quad.Value {
+	s := fmt.Sprint(v)
+	switch {
+	case f.IRI:
+		return quad.IRI(s)
+	case f.Datatype != "":
+		return quad.TypedString{Value: quad.String(s), Type: f.Datatype}
+	default:
+		return quad.String(s)
+	}
+}
+
+// LoadRDFSchema generates GraphQL types from the schema graph stored in qs.
+func LoadRDFSchema(ctx context.Context, qs graph.QuadStore) (*RDFSchema, error) {
+	types := make(map[quad.IRI]map[quad.IRI]bool)
+	domains := make(map[quad.IRI][]quad.IRI)
+	ranges := make(map[quad.IRI][]quad.IRI)
+	superClasses := make(map[quad.IRI][]quad.IRI)
+	comments := make(map[quad.IRI]string)
+	for _, t := range []struct {
+		preds []string
+		fn    func(s, o quad.IRI)
+	}{
+		{[]string{rdf.Type}, func(s, o quad.IRI) {
+			if types[s] == nil {
+				types[s] = make(map[quad.IRI]bool)
+			}
+			types[s][o] = true
+		}},
+		{[]string{rdfs.Domain, schemaDomainIncludes}, func(s, o quad.IRI) {
+			domains[s] = append(domains[s], o)
+		}},
+		{[]string{rdfs.Range, schemaRangeIncludes}, func(s, o quad.IRI) {
+			ranges[s] = append(ranges[s], o)
+		}},
+		{[]string{rdfs.SubClassOf}, func(s, o quad.IRI) {
+			superClasses[s] = append(superClasses[s], o)
+		}},
+	} {
+		quads, err := quadsWithPredicate(ctx, qs, t.preds...)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range quads {
+			s, sok := q.Subject.(quad.IRI)
+			o, ook := q.Object.(quad.IRI)
+			if sok && ook {
+				t.fn(s.Full(), o.Full())
+			}
+		}
+	}
+	quads, err := quadsWithPredicate(ctx, qs, rdfs.Comment)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range quads {
+		if s, ok := q.Subject.(quad.IRI); ok {
+			comments[s.Full()] = quad.ToString(q.Object)
+		}
+	}
+
+	// the classes are the resources with a class type along with any
+	// property domains
+	classes := make(map[quad.IRI]bool)
+	for s, t := range types {
+		if t[rdfs.NS+"Class"] || t[owlClass] || t[schema.NS+"Class"] {
+			classes[s] = true
+		}
+	}
+	for _, ds := range domains {
+		for _, d := range ds {
+			classes[d] = true
+		}
+	}
+
+	s := &RDFSchema{
+		types:      make(map[string]*RDFType),
+		rootFields: make(map[string]*RDFType),
+	}
+	reserved := builtinNames()
+	classTypes := make(map[quad.IRI]*RDFType, len(classes))
+	for _, class := range sortedIRIs(classes) {
+		t := &RDFType{
+			Name:    upperFirst(localName(class)),
+			Class:   class,
+			Comment: comments[class],
+		}
+		if reserved[t.Name] || isScalar(t.Name) {
+			return nil, fmt.Errorf("GraphQL type %s generated from %s conflicts with a built-in type", t.Name, class)
+		}
+		if other, ok := s.types[t.Name]; ok {
+			return nil, fmt.Errorf("GraphQL type %s generated from both %s and %s", t.Name, other.Class, class)
+		}
+		for _, name := range []string{lowerFirst(t.Name), t.listField()} {
+			if reserved["Query."+name] {
+				return nil, fmt.Errorf("GraphQL query field %s generated from %s conflicts with a built-in field", name, class)
+			}
+			if other, ok := s.rootFields[name]; ok {
+				return nil, fmt.Errorf("GraphQL query field %s generated from both %s and %s", name, other.Class, class)
+			}
+			s.rootFields[name] = t
+		}
+		s.types[t.Name] = t
+		s.Types = append(s.Types, t)
+		classTypes[class] = t
+	}
+
+	// add fields for each property to the types generated from its
+	// domains and their subclasses
+	props := make(map[quad.IRI]bool, len(domains))
+	for p := range domains {
+		props[p] = true
+	}
+	for _, prop := range sortedIRIs(props) {
+		field := &RDFField{
+			Name:     lowerFirst(localName(prop)),
+			Property: prop,
+			Comment:  comments[prop],
+			List:     !types[prop][owlFunctionalProperty],
+		}
+		setFieldType(field, ranges[prop], classTypes)
+		for _, t := range s.Types {
+			if !inDomain(t.Class, domains[prop], superClasses) {
+				continue
+			}
+			if field.Name == "id" || field.Name == "__typename" {
+				return nil, fmt.Errorf("GraphQL field %s.%s generated from %s conflicts with a built-in field", t.Name, field.Name, prop)
+			}
+			if other := t.Field(field.Name); other != nil {
+				return nil, fmt.Errorf("GraphQL field %s.%s generated from both %s and %s", t.Name, field.Name, other.Property, prop)
+			}
+			t.Fields = append(t.Fields, field)
+		}
+	}
+
+	if len(s.Types) == 0 {
+		return nil, errors.New("no classes found in schema graph")
+	}
+	if _, err := graphql.ParseSchema(s.mergeSchema(GraphQLSchema), nil); err != nil {
+		return nil, fmt.Errorf("error parsing generated GraphQL schema: %s", err)
+	}
+	return s, nil
+}
+
+// Type returns the generated type with the given name, or nil if it does
+// not exist.
+func (s *RDFSchema) Type(name string) *RDFType {
+	return s.types[name]
+}
+
+// GraphQLSchema returns the generated GraphQL schema, which has a query
+// field for loading a resource of each type from a graph by IRI and
+// another for listing the resources of each type in a graph.
+func (s *RDFSchema) GraphQLSchema() string {
+	return "schema {\n  query: Query\n}\n\ntype Query {\n" + s.queryFields() + "}\n" + s.typeDefinitions()
+}
+
+// mergeSchema returns the given schema with the generated query fields
+// added to its Query type and the generated types appended.
+func (s *RDFSchema) mergeSchema(schema string) string {
+	return strings.Replace(schema, "type Query {\n", "type Query {\n"+s.queryFields()+"\n", 1) + s.typeDefinitions()
+}
+
+// queryFields returns the definitions of the generated query fields.
+func (s *RDFSchema) queryFields() string {
+	var b bytes.Buffer
+	for i, t := range s.Types {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  %s(graph: String!, id: String!): %s\n\n", lowerFirst(t.Name), t.Name)
+		fmt.Fprintf(&b, "  %s(graph: String!, filter: %sFilter, first: Int, skip: Int): [%s!]!\n", t.listField(), t.Name, t.Name)
+	}
+	return b.String()
+}
+
+// typeDefinitions returns the definitions of the generated object types
+// and the filter input types of their list fields.
+func (s *RDFSchema) typeDefinitions() string {
+	var b bytes.Buffer
+	for _, t := range s.Types {
+		b.WriteString("\n")
+		writeDescription(&b, "", fmt.Sprintf("%s is generated from %s.", t.Name, t.Class), t.Comment)
+		fmt.Fprintf(&b, "type %s {\n  id: String!\n", t.Name)
+		for _, f := range t.Fields {
+			b.WriteString("\n")
+			writeDescription(&b, "  ", f.Comment)
+			typ := f.Type
+			if f.List {
+				typ = "[" + typ + "!]!"
+			}
+			fmt.Fprintf(&b, "  %s: %s\n", f.Name, typ)
+		}
+		fmt.Fprintf(&b, "}\n\ninput %sFilter {\n  id: String\n", t.Name)
+		for _, f := range t.Fields {
+			typ := f.Type
+			if f.Object {
+				typ = "String"
+			}
+			fmt.Fprintf(&b, "  %s: %s\n", f.Name, typ)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// writeDescription writes the non-empty lines of the given descriptions as
+// GraphQL comments.
+func writeDescription(b *bytes.Buffer, indent string, descriptions ...string) {
+	for _, d := range descriptions {
+		for _, line := range strings.Split(d, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(b, "%s# %s\n", indent, line)
+			}
+		}
+	}
+}
+
+// setFieldType sets the type of a field from the ranges of its property,
+// preferring ranges which are generated types.
+func setFieldType(f *RDFField, ranges []quad.IRI, classTypes map[quad.IRI]*RDFType) {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i] < ranges[j] })
+	for _, r := range ranges {
+		if t, ok := classTypes[r]; ok {
+			f.Type, f.Object, f.IRI = t.Name, true, true
+			return
+		}
+	}
+	f.Type = "String"
+	if len(ranges) == 0 {
+		return
+	}
+	r := ranges[0]
+	switch {
+	case containsIRI(intDatatypes, r):
+		f.Type, f.Datatype = "Int", r
+	case containsIRI(floatDatatypes, r):
+		f.Type, f.Datatype = "Float", r
+	case containsIRI(booleanDatatypes, r):
+		f.Type, f.Datatype = "Boolean", r
+	case containsIRI(stringDatatypes, r):
+	case strings.HasPrefix(string(r), nsXSD) || strings.HasPrefix(string(r), schema.NS):
+		f.Datatype = r
+	default:
+		f.IRI = true
+	}
+}
+
+// inDomain returns whether the class or any of its superclasses is one of
+// the given domains.
+func inDomain(class quad.IRI, domains []quad.IRI, superClasses map[quad.IRI][]quad.IRI) bool {
+	seen := make(map[quad.IRI]bool)
+	queue := []quad.IRI{class}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		for _, d := range domains {
+			if d == c {
+				return true
+			}
+		}
+		queue = append(queue, superClasses[c]...)
+	}
+	return false
+}
+
+// quadsWithPredicate returns the quads in the store with any of the given
+// predicates, which are matched in both their full and prefixed forms.
+func quadsWithPredicate(ctx context.Context, qs graph.QuadStore, preds ...string) ([]quad.Quad, error) {
+	var quads []quad.Quad
+	seen := make(map[quad.IRI]bool)
+	for _, p := range preds {
+		for _, iri := range []quad.IRI{quad.IRI(p).Full(), quad.IRI(p).Short()} {
+			if seen[iri] {
+				continue
+			}
+			seen[iri] = true
+			node := qs.ValueOf(iri)
+			if node == nil {
+				continue
+			}
+			it := qs.QuadIterator(quad.Predicate, node)
+			for it.Next(ctx) {
+				quads = append(quads, qs.Quad(it.Result()))
+			}
+			err := it.Err()
+			it.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return quads, nil
+}
+
+// builtinNames returns the names of the types and query fields in the
+// built-in GraphQL schema, with query fields prefixed by "Query.".
+func builtinNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, m := range regexp.MustCompile(`(?m)^(?:type|input|enum|scalar|interface|union) (\w+)`).FindAllStringSubmatch(GraphQLSchema, -1) {
+		names[m[1]] = true
+	}
+	query := regexp.MustCompile(`(?s)type Query \{(.*?)\}`).FindStringSubmatch(GraphQLSchema)
+	for _, m := range regexp.MustCompile(`(?m)^\s*(\w+)[(:]`).FindAllStringSubmatch(query[1], -1) {
+		names["Query."+m[1]] = true
+	}
+	return names
+}
+
+func isScalar(name string) bool {
+	switch name {
+	case "Int", "Float", "String", "Boolean", "ID":
+		return true
+	default:
+		return false
+	}
+}
+
+func containsIRI(iris []string, iri quad.IRI) bool {
+	for _, v := range iris {
+		if quad.IRI(v).Full() == iri {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedIRIs(set map[quad.IRI]bool) []quad.IRI {
+	iris := make([]quad.IRI, 0, len(set))
+	for iri := range set {
+		iris = append(iris, iri)
+	}
+	sort.Slice(iris, func(i, j int) bool { return iris[i] < iris[j] })
+	return iris
+}
+
+// localName returns the last segment of an IRI as a valid GraphQL name.
+func localName(iri quad.IRI) string {
+	s := string(iri)
+	if i := strings.LastIndexAny(s, "#/:"); i >= 0 && i < len(s)-1 {
+		s = s[i+1:]
+	}
+	name := []rune(s)
+	for i, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || unicode.IsDigit(name[0]) {
+		name = append([]rune{'_'}, name...)
+	}
+	return string(name)
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,307 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cayleygraph "github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/quad/nquads"
+	"github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/graphql"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+const testRDFSchema = `
+<http://example.com/Recording> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.w3.org/2000/01/rdf-schema#Class> .
+<http://example.com/Recording> <http://www.w3.org/2000/01/rdf-schema#comment> "A sound recording." .
+<http://example.com/MusicRecording> <http://www.w3.org/2000/01/rdf-schema#subClassOf> <http://example.com/Recording> .
+<http://example.com/MusicRecording> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.w3.org/2000/01/rdf-schema#Class> .
+<http://example.com/Person> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.w3.org/2002/07/owl#Class> .
+<http://example.com/title> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.w3.org/2002/07/owl#FunctionalProperty> .
+<http://example.com/title> <http://www.w3.org/2000/01/rdf-schema#domain> <http://example.com/Recording> .
+<http://example.com/title> <http://www.w3.org/2000/01/rdf-schema#range> <http://www.w3.org/2001/XMLSchema#string> .
+<http://example.com/duration> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.w3.org/2002/07/owl#FunctionalProperty> .
+<http://example.com/duration> <http://www.w3.org/2000/01/rdf-schema#domain> <http://example.com/Recording> .
+<http://example.com/duration> <http://www.w3.org/2000/01/rdf-schema#range> <http://www.w3.org/2001/XMLSchema#integer> .
+<http://example.com/performer> <http://www.w3.org/2000/01/rdf-schema#domain> <http://example.com/Recording> .
+<http://example.com/performer> <http://www.w3.org/2000/01/rdf-schema#range> <http://example.com/Person> .
+<http://example.com/isrc> <http://www.w3.org/2000/01/rdf-schema#domain> <http://example.com/Recording> .
+<http://example.com/name> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.w3.org/2002/07/owl#FunctionalProperty> .
+<http://example.com/name> <http://schema.org/domainIncludes> <http://example.com/Person> .
+<http://example.com/name> <http://schema.org/rangeIncludes> <http://schema.org/Text> .
+`
+
+const testRDFData = `
+<http://example.com/rec1> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.com/Recording> .
+<http://example.com/rec1> <http://example.com/title> "Song A" .
+<http://example.com/rec1> <http://example.com/duration> "180"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://example.com/rec1> <http://example.com/performer> <http://example.com/alice> .
+<http://example.com/rec1> <http://example.com/performer> <http://example.com/bob> .
+<http://example.com/rec1> <http://example.com/isrc> "GBAYE0000001" .
+<http://example.com/rec2> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.com/Recording> .
+<http://example.com/rec2> <http://example.com/title> "Song B" .
+<http://example.com/rec2> <http://example.com/performer> <http://example.com/alice> .
+<http://example.com/alice> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.com/Person> .
+<http://example.com/alice> <http://example.com/name> "Alice" .
+<http://example.com/bob> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.com/Person> .
+<http://example.com/bob> <http://example.com/name> "Bob" .
+`
+
+func TestRDFSchema(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	driver := graph.NewDriver("kord-rdf-test", ts, testutil.NewTestRegistry(), ts.Dir)
+	api, err := NewAPI(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Auth.Tokens = []string{testAPIToken}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	client := NewClient(srv.URL)
+	client.SetToken(testAPIToken)
+
+	// load the schema and data into a graph
+	id := testKordID.Hex()
+	if _, err := client.CreateGraph(id); err != nil {
+		t.Fatal(err)
+	}
+	var deltas []cayleygraph.Delta
+	r := nquads.NewReader(strings.NewReader(testRDFSchema+testRDFData), false)
+	for {
+		q, err := r.ReadQuad()
+		if err != nil {
+			break
+		}
+		deltas = append(deltas, cayleygraph.Delta{Quad: q, Action: cayleygraph.Add})
+	}
+	if _, err := client.ApplyDeltas(id, deltas, cayleygraph.IgnoreOpts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// generate the types
+	qs, err := driver.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Release(id)
+	s, err := LoadRDFSchema(context.Background(), qs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []struct {
+		typ, field, fieldType string
+		list                  bool
+	}{
+		{"Recording", "title", "String", false},
+		{"Recording", "duration", "Int", false},
+		{"Recording", "performer", "Person", true},
+		{"Recording", "isrc", "String", true},
+		{"MusicRecording", "title", "String", false},
+		{"Person", "name", "String", false},
+	} {
+		typ := s.Type(x.typ)
+		if typ == nil {
+			t.Fatalf("missing type %s", x.typ)
+		}
+		f := typ.Field(x.field)
+		if f == nil {
+			t.Fatalf("missing field %s.%s", x.typ, x.field)
+		}
+		if f.Type != x.fieldType || f.List != x.list {
+			t.Fatalf("expected %s.%s to have type %s (list %t), got %s (list %t)", x.typ, x.field, x.fieldType, x.list, f.Type, f.List)
+		}
+	}
+	if err := api.SetRDFSchema(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// list recordings by a performer, with a page size which keeps the
+	// nested performers within the complexity limit
+	query := `
+query Recordings($graph: String!, $performer: String) {
+  recordings(graph: $graph, filter: {performer: $performer}, first: 10) {
+    id
+    title
+    duration
+    isrc
+    performers: performer {
+      name
+    }
+  }
+}
+`
+	var v struct {
+		Recordings []struct {
+			ID         string   `json:"id"`
+			Title      string   `json:"title"`
+			Duration   *int     `json:"duration"`
+			ISRC       []string `json:"isrc"`
+			Performers []struct {
+				Name string `json:"name"`
+			} `json:"performers"`
+		} `json:"recordings"`
+	}
+	variables := graphql.Variables{"graph": id, "performer": "http://example.com/bob"}
+	if _, err := client.Do(query, variables, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Recordings) != 1 {
+		t.Fatalf("expected 1 recording, got %d", len(v.Recordings))
+	}
+	rec := v.Recordings[0]
+	if rec.ID != "http://example.com/rec1" || rec.Title != "Song A" || rec.Duration == nil || *rec.Duration != 180 {
+		t.Fatalf("unexpected recording: %+v", rec)
+	}
+	if len(rec.ISRC) != 1 || rec.ISRC[0] != "GBAYE0000001" {
+		t.Fatalf("unexpected ISRCs: %v", rec.ISRC)
+	}
+	if len(rec.Performers) != 2 || rec.Performers[0].Name != "Alice" || rec.Performers[1].Name != "Bob" {
+		t.Fatalf("unexpected performers: %+v", rec.Performers)
+	}
+
+	// page through all recordings
+	variables = graphql.Variables{"graph": id}
+	for skip, expected := range []string{"Song A", "Song B"} {
+		query := `
+query Recordings($graph: String!, $skip: Int) {
+  recordings(graph: $graph, first: 1, skip: $skip) {
+    title
+  }
+}
+`
+		variables["skip"] = skip
+		if _, err := client.Do(query, variables, &v); err != nil {
+			t.Fatal(err)
+		}
+		if len(v.Recordings) != 1 || v.Recordings[0].Title != expected {
+			t.Fatalf("expected %q, got %+v", expected, v.Recordings)
+		}
+	}
+
+	// load a recording using a fragment
+	query = `
+query Recording($graph: String!) {
+  recording(graph: $graph, id: "http://example.com/rec2") {
+    __typename
+    ...performers
+  }
+  missing: recording(graph: $graph, id: "http://example.com/alice") {
+    id
+  }
+}
+
+fragment performers on Recording {
+  performer {
+    id
+  }
+}
+`
+	var recording struct {
+		Recording struct {
+			Typename  string `json:"__typename"`
+			Performer []struct {
+				ID string `json:"id"`
+			} `json:"performer"`
+		} `json:"recording"`
+		Missing *struct{} `json:"missing"`
+	}
+	if _, err := client.Do(query, variables, &recording); err != nil {
+		t.Fatal(err)
+	}
+	if recording.Recording.Typename != "Recording" || len(recording.Recording.Performer) != 1 || recording.Recording.Performer[0].ID != "http://example.com/alice" {
+		t.Fatalf("unexpected recording: %+v", recording.Recording)
+	}
+	if recording.Missing != nil {
+		t.Fatal("expected a person not to be loaded as a recording")
+	}
+
+	// check invalid queries are rejected
+	if _, err := client.Do(`{ recordings(graph: "x") { unknown } }`, nil, nil); err == nil {
+		t.Fatal("expected error querying unknown field")
+	}
+
+	// check the built-in schema is still served along with the generated
+	// schema
+	var generated struct {
+		GeneratedSchema string `json:"generatedSchema"`
+	}
+	if _, err := client.Do(`{ generatedSchema }`, nil, &generated); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(generated.GeneratedSchema, "type Recording {") {
+		t.Fatalf("unexpected generated schema:\n%s", generated.GeneratedSchema)
+	}
+	property := "kord:property"
+	if _, err := client.Claim(id, &ClaimFilter{Property: &property}); err != nil {
+		t.Fatal(err)
+	}
+
+	// check a single query can select both built-in and generated fields,
+	// and that the generated types can be introspected
+	query = `
+query Mixed($graph: String!) {
+  graph(id: $graph) {
+    id
+  }
+  recording(graph: $graph, id: "http://example.com/rec1") {
+    title
+  }
+  __type(name: "Recording") {
+    fields {
+      name
+    }
+  }
+}
+`
+	var mixed struct {
+		Graph struct {
+			ID string `json:"id"`
+		} `json:"graph"`
+		Recording struct {
+			Title string `json:"title"`
+		} `json:"recording"`
+		Type struct {
+			Fields []struct {
+				Name string `json:"name"`
+			} `json:"fields"`
+		} `json:"__type"`
+	}
+	if _, err := client.Do(query, variables, &mixed); err != nil {
+		t.Fatal(err)
+	}
+	if mixed.Graph.ID != id || mixed.Recording.Title != "Song A" {
+		t.Fatalf("unexpected response: %+v", mixed)
+	}
+	var fields []string
+	for _, f := range mixed.Type.Fields {
+		fields = append(fields, f.Name)
+	}
+	if strings.Join(fields, ",") != "id,duration,isrc,performer,title" {
+		t.Fatalf("unexpected Recording fields: %v", fields)
+	}
+}
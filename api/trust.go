@@ -0,0 +1,271 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/cayleygraph/cayley/graph"
+)
+
+const (
+	// DefaultTrustDepth is the default maximum length of trust paths.
+	DefaultTrustDepth = 3
+
+	// MaxTrustDepth is the maximum supported length of trust paths.
+	MaxTrustDepth = 6
+
+	// DefaultTrustPaths is the default maximum number of trust paths
+	// returned by a search.
+	DefaultTrustPaths = 100
+
+	// MaxTrustPaths is the maximum number of trust paths returned by a
+	// search.
+	MaxTrustPaths = 1000
+
+	// maxTrustFrontier is the maximum number of partial paths a trust path
+	// search keeps in memory.
+	maxTrustFrontier = 100000
+
+	// DefaultTrustDamping is the default probability of following a claim
+	// rather than jumping back to a root ID when computing trust scores.
+	DefaultTrustDamping = 0.85
+
+	// DefaultTrustIterations is the default number of power iterations used
+	// when computing trust scores.
+	DefaultTrustIterations = 20
+
+	// MaxTrustIterations is the maximum number of power iterations used
+	// when computing trust scores.
+	MaxTrustIterations = 100
+)
+
+// TrustPath is a chain of claims where the subject of each claim is the
+// issuer of the next.
+type TrustPath []*Claim
+
+// TrustPaths returns at most maxPaths paths of validly signed claims in the
+// graph leading from one KORD ID to another, with at most maxDepth claims per
+// path and no ID visited twice. Paths are found with a breadth first search
+// so are ordered shortest first, and the search stops once the context is
+// done.
+func TrustPaths(ctx context.Context, qs graph.QuadStore, from, to ID, maxDepth, maxPaths int) ([]TrustPath, error) {
+	if maxDepth < 1 || maxDepth > MaxTrustDepth {
+		return nil, fmt.Errorf("invalid trust path depth %d, must be between 1 and %d", maxDepth, MaxTrustDepth)
+	}
+	if maxPaths < 0 || maxPaths > MaxTrustPaths {
+		return nil, fmt.Errorf("invalid trust path count %d, must be between 0 and %d", maxPaths, MaxTrustPaths)
+	}
+	issued := make(map[ID][]*Claim)
+	issuedBy := func(id ID) ([]*Claim, error) {
+		if claims, ok := issued[id]; ok {
+			return claims, nil
+		}
+		issuer := id.Hex()
+		claims, err := loadClaims(ctx, qs, claimPath(qs, &ClaimFilter{Issuer: &issuer}))
+		if err != nil {
+			return nil, err
+		}
+		claims = validClaims(claims)
+		issued[id] = claims
+		return claims, nil
+	}
+
+	// visits returns whether the path from the root visits the ID
+	visits := func(path TrustPath, id ID) bool {
+		if id == from {
+			return true
+		}
+		for _, claim := range path {
+			if claim.Subject == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	paths := []TrustPath{}
+	queue := []TrustPath{{}}
+	for len(queue) > 0 && len(paths) < maxPaths {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		current := queue[0]
+		queue = queue[1:]
+		if len(current) == maxDepth {
+			continue
+		}
+		id := from
+		if len(current) > 0 {
+			id = current[len(current)-1].Subject
+		}
+		claims, err := issuedBy(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, claim := range claims {
+			if visits(current, claim.Subject) {
+				continue
+			}
+			next := make(TrustPath, len(current), len(current)+1)
+			copy(next, current)
+			next = append(next, claim)
+			if claim.Subject == to {
+				paths = append(paths, next)
+				if len(paths) == maxPaths {
+					break
+				}
+				continue
+			}
+			if len(queue) == maxTrustFrontier {
+				return nil, fmt.Errorf("trust path search exceeded %d partial paths, use a lower depth", maxTrustFrontier)
+			}
+			queue = append(queue, next)
+		}
+	}
+	return paths, nil
+}
+
+// TrustConfig configures the computation of trust scores.
+type TrustConfig struct {
+	// Roots are the KORD IDs which are trusted a priori.
+	Roots []ID
+
+	// Damping is the probability of following a claim rather than jumping
+	// back to a root ID.
+	Damping float64
+
+	// Iterations is the number of power iterations to run.
+	Iterations int
+}
+
+// DefaultTrustConfig returns a TrustConfig with default parameters for the
+// given root IDs.
+func DefaultTrustConfig(roots ...ID) *TrustConfig {
+	return &TrustConfig{
+		Roots:      roots,
+		Damping:    DefaultTrustDamping,
+		Iterations: DefaultTrustIterations,
+	}
+}
+
+// TrustScore is the trust score of a KORD ID.
+type TrustScore struct {
+	ID    ID
+	Score float64
+}
+
+// TrustScores computes personalised PageRank scores over the graph of validly
+// signed claims, where each claim is an edge from its issuer to its subject
+// and random jumps return to the configured root IDs. Scores sum to 1 and are
+// ordered highest first.
+func TrustScores(ctx context.Context, qs graph.QuadStore, config *TrustConfig) ([]TrustScore, error) {
+	if len(config.Roots) == 0 {
+		return nil, fmt.Errorf("missing trust roots")
+	}
+	if config.Damping < 0 || config.Damping >= 1 {
+		return nil, fmt.Errorf("invalid trust damping %v, must be in the range [0, 1)", config.Damping)
+	}
+	if config.Iterations < 1 || config.Iterations > MaxTrustIterations {
+		return nil, fmt.Errorf("invalid trust iterations %d, must be between 1 and %d", config.Iterations, MaxTrustIterations)
+	}
+
+	// build the adjacency lists, ignoring self claims and counting
+	// multiple claims between the same IDs once
+	claims, err := loadClaims(ctx, qs, claimPath(qs, &ClaimFilter{}))
+	if err != nil {
+		return nil, err
+	}
+	edges := make(map[ID]map[ID]struct{})
+	nodes := make(map[ID]struct{})
+	for _, claim := range validClaims(claims) {
+		nodes[claim.Issuer] = struct{}{}
+		nodes[claim.Subject] = struct{}{}
+		if claim.Issuer == claim.Subject {
+			continue
+		}
+		if _, ok := edges[claim.Issuer]; !ok {
+			edges[claim.Issuer] = make(map[ID]struct{})
+		}
+		edges[claim.Issuer][claim.Subject] = struct{}{}
+	}
+
+	teleport := make(map[ID]float64, len(config.Roots))
+	for _, root := range config.Roots {
+		nodes[root] = struct{}{}
+		teleport[root] += 1 / float64(len(config.Roots))
+	}
+	scores := make(map[ID]float64, len(nodes))
+	for id, v := range teleport {
+		scores[id] = v
+	}
+	for i := 0; i < config.Iterations; i++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		next := make(map[ID]float64, len(nodes))
+		dangling := 0.0
+		for id, score := range scores {
+			out := edges[id]
+			if len(out) == 0 {
+				dangling += score
+				continue
+			}
+			share := config.Damping * score / float64(len(out))
+			for subject := range out {
+				next[subject] += share
+			}
+			dangling += (1 - config.Damping) * score
+		}
+		for id, v := range teleport {
+			next[id] += dangling * v
+		}
+		scores = next
+	}
+
+	result := make([]TrustScore, 0, len(nodes))
+	for id := range nodes {
+		result = append(result, TrustScore{ID: id, Score: scores[id]})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].ID.Hex() < result[j].ID.Hex()
+	})
+	return result, nil
+}
+
+// validClaims returns the claims with valid issuer signatures.
+func validClaims(claims []*Claim) []*Claim {
+	valid := make([]*Claim, 0, len(claims))
+	for _, claim := range claims {
+		if VerifyClaim(claim) {
+			valid = append(valid, claim)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,145 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+func TestTrust(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	driver := graph.NewDriver("kord-trust-test", ts, testutil.NewTestRegistry(), ts.Dir)
+	resolver := NewResolver(driver)
+	if _, err := driver.Create(testKordID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+
+	// create claims a -> b, b -> c and a -> c
+	keys := make([]*ecdsa.PrivateKey, 3)
+	ids := make([]ID, 3)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+		ids[i] = NewID(crypto.PubkeyToAddress(key.PublicKey))
+	}
+	a, b, c := ids[0], ids[1], ids[2]
+	for _, edge := range [][2]int{{0, 1}, {1, 2}, {0, 2}} {
+		claim := &Claim{
+			Issuer:   ids[edge[0]],
+			Subject:  ids[edge[1]],
+			Property: "trusts",
+			Claim:    quad.String("true"),
+		}
+		id := claim.ID()
+		claim.Signature, err = crypto.Sign(id[:], keys[edge[0]])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := resolver.writeClaim(context.Background(), testKordID.Hex(), claim); err != nil {
+			t.Fatal(err)
+		}
+	}
+	qs, err := driver.Get(testKordID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Release(testKordID.Hex())
+
+	// check the trust paths from a to c
+	paths, err := TrustPaths(context.Background(), qs, a, c, DefaultTrustDepth, DefaultTrustPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 trust paths, got %d", len(paths))
+	}
+	if len(paths[0]) != 1 || paths[0][0].Issuer != a || paths[0][0].Subject != c {
+		t.Fatalf("unexpected shortest trust path: %v", paths[0])
+	}
+	if len(paths[1]) != 2 || paths[1][0].Subject != b || paths[1][1].Subject != c {
+		t.Fatalf("unexpected second trust path: %v", paths[1])
+	}
+	paths, err = TrustPaths(context.Background(), qs, c, a, DefaultTrustDepth, DefaultTrustPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 0 {
+		t.Fatalf("expected no trust paths from c to a, got %d", len(paths))
+	}
+
+	// check the number of paths is limited to the shortest, and that the
+	// search stops once the context is done
+	paths, err = TrustPaths(context.Background(), qs, a, c, DefaultTrustDepth, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || len(paths[0]) != 1 {
+		t.Fatalf("expected the shortest trust path, got %v", paths)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := TrustPaths(ctx, qs, a, c, DefaultTrustDepth, DefaultTrustPaths); err != context.Canceled {
+		t.Fatalf("expected context.Canceled error, got %v", err)
+	}
+
+	// check the trust scores rooted at a
+	scores, err := TrustScores(context.Background(), qs, DefaultTrustConfig(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 3 {
+		t.Fatalf("expected 3 trust scores, got %d", len(scores))
+	}
+	if scores[0].ID != a || scores[1].ID != c || scores[2].ID != b {
+		t.Fatalf("unexpected trust score order: %v", scores)
+	}
+	var total float64
+	for _, score := range scores {
+		total += score.Score
+	}
+	if total < 0.999 || total > 1.001 {
+		t.Fatalf("expected trust scores to sum to 1, got %v", total)
+	}
+
+	// check the number of iterations is limited, and that the computation
+	// stops once the context is done
+	config := DefaultTrustConfig(a)
+	config.Iterations = MaxTrustIterations + 1
+	if _, err := TrustScores(context.Background(), qs, config); err == nil {
+		t.Fatalf("expected error computing %d trust iterations", config.Iterations)
+	}
+	if _, err := TrustScores(ctx, qs, DefaultTrustConfig(a)); err != context.Canceled {
+		t.Fatalf("expected context.Canceled error, got %v", err)
+	}
+}
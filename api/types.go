@@ -21,7 +21,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 
+	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/quad"
 	"github.com/cayleygraph/cayley/voc"
 	"github.com/ethereum/go-ethereum/common"
@@ -50,44 +53,104 @@ type GraphInput struct {
 }
 
 type SetGraphInput struct {
-	ID        string `json:"id"`
-	Hash      string `json:"hash"`
-	Signature string `json:"signature"`
+	ID        string  `json:"id"`
+	Hash      string  `json:"hash"`
+	Signature string  `json:"signature"`
+	Nonce     *string `json:"nonce,omitempty"`
 }
 
+// DelegateInput is the input of GraphQL addDelegate and removeDelegate
+// mutations.
+type DelegateInput struct {
+	Graph    string `json:"graph"`
+	Delegate string `json:"delegate"`
+}
+
+// quad returns the quad which links the owner of the graph to the delegate.
+func (d *DelegateInput) quad() (quad.Quad, error) {
+	if !common.IsHexAddress(d.Graph) {
+		return quad.Quad{}, fmt.Errorf("graph %s is not owned by a KORD ID", d.Graph)
+	}
+	if !common.IsHexAddress(d.Delegate) {
+		return quad.Quad{}, fmt.Errorf("invalid delegate KORD ID, must be a hex string: %s", d.Delegate)
+	}
+	owner := common.HexToAddress(d.Graph)
+	delegate := common.HexToAddress(d.Delegate)
+	return quad.Make(quad.IRI(owner.Hex()), delegatePredicate, quad.IRI(delegate.Hex()), nil), nil
+}
+
+// ReadPolicyInput is the input of a GraphQL setReadPolicy mutation.
+type ReadPolicyInput struct {
+	Graph  string `json:"graph"`
+	Policy string `json:"policy"`
+}
+
+// ReaderInput is the input of GraphQL addReader and removeReader mutations.
+type ReaderInput struct {
+	Graph  string `json:"graph"`
+	Reader string `json:"reader"`
+}
+
+// quad returns the quad which links the owner of the graph to the reader.
+func (r *ReaderInput) quad() (quad.Quad, error) {
+	if !common.IsHexAddress(r.Graph) {
+		return quad.Quad{}, fmt.Errorf("graph %s is not owned by a KORD ID", r.Graph)
+	}
+	if !common.IsHexAddress(r.Reader) {
+		return quad.Quad{}, fmt.Errorf("invalid reader KORD ID, must be a hex string: %s", r.Reader)
+	}
+	owner := common.HexToAddress(r.Graph)
+	reader := common.HexToAddress(r.Reader)
+	return quad.Make(quad.IRI(owner.Hex()), readerPredicate, quad.IRI(reader.Hex()), nil), nil
+}
+
+// Claim is a signed statement by an issuer that a property of the subject
+// has a value, which can be any RDF value (a string, IRI, typed literal or
+// language string).
 type Claim struct {
 	Issuer    ID
 	Subject   ID
 	Property  string
-	Claim     string
+	Claim     quad.Value
 	Signature []byte
 }
 
+// ID returns the claim ID, which is the hash signed by the issuer.
 func (c *Claim) ID() common.Hash {
 	return crypto.Keccak256Hash(
 		c.Issuer.Address[:],
 		c.Subject.Address[:],
 		[]byte(c.Property),
-		[]byte(c.Claim),
+		claimValueBytes(c.Claim),
 	)
 }
 
+// claimJSON is the JSON representation of a claim, with the claim value in
+// its JSON-LD form. A plain string claim value can alternatively be typed
+// with claimType and claimLanguage, which is how the GraphQL API returns
+// claim values.
 type claimJSON struct {
-	ID        string `json:"id"`
-	Issuer    string `json:"issuer"`
-	Subject   string `json:"subject"`
-	Property  string `json:"property"`
-	Claim     string `json:"claim"`
-	Signature string `json:"signature"`
+	ID            string          `json:"id"`
+	Issuer        string          `json:"issuer"`
+	Subject       string          `json:"subject"`
+	Property      string          `json:"property"`
+	Claim         json.RawMessage `json:"claim"`
+	ClaimType     *string         `json:"claimType,omitempty"`
+	ClaimLanguage *string         `json:"claimLanguage,omitempty"`
+	Signature     string          `json:"signature"`
 }
 
 func (c *Claim) MarshalJSON() ([]byte, error) {
+	value, err := json.Marshal(marshalClaimValue(c.Claim))
+	if err != nil {
+		return nil, err
+	}
 	return json.Marshal(&claimJSON{
 		ID:        c.ID().String(),
 		Issuer:    c.Issuer.Hex(),
 		Subject:   c.Subject.Hex(),
 		Property:  c.Property,
-		Claim:     c.Claim,
+		Claim:     value,
 		Signature: hexutil.Encode(c.Signature),
 	})
 }
@@ -97,11 +160,32 @@ func (c *Claim) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &v); err != nil {
 		return err
 	}
+	value, err := unmarshalClaimValue(v.Claim)
+	if err != nil {
+		return err
+	}
+	if v.ClaimType != nil || v.ClaimLanguage != nil {
+		s, ok := value.(quad.String)
+		if !ok {
+			return errors.New("claimType and claimLanguage require a string claim value")
+		}
+		var typ, lang string
+		if v.ClaimType != nil {
+			typ = *v.ClaimType
+		}
+		if v.ClaimLanguage != nil {
+			lang = *v.ClaimLanguage
+		}
+		value, err = NewClaimValue(string(s), typ, lang)
+		if err != nil {
+			return err
+		}
+	}
 	*c = Claim{
 		Issuer:    HexToID(v.Issuer),
 		Subject:   HexToID(v.Subject),
 		Property:  v.Property,
-		Claim:     v.Claim,
+		Claim:     value,
 		Signature: common.FromHex(v.Signature),
 	}
 	return nil
@@ -110,12 +194,12 @@ func (c *Claim) UnmarshalJSON(b []byte) error {
 type claimQuad struct {
 	rdfType struct{} `quad:"@type > id:Claim"`
 
-	ID        quad.IRI `quad:"@id"`
-	Issuer    quad.IRI `quad:"kord:issuer"`
-	Subject   quad.IRI `quad:"kord:subject"`
-	Property  string   `quad:"kord:property"`
-	Claim     string   `quad:"kord:claim"`
-	Signature string   `quad:"kord:signature"`
+	ID        quad.IRI   `quad:"@id"`
+	Issuer    quad.IRI   `quad:"kord:issuer"`
+	Subject   quad.IRI   `quad:"kord:subject"`
+	Property  string     `quad:"kord:property"`
+	Claim     quad.Value `quad:"kord:claim"`
+	Signature string     `quad:"kord:signature"`
 }
 
 func (c *Claim) Quad() *claimQuad {
@@ -124,7 +208,7 @@ func (c *Claim) Quad() *claimQuad {
 		Issuer:    quad.IRI(c.Issuer.Hex()),
 		Subject:   quad.IRI(c.Subject.Hex()),
 		Property:  c.Property,
-		Claim:     c.Claim,
+		Claim:     canonicalValue(c.Claim),
 		Signature: hexutil.Encode(c.Signature),
 	}
 }
@@ -134,23 +218,166 @@ func (c *claimQuad) ToClaim() *Claim {
 		Issuer:    HexToID(string(c.Issuer)),
 		Subject:   HexToID(string(c.Subject)),
 		Property:  c.Property,
-		Claim:     c.Claim,
+		Claim:     canonicalValue(c.Claim),
 		Signature: common.FromHex(c.Signature),
 	}
 }
 
 type ClaimFilter struct {
-	Issuer   *string `json:"issuer"`
-	Subject  *string `json:"subject"`
-	Property *string `json:"property"`
-	Claim    *string `json:"claim"`
+	Issuer        *string `json:"issuer"`
+	Subject       *string `json:"subject"`
+	Property      *string `json:"property"`
+	Claim         *string `json:"claim"`
+	ClaimType     *string `json:"claimType"`
+	ClaimLanguage *string `json:"claimLanguage"`
 }
 
 type ClaimInput struct {
-	Graph     string `json:"graph"`
-	Issuer    string `json:"issuer"`
-	Subject   string `json:"subject"`
-	Property  string `json:"property"`
-	Claim     string `json:"claim"`
-	Signature string `json:"signature"`
+	Graph         string  `json:"graph"`
+	Issuer        string  `json:"issuer"`
+	Subject       string  `json:"subject"`
+	Property      string  `json:"property"`
+	Claim         string  `json:"claim"`
+	ClaimType     *string `json:"claimType"`
+	ClaimLanguage *string `json:"claimLanguage"`
+	Signature     string  `json:"signature"`
+}
+
+// Value returns the typed claim value of the input.
+func (c *ClaimInput) Value() (quad.Value, error) {
+	var typ, lang string
+	if c.ClaimType != nil {
+		typ = *c.ClaimType
+	}
+	if c.ClaimLanguage != nil {
+		lang = *c.ClaimLanguage
+	}
+	return NewClaimValue(c.Claim, typ, lang)
+}
+
+// ToClaim returns the claim described by the input.
+func (c *ClaimInput) ToClaim() (*Claim, error) {
+	value, err := c.Value()
+	if err != nil {
+		return nil, err
+	}
+	return &Claim{
+		Issuer:    HexToID(c.Issuer),
+		Subject:   HexToID(c.Subject),
+		Property:  c.Property,
+		Claim:     value,
+		Signature: common.FromHex(c.Signature),
+	}, nil
+}
+
+type ClaimSchemaInput struct {
+	Graph     string  `json:"graph"`
+	Property  string  `json:"property"`
+	ValueType string  `json:"valueType"`
+	Pattern   *string `json:"pattern"`
+}
+
+// ClaimSchema returns the claim schema of the input.
+func (c *ClaimSchemaInput) ClaimSchema() *ClaimSchema {
+	s := &ClaimSchema{
+		Property:  c.Property,
+		ValueType: c.ValueType,
+	}
+	if c.Pattern != nil {
+		s.Pattern = *c.Pattern
+	}
+	return s
+}
+
+// QuadInput is a quad with each term in N-Quads syntax.
+type QuadInput struct {
+	Subject   string  `json:"subject"`
+	Predicate string  `json:"predicate"`
+	Object    string  `json:"object"`
+	Label     *string `json:"label"`
+}
+
+// ToQuad parses the terms of the input into a quad.
+func (q *QuadInput) ToQuad() (quad.Quad, error) {
+	var (
+		v   quad.Quad
+		err error
+	)
+	if v.Subject, err = ParseTerm(q.Subject); err != nil {
+		return v, err
+	}
+	if v.Predicate, err = ParseTerm(q.Predicate); err != nil {
+		return v, err
+	}
+	if v.Object, err = ParseTerm(q.Object); err != nil {
+		return v, err
+	}
+	if q.Label != nil {
+		if v.Label, err = ParseTerm(*q.Label); err != nil {
+			return v, err
+		}
+	}
+	switch v.Subject.(type) {
+	case quad.IRI, quad.BNode:
+	default:
+		return v, fmt.Errorf("invalid quad subject, must be an IRI or blank node: %s", q.Subject)
+	}
+	if _, ok := v.Predicate.(quad.IRI); !ok {
+		return v, fmt.Errorf("invalid quad predicate, must be an IRI: %s", q.Predicate)
+	}
+	switch v.Label.(type) {
+	case nil, quad.IRI, quad.BNode:
+	default:
+		return v, fmt.Errorf("invalid quad label, must be an IRI or blank node: %s", *q.Label)
+	}
+	return v, nil
+}
+
+// NewQuadInput returns the input for a quad.
+func NewQuadInput(q quad.Quad) QuadInput {
+	return QuadInput{
+		Subject:   q.Subject.String(),
+		Predicate: q.Predicate.String(),
+		Object:    q.Object.String(),
+		Label:     termString(q.Label),
+	}
+}
+
+// Actions of a DeltaInput.
+const (
+	AddAction    = "ADD"
+	DeleteAction = "DELETE"
+)
+
+type DeltaInput struct {
+	Action string    `json:"action"`
+	Quad   QuadInput `json:"quad"`
+}
+
+// ToDelta parses the input into a graph delta.
+func (d *DeltaInput) ToDelta() (graph.Delta, error) {
+	q, err := d.Quad.ToQuad()
+	if err != nil {
+		return graph.Delta{}, err
+	}
+	switch d.Action {
+	case AddAction:
+		return graph.Delta{Quad: q, Action: graph.Add}, nil
+	case DeleteAction:
+		return graph.Delta{Quad: q, Action: graph.Delete}, nil
+	default:
+		return graph.Delta{}, fmt.Errorf("invalid delta action: %s", d.Action)
+	}
+}
+
+type DeltasInput struct {
+	Graph            string       `json:"graph"`
+	Deltas           []DeltaInput `json:"deltas"`
+	IgnoreDuplicates *bool        `json:"ignoreDuplicates"`
+	IgnoreMissing    *bool        `json:"ignoreMissing"`
+}
+
+type QuadsInput struct {
+	Graph string      `json:"graph"`
+	Quads []QuadInput `json:"quads"`
 }
@@ -0,0 +1,203 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cayleygraph/cayley/quad"
+)
+
+const (
+	// IRIType is the claim type used for IRI claim values.
+	IRIType = "@id"
+
+	nsXSD = "http://www.w3.org/2001/XMLSchema#"
+
+	// XSDString is the datatype of plain string claim values.
+	XSDString = nsXSD + "string"
+
+	// RDFJSON is the datatype used for nested JSON objects.
+	RDFJSON = "http://www.w3.org/1999/02/22-rdf-syntax-ns#JSON"
+)
+
+// NewClaimValue returns a claim value from its lexical form and either a
+// datatype IRI (or IRIType for an IRI) or a language tag.
+func NewClaimValue(value, typ, lang string) (quad.Value, error) {
+	switch {
+	case typ != "" && lang != "":
+		return nil, errors.New("claim value cannot have both a type and a language")
+	case typ == IRIType:
+		return quad.IRI(value), nil
+	case lang != "":
+		return quad.LangString{Value: quad.String(value), Lang: lang}, nil
+	case typ != "":
+		return canonicalValue(quad.TypedString{Value: quad.String(value), Type: quad.IRI(typ)}), nil
+	default:
+		return quad.String(value), nil
+	}
+}
+
+// ClaimValueParts returns the lexical form, type and language of a claim
+// value, which are the inverse of the arguments to NewClaimValue.
+func ClaimValueParts(v quad.Value) (value, typ, lang string) {
+	switch v := canonicalValue(v).(type) {
+	case quad.IRI:
+		return string(v), IRIType, ""
+	case quad.LangString:
+		return string(v.Value), "", v.Lang
+	case quad.TypedString:
+		return string(v.Value), string(v.Type), ""
+	case quad.String:
+		return string(v), "", ""
+	case nil:
+		return "", "", ""
+	default:
+		return v.String(), "", ""
+	}
+}
+
+// canonicalValue converts native values like quad.Int to their typed string
+// form, expands IRIs and converts xsd:string literals to plain strings so
+// that equal values have a single representation.
+func canonicalValue(v quad.Value) quad.Value {
+	if ts, ok := v.(quad.TypedStringer); ok {
+		v = ts.TypedString()
+	}
+	switch v := v.(type) {
+	case quad.IRI:
+		return v.Full()
+	case quad.TypedString:
+		v.Type = v.Type.Full()
+		if v.Type == XSDString {
+			return v.Value
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// claimValueBytes returns the bytes hashed into the claim ID for a value.
+// Plain strings use their raw bytes so that the IDs of untyped claims are
+// unchanged, whereas other values use a NUL byte followed by their N-Quads
+// form (plain strings with a leading NUL byte are rejected when writing
+// claims so the two cannot collide).
+func claimValueBytes(v quad.Value) []byte {
+	switch v := canonicalValue(v).(type) {
+	case quad.String:
+		return []byte(v)
+	case nil:
+		return nil
+	default:
+		return append([]byte{0}, v.String()...)
+	}
+}
+
+// marshalClaimValue returns the JSON-LD representation of a claim value,
+// which is a JSON string for plain strings and a value object otherwise.
+func marshalClaimValue(v quad.Value) interface{} {
+	value, typ, lang := ClaimValueParts(v)
+	switch {
+	case typ == IRIType:
+		return map[string]string{"@id": value}
+	case lang != "":
+		return map[string]string{"@value": value, "@language": lang}
+	case typ != "":
+		return map[string]string{"@value": value, "@type": typ}
+	default:
+		return value
+	}
+}
+
+// unmarshalClaimValue decodes a claim value from its JSON-LD representation,
+// also accepting JSON numbers and booleans (as xsd:integer, xsd:double or
+// xsd:boolean literals) and other JSON objects and arrays (as rdf:JSON
+// literals).
+func unmarshalClaimValue(data []byte) (quad.Value, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("missing claim value")
+	}
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return quad.String(s), nil
+	case 't', 'f':
+		var b bool
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, err
+		}
+		return NewClaimValue(strconv.FormatBool(b), nsXSD+"boolean", "")
+	case '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		if _, ok := obj["@id"]; ok && len(obj) == 1 {
+			var iri string
+			if err := json.Unmarshal(obj["@id"], &iri); err != nil {
+				return nil, err
+			}
+			return quad.IRI(iri), nil
+		}
+		if raw, ok := obj["@value"]; ok {
+			var v struct {
+				Value    string `json:"@value"`
+				Type     string `json:"@type"`
+				Language string `json:"@language"`
+			}
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, fmt.Errorf("invalid claim value object %s: %s", raw, err)
+			}
+			return NewClaimValue(v.Value, v.Type, v.Language)
+		}
+	case '[':
+	default:
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		if _, err := n.Int64(); err == nil {
+			return NewClaimValue(n.String(), nsXSD+"integer", "")
+		}
+		return NewClaimValue(n.String(), nsXSD+"double", "")
+	}
+
+	// store other objects and arrays as canonical JSON literals
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewClaimValue(string(canonical), RDFJSON, "")
+}
@@ -0,0 +1,151 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/graph"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+func TestClaimValueJSON(t *testing.T) {
+	for _, v := range []quad.Value{
+		quad.String("test"),
+		quad.IRI("http://example.com/test"),
+		quad.LangString{Value: "test", Lang: "en"},
+		quad.TypedString{Value: "42", Type: nsXSD + "integer"},
+	} {
+		claim := newTestClaimValue(t, "test", v)
+		data, err := json.Marshal(claim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gotClaim Claim
+		if err := json.Unmarshal(data, &gotClaim); err != nil {
+			t.Fatal(err)
+		}
+		if gotClaim.Claim != canonicalValue(v) {
+			t.Fatalf("expected claim value %v, got %v", v, gotClaim.Claim)
+		}
+		if gotClaim.ID() != claim.ID() {
+			t.Fatalf("expected claim ID %s, got %s", claim.ID().Hex(), gotClaim.ID().Hex())
+		}
+	}
+
+	// check plain strings and typed values have different IDs
+	plain := newTestClaimValue(t, "test", quad.String("http://example.com/test"))
+	iri := newTestClaimValue(t, "test", quad.IRI("http://example.com/test"))
+	if plain.ID() == iri.ID() {
+		t.Fatal("expected plain string and IRI claims to have different IDs")
+	}
+
+	// check JSON numbers are decoded as typed literals
+	var claim Claim
+	if err := json.Unmarshal([]byte(`{"property":"age","claim":42}`), &claim); err != nil {
+		t.Fatal(err)
+	}
+	expected := quad.TypedString{Value: "42", Type: nsXSD + "integer"}
+	if claim.Claim != expected {
+		t.Fatalf("expected claim value %v, got %v", expected, claim.Claim)
+	}
+}
+
+func TestClaimSchema(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	driver := graph.NewDriver("kord-schema-test", ts, testutil.NewTestRegistry(), ts.Dir)
+	api, err := NewAPI(driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Auth.Tokens = []string{testAPIToken}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	client := NewClient(srv.URL)
+	client.SetToken(testAPIToken)
+	if _, err := client.CreateGraph(testKordID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+
+	// register an integer schema for the age property
+	if _, err := client.RegisterClaimSchema(testKordID.Hex(), &ClaimSchema{
+		Property:  "age",
+		ValueType: nsXSD + "integer",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.RegisterClaimSchema(testKordID.Hex(), &ClaimSchema{
+		Property:  "age",
+		ValueType: StringValueType,
+	}); err == nil {
+		t.Fatal("expected registering a second age schema to fail")
+	}
+
+	// check non-conforming claims are rejected
+	for _, v := range []quad.Value{
+		quad.String("42"),
+		quad.TypedString{Value: "forty two", Type: nsXSD + "integer"},
+	} {
+		if _, err := client.CreateClaim(testKordID.Hex(), newTestClaimValue(t, "age", v)); err == nil {
+			t.Fatalf("expected claim with value %v to be rejected", v)
+		}
+	}
+
+	// check a conforming claim is stored with its type
+	expected := quad.TypedString{Value: "42", Type: nsXSD + "integer"}
+	claim := newTestClaimValue(t, "age", expected)
+	if _, err := client.CreateClaim(testKordID.Hex(), claim); err != nil {
+		t.Fatal(err)
+	}
+	gotClaim, err := client.ClaimByID(claim.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotClaim == nil {
+		t.Fatal("expected claim to be found")
+	}
+	if gotClaim.Claim != expected {
+		t.Fatalf("expected claim value %v, got %v", expected, gotClaim.Claim)
+	}
+}
+
+func newTestClaimValue(t *testing.T, property string, value quad.Value) *Claim {
+	c := &Claim{
+		Issuer:   testKordID,
+		Subject:  testKordID,
+		Property: property,
+		Claim:    value,
+	}
+	id := c.ID()
+	signature, err := crypto.Sign(id[:], testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Signature = signature
+	return c
+}
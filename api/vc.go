@@ -0,0 +1,451 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/pkg/did"
+)
+
+const (
+	// CredentialsContext is the JSON-LD context of W3C Verifiable
+	// Credentials.
+	CredentialsContext = "https://www.w3.org/2018/credentials/v1"
+
+	// Secp256k1Context is the JSON-LD context of the
+	// EcdsaSecp256k1Signature2019 Linked Data Proof suite.
+	Secp256k1Context = "https://w3id.org/security/suites/secp256k1-2019/v1"
+
+	// ClaimVocab is the vocabulary of KORD claim properties and of the
+	// KordClaim and KordClaimSignature types, which are used as full IRIs
+	// in credentials so that only the published contexts are needed to
+	// process them.
+	ClaimVocab = "http://schema.kord-network.io/"
+
+	// ClaimCredentialType is the credential type of KORD claims.
+	ClaimCredentialType = ClaimVocab + "KordClaim"
+
+	// CredentialProofType is the type of the issuer's Linked Data Proof of
+	// a credential.
+	CredentialProofType = "EcdsaSecp256k1Signature2019"
+
+	// ClaimProofType is the type of the second proof of a credential,
+	// which is the issuer's signature of the KORD claim ID so that the
+	// credential can be imported as a claim.
+	ClaimProofType = ClaimVocab + "KordClaimSignature"
+
+	// claimIDPrefix is the prefix of credential IDs derived from claim IDs.
+	claimIDPrefix = "urn:kord:claim:"
+)
+
+// IRIs of the terms of the published contexts which are used in the
+// canonical form of credentials and proofs.
+const (
+	iriRDFType            = "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"
+	iriXSDString          = nsXSD + "string"
+	iriXSDDateTime        = nsXSD + "dateTime"
+	iriCredentials        = "https://www.w3.org/2018/credentials#"
+	iriSecurity           = "https://w3id.org/security#"
+	iriCreated            = "http://purl.org/dc/terms/created"
+	iriProofPurpose       = iriSecurity + "proofPurpose"
+	iriVerificationMethod = iriSecurity + "verificationMethod"
+)
+
+// jwsHeader is the header of the detached JWS of a credential proof, which
+// signs the unencoded payload as described by the Linked Data Proof suite.
+var jwsHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256K","b64":false,"crit":["b64"]}`))
+
+// Credential is a W3C Verifiable Credential representing a KORD claim.
+//
+// The credential subject has the subject's DID as its "id" and the claim
+// property as its only other key, with the claim value in its JSON-LD form.
+// Properties which are not IRIs are expanded using ClaimVocab.
+//
+// The first proof is an EcdsaSecp256k1Signature2019 proof, the issuer's
+// signature of the canonical form of the credential and of the proof
+// options, so it covers every credential field. The second is the issuer's
+// signature of the KORD claim ID, which is needed to import the credential
+// as a claim.
+type Credential struct {
+	Context           []string               `json:"@context"`
+	ID                string                 `json:"id"`
+	Type              []string               `json:"type"`
+	Issuer            string                 `json:"issuer"`
+	IssuanceDate      string                 `json:"issuanceDate"`
+	CredentialSubject map[string]interface{} `json:"credentialSubject"`
+	Proof             []*Proof               `json:"proof"`
+}
+
+// Proof is a Verifiable Credential proof.
+type Proof struct {
+	Type               string `json:"type"`
+	Created            string `json:"created"`
+	ProofPurpose       string `json:"proofPurpose"`
+	VerificationMethod string `json:"verificationMethod"`
+	JWS                string `json:"jws,omitempty"`
+	ProofValue         string `json:"proofValue,omitempty"`
+}
+
+// Credential returns the claim as a Verifiable Credential issued at the
+// given time, using sign to sign its proof with the issuer's key.
+func (c *Claim) Credential(issued time.Time, sign func(common.Hash) ([]byte, error)) (*Credential, error) {
+	date := issued.UTC().Format(time.RFC3339)
+	vc := &Credential{
+		Context: []string{CredentialsContext, Secp256k1Context},
+		ID:      claimIDPrefix + c.ID().Hex(),
+		Type:    []string{"VerifiableCredential", ClaimCredentialType},
+		Issuer:  did.New(c.Issuer.Address),
+		CredentialSubject: map[string]interface{}{
+			"id":                         did.New(c.Subject.Address),
+			claimPropertyIRI(c.Property): marshalClaimValue(c.Claim),
+		},
+		IssuanceDate: date,
+	}
+	proof := &Proof{
+		Type:               CredentialProofType,
+		Created:            date,
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: did.VerificationMethodID(c.Issuer.Address),
+	}
+	input, err := vc.signingInput(proof)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign(sha256.Sum256(input))
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) < 64 {
+		return nil, fmt.Errorf("invalid credential signature length %d", len(sig))
+	}
+	proof.JWS = jwsHeader + ".." + base64.RawURLEncoding.EncodeToString(sig[:64])
+	vc.Proof = []*Proof{proof, {
+		Type:               ClaimProofType,
+		Created:            date,
+		ProofPurpose:       "assertionMethod",
+		VerificationMethod: did.VerificationMethodID(c.Issuer.Address),
+		ProofValue:         hexutil.Encode(c.Signature),
+	}}
+	return vc, nil
+}
+
+// ClaimFromCredential converts a Verifiable Credential back to a KORD claim,
+// returning an error unless both of its proofs are valid signatures by the
+// issuer.
+func ClaimFromCredential(vc *Credential) (*Claim, error) {
+	if len(vc.Context) == 0 || vc.Context[0] != CredentialsContext {
+		return nil, fmt.Errorf("invalid credential: first context must be %s", CredentialsContext)
+	}
+	if !hasString(vc.Type, "VerifiableCredential") {
+		return nil, errors.New("invalid credential: missing VerifiableCredential type")
+	}
+	issuer, err := did.Parse(vc.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential issuer: %s", err)
+	}
+	subjectDID, _ := vc.CredentialSubject["id"].(string)
+	subject, err := did.Parse(subjectDID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential subject: %s", err)
+	}
+	if len(vc.CredentialSubject) != 2 {
+		return nil, fmt.Errorf("invalid credential subject: expected a single property, got %d", len(vc.CredentialSubject)-1)
+	}
+	proof := vc.proof(CredentialProofType)
+	if proof == nil {
+		return nil, fmt.Errorf("invalid credential: missing %s proof", CredentialProofType)
+	}
+	if err := vc.verifyProof(proof, issuer); err != nil {
+		return nil, err
+	}
+	claimProof := vc.proof(ClaimProofType)
+	if claimProof == nil {
+		return nil, fmt.Errorf("invalid credential: missing %s proof", ClaimProofType)
+	}
+	sig, err := hexutil.Decode(claimProof.ProofValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential proof value: %s", err)
+	}
+
+	claim := &Claim{
+		Issuer:    NewID(issuer),
+		Subject:   NewID(subject),
+		Signature: sig,
+	}
+	for property, value := range vc.CredentialSubject {
+		if property == "id" {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		claim.Property = claimProperty(property)
+		claim.Claim, err = unmarshalClaimValue(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential subject %s: %s", property, err)
+		}
+	}
+	if vc.ID != "" && strings.HasPrefix(vc.ID, claimIDPrefix) {
+		if id := common.HexToHash(strings.TrimPrefix(vc.ID, claimIDPrefix)); id != claim.ID() {
+			return nil, fmt.Errorf("credential ID mismatch, expected %s, got %s", claim.ID().Hex(), id.Hex())
+		}
+	}
+	if !VerifyClaim(claim) {
+		return nil, errors.New("invalid credential claim signature")
+	}
+	return claim, nil
+}
+
+// proof returns the credential's proof of the given type.
+func (vc *Credential) proof(typ string) *Proof {
+	for _, p := range vc.Proof {
+		if p != nil && p.Type == typ {
+			return p
+		}
+	}
+	return nil
+}
+
+// verifyProof returns an error unless the proof's JWS is a signature of
+// the credential by the issuer.
+func (vc *Credential) verifyProof(proof *Proof, issuer common.Address) error {
+	if proof.VerificationMethod != did.VerificationMethodID(issuer) {
+		return fmt.Errorf("invalid credential proof: unknown verification method %s", proof.VerificationMethod)
+	}
+	parts := strings.Split(proof.JWS, ".")
+	if len(parts) != 3 || parts[0] != jwsHeader || parts[1] != "" {
+		return errors.New("invalid credential proof: expected a detached ES256K JWS")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		return errors.New("invalid credential proof: invalid JWS signature")
+	}
+	input, err := vc.signingInput(proof)
+	if err != nil {
+		return err
+	}
+	// the signature does not include the recovery ID, so check whether
+	// either of the keys it could be from is the issuer's
+	hash := sha256.Sum256(input)
+	for v := byte(0); v < 2; v++ {
+		pub, err := crypto.SigToPub(hash[:], append(sig[:64:64], v))
+		if err == nil && crypto.PubkeyToAddress(*pub) == issuer {
+			return nil
+		}
+	}
+	return errors.New("invalid credential proof signature")
+}
+
+// signingInput returns the data signed by the proof's JWS, which is the
+// encoded JWS header followed by the SHA-256 hashes of the canonical forms
+// of the proof options and of the credential without its proofs.
+func (vc *Credential) signingInput(proof *Proof) ([]byte, error) {
+	doc, err := vc.canonicalize()
+	if err != nil {
+		return nil, err
+	}
+	options := proof.canonicalize()
+	optionsHash := sha256.Sum256([]byte(options))
+	docHash := sha256.Sum256([]byte(doc))
+	input := make([]byte, 0, len(jwsHeader)+1+2*sha256.Size)
+	input = append(input, jwsHeader...)
+	input = append(input, '.')
+	input = append(input, optionsHash[:]...)
+	return append(input, docHash[:]...), nil
+}
+
+// canonicalize returns the canonical N-Quads of the credential without its
+// proofs. The only blank node is the credential itself if it has no ID, so
+// the result is the same as URDNA2015 canonicalization of the credential
+// expanded with the published contexts.
+func (vc *Credential) canonicalize() (string, error) {
+	node := "_:c14n0"
+	if vc.ID != "" {
+		node = nquadsIRI(vc.ID)
+	}
+	var lines []string
+	add := func(s, p, o string) {
+		lines = append(lines, s+" "+nquadsIRI(p)+" "+o+" .\n")
+	}
+	for _, typ := range vc.Type {
+		iri, err := credentialTypeIRI(typ)
+		if err != nil {
+			return "", err
+		}
+		add(node, iriRDFType, nquadsIRI(iri))
+	}
+	if vc.Issuer != "" {
+		add(node, iriCredentials+"issuer", nquadsIRI(vc.Issuer))
+	}
+	if vc.IssuanceDate != "" {
+		add(node, iriCredentials+"issuanceDate", nquadsLiteral(vc.IssuanceDate, iriXSDDateTime, ""))
+	}
+	subjectDID, _ := vc.CredentialSubject["id"].(string)
+	if subjectDID == "" {
+		return "", errors.New("invalid credential subject: missing id")
+	}
+	subject := nquadsIRI(subjectDID)
+	add(node, iriCredentials+"credentialSubject", subject)
+	for property, v := range vc.CredentialSubject {
+		if property == "id" {
+			continue
+		}
+		if !strings.Contains(property, ":") {
+			return "", fmt.Errorf("invalid credential subject: property %s is not an IRI", property)
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		value, err := unmarshalClaimValue(data)
+		if err != nil {
+			return "", fmt.Errorf("invalid credential subject %s: %s", property, err)
+		}
+		lexical, typ, lang := ClaimValueParts(value)
+		if typ == IRIType {
+			add(subject, property, nquadsIRI(lexical))
+		} else {
+			add(subject, property, nquadsLiteral(lexical, typ, lang))
+		}
+	}
+	return joinNQuads(lines), nil
+}
+
+// canonicalize returns the canonical N-Quads of the proof options, which
+// are the proof without its JWS, with the proof being the blank node
+// _:c14n0 as it is in URDNA2015 canonicalization.
+func (p *Proof) canonicalize() string {
+	const node = "_:c14n0"
+	var lines []string
+	add := func(pred, o string) {
+		lines = append(lines, node+" "+nquadsIRI(pred)+" "+o+" .\n")
+	}
+	add(iriRDFType, nquadsIRI(iriSecurity+p.Type))
+	if p.Created != "" {
+		add(iriCreated, nquadsLiteral(p.Created, iriXSDDateTime, ""))
+	}
+	if p.ProofPurpose != "" {
+		add(iriProofPurpose, nquadsIRI(iriSecurity+p.ProofPurpose))
+	}
+	if p.VerificationMethod != "" {
+		add(iriVerificationMethod, nquadsIRI(p.VerificationMethod))
+	}
+	return joinNQuads(lines)
+}
+
+// credentialTypeIRI expands a credential type, which is either a term of
+// the credentials context or an IRI.
+func credentialTypeIRI(typ string) (string, error) {
+	switch {
+	case typ == "VerifiableCredential":
+		return iriCredentials + typ, nil
+	case strings.Contains(typ, ":"):
+		return typ, nil
+	default:
+		return "", fmt.Errorf("invalid credential type: %s", typ)
+	}
+}
+
+// claimPropertyIRI returns the key of the claim property in a credential
+// subject, which is the property if it is an IRI and otherwise the
+// property in ClaimVocab.
+func claimPropertyIRI(property string) string {
+	if strings.Contains(property, ":") {
+		return property
+	}
+	return ClaimVocab + property
+}
+
+// claimProperty is the inverse of claimPropertyIRI.
+func claimProperty(iri string) string {
+	if name := strings.TrimPrefix(iri, ClaimVocab); name != iri && !strings.Contains(name, ":") {
+		return name
+	}
+	return iri
+}
+
+func nquadsIRI(iri string) string {
+	return "<" + iri + ">"
+}
+
+// nquadsLiteral returns a literal in canonical N-Quads form, which escapes
+// only quotes, backslashes and line breaks and leaves out the xsd:string
+// datatype.
+func nquadsLiteral(value, typ, lang string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	switch {
+	case lang != "":
+		buf.WriteString("@" + lang)
+	case typ != "" && typ != iriXSDString:
+		buf.WriteString("^^" + nquadsIRI(typ))
+	}
+	return buf.String()
+}
+
+// joinNQuads sorts the N-Quads lines and removes duplicates.
+func joinNQuads(lines []string) string {
+	sort.Strings(lines)
+	var buf bytes.Buffer
+	for i, line := range lines {
+		if i > 0 && line == lines[i-1] {
+			continue
+		}
+		buf.WriteString(line)
+	}
+	return buf.String()
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestCredential(t *testing.T) {
+	claim := newTestClaim(t, "username", "test")
+	sign := func(hash common.Hash) ([]byte, error) {
+		return crypto.Sign(hash[:], testKey)
+	}
+	issued := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	credential, err := claim.Credential(issued, sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check the canonical form of the credential, which the proof signs
+	canonical, err := credential.canonicalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	did := "did:kord:" + testKordID.Hex()
+	expected := strings.Join([]string{
+		fmt.Sprintf(`<%s> <http://schema.kord-network.io/username> "test" .`, did),
+		fmt.Sprintf(`<urn:kord:claim:%s> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.kord-network.io/KordClaim> .`, claim.ID().Hex()),
+		fmt.Sprintf(`<urn:kord:claim:%s> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <https://www.w3.org/2018/credentials#VerifiableCredential> .`, claim.ID().Hex()),
+		fmt.Sprintf(`<urn:kord:claim:%s> <https://www.w3.org/2018/credentials#credentialSubject> <%s> .`, claim.ID().Hex(), did),
+		fmt.Sprintf(`<urn:kord:claim:%s> <https://www.w3.org/2018/credentials#issuanceDate> "2018-05-01T12:00:00Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .`, claim.ID().Hex()),
+		fmt.Sprintf(`<urn:kord:claim:%s> <https://www.w3.org/2018/credentials#issuer> <%s> .`, claim.ID().Hex(), did),
+	}, "\n") + "\n"
+	if canonical != expected {
+		t.Fatalf("unexpected canonical credential:\nexpected:\n%s\nactual:\n%s", expected, canonical)
+	}
+
+	// check the claim round trips through a JSON encoded credential
+	data, err := json.Marshal(credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode := func() *Credential {
+		t.Helper()
+		var vc Credential
+		if err := json.Unmarshal(data, &vc); err != nil {
+			t.Fatal(err)
+		}
+		return &vc
+	}
+	vc := decode()
+	if vc.Issuer != did {
+		t.Fatalf("unexpected credential issuer: %s", vc.Issuer)
+	}
+	if v := vc.CredentialSubject[ClaimVocab+"username"]; v != "test" {
+		t.Fatalf("unexpected credential subject username: %s", v)
+	}
+	if len(vc.Proof) != 2 || vc.Proof[0].Type != CredentialProofType || vc.Proof[1].Type != ClaimProofType {
+		t.Fatalf("unexpected credential proofs: %v", vc.Proof)
+	}
+	gotClaim, err := ClaimFromCredential(vc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotClaim.ID() != claim.ID() {
+		t.Fatalf("expected claim ID %s, got %s", claim.ID().Hex(), gotClaim.ID().Hex())
+	}
+
+	// check tampered credentials are rejected, including changes to fields
+	// which the claim signature does not cover
+	for name, tamper := range map[string]func(vc *Credential){
+		"claim value": func(vc *Credential) {
+			vc.ID = ""
+			vc.CredentialSubject[ClaimVocab+"username"] = "other"
+		},
+		"issuance date": func(vc *Credential) {
+			vc.IssuanceDate = "2019-05-01T12:00:00Z"
+		},
+		"proof created": func(vc *Credential) {
+			vc.Proof[0].Created = "2019-05-01T12:00:00Z"
+		},
+		"missing proof": func(vc *Credential) {
+			vc.Proof = vc.Proof[1:]
+		},
+		"other signer": func(vc *Credential) {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := claim.Credential(issued, func(hash common.Hash) ([]byte, error) {
+				return crypto.Sign(hash[:], key)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			vc.Proof[0] = other.Proof[0]
+		},
+	} {
+		vc := decode()
+		tamper(vc)
+		if _, err := ClaimFromCredential(vc); err == nil {
+			t.Fatalf("expected credential with tampered %s to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/kord-network/go-kord/api"
+)
+
+func init() {
+	registerCommand("claim", RunClaim, `
+usage: kord claim create [options]
+       kord claim list [options] <id>
+       kord claim verify [options] <file>
+       kord claim export [options] <id>
+       kord claim import [options] <id> <file>
+
+Create, list, verify, export or import KORD claims.
+
+The create command signs a claim with the issuer's key, adds it to the graph
+given by --graph and updates the registry with the new graph hash.
+
+The list command outputs claims in the graph which match the given filters,
+and the verify command checks the signatures of the claims in a file without
+connecting to a KORD node, printing whether each claim is valid along with
+its ID, or its position in the file if it cannot be decoded.
+
+Claims are exported as a JSON array, either in the KORD claim format or as
+W3C Verifiable Credentials if --vc is set. Credentials have an
+EcdsaSecp256k1Signature2019 proof covering every credential field, which is
+signed with the issuer's key so the keystore must contain the key of each
+issuer, and a second proof containing the KORD claim signature. Imported and
+verified files can contain either format, credentials being valid only if
+both proofs are, and the graph is updated once all claims have been
+imported.
+
+options:
+        -u, --url <url>          URL of the KORD node
+        -k, --keystore <dir>     Keystore directory
+        --graph <id>             Graph to create the claim in
+        --issuer <id>            Claim issuer
+        --subject <id>           Claim subject
+        --property <property>    Claim property
+        --value <value>          Claim value
+        --type <type>            Datatype IRI of the value, or @id for an IRI
+        --lang <lang>            Language tag of the value
+        --vc                     Export claims as Verifiable Credentials
+
+example:
+        kord claim create --graph 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88 \
+                          --issuer 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88 \
+                          --subject 0x8c0F2F01f2dDb5b3C5b9B3f0D5E8A7F6a8d1f6b2 \
+                          --property username --value jaak
+
+        kord claim list --property username 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88
+
+        kord claim verify claims.json
+
+        kord claim export --vc 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88 > claims.json
+
+        kord claim import 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88 claims.json
+`[1:])
+}
+
+func RunClaim(ctx *Context) error {
+	switch {
+	case ctx.Args.Bool("create"):
+		return RunClaimCreate(ctx)
+	case ctx.Args.Bool("list"):
+		return RunClaimList(ctx)
+	case ctx.Args.Bool("verify"):
+		return RunClaimVerify(ctx)
+	case ctx.Args.Bool("export"):
+		return RunClaimExport(ctx)
+	case ctx.Args.Bool("import"):
+		return RunClaimImport(ctx)
+	default:
+		return errors.New("unknown claim command")
+	}
+}
+
+func RunClaimCreate(ctx *Context) error {
+	args := make(map[string]common.Address)
+	for _, name := range []string{"--graph", "--issuer", "--subject"} {
+		v := ctx.Args.String(name)
+		if v == "" {
+			return fmt.Errorf("missing %s", name)
+		}
+		if !common.IsHexAddress(v) {
+			return fmt.Errorf("invalid %s, must be a hex KORD ID: %s", name, v)
+		}
+		args[name] = common.HexToAddress(v)
+	}
+	property := ctx.Args.String("--property")
+	if property == "" {
+		return errors.New("missing --property")
+	}
+	value, err := api.NewClaimValue(ctx.Args.String("--value"), ctx.Args.String("--type"), ctx.Args.String("--lang"))
+	if err != nil {
+		return err
+	}
+
+	claim := &api.Claim{
+		Issuer:   api.NewID(args["--issuer"]),
+		Subject:  api.NewID(args["--subject"]),
+		Property: property,
+		Claim:    value,
+	}
+	log.Info("signing claim", "id", claim.ID().Hex())
+	claim.Signature, err = signHash(ctx, claim.Issuer.Address, claim.ID())
+	if err != nil {
+		return err
+	}
+
+	apiClient, err := ctx.APIClient()
+	if err != nil {
+		return err
+	}
+	graph := args["--graph"]
+	log.Info("creating claim", "graph", graph, "id", claim.ID().Hex())
+	hash, err := apiClient.CreateClaim(graph.Hex(), claim)
+	if err != nil {
+		return err
+	}
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+	if err := setGraph(ctx, client, graph, hash); err != nil {
+		return err
+	}
+	log.Info("claim created successfully", "id", claim.ID().Hex(), "hash", hash)
+	fmt.Fprintln(ctx.Stdout, claim.ID().Hex())
+	return nil
+}
+
+func RunClaimList(ctx *Context) error {
+	id, err := ctx.KordID()
+	if err != nil {
+		return err
+	}
+	filter := &api.ClaimFilter{}
+	for name, dst := range map[string]**string{
+		"--issuer":   &filter.Issuer,
+		"--subject":  &filter.Subject,
+		"--property": &filter.Property,
+		"--value":    &filter.Claim,
+		"--type":     &filter.ClaimType,
+		"--lang":     &filter.ClaimLanguage,
+	} {
+		if v := ctx.Args.String(name); v != "" {
+			*dst = &v
+		}
+	}
+	if filter.Issuer != nil {
+		issuer := common.HexToAddress(*filter.Issuer).Hex()
+		filter.Issuer = &issuer
+	}
+	if filter.Subject != nil {
+		subject := common.HexToAddress(*filter.Subject).Hex()
+		filter.Subject = &subject
+	}
+
+	client, err := ctx.APIClient()
+	if err != nil {
+		return err
+	}
+	claims, err := client.Claim(id.Hex(), filter)
+	if err != nil {
+		return err
+	}
+	return writeJSON(ctx, claims)
+}
+
+func RunClaimVerify(ctx *Context) error {
+	data, err := ioutil.ReadFile(ctx.Args.String("<file>"))
+	if err != nil {
+		return err
+	}
+	raws, err := splitClaims(data)
+	if err != nil {
+		return err
+	}
+	invalid := 0
+	for i, raw := range raws {
+		// claims which fail to decode, including credentials whose proof
+		// is invalid, are reported as invalid using their position
+		claim, err := decodeClaim(raw)
+		if err != nil {
+			log.Warn("invalid claim", "index", i, "err", err)
+			fmt.Fprintln(ctx.Stdout, i, "invalid")
+			invalid++
+			continue
+		}
+		status := "valid"
+		if !api.VerifyClaim(claim) {
+			status = "invalid"
+			invalid++
+		}
+		fmt.Fprintln(ctx.Stdout, claim.ID().Hex(), status)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d claims are invalid", invalid, len(raws))
+	}
+	return nil
+}
+
+func RunClaimExport(ctx *Context) error {
+	id, err := ctx.KordID()
+	if err != nil {
+		return err
+	}
+	client, err := ctx.APIClient()
+	if err != nil {
+		return err
+	}
+	claims, err := client.Claim(id.Hex(), &api.ClaimFilter{})
+	if err != nil {
+		return err
+	}
+
+	var out interface{} = claims
+	if ctx.Args.Bool("--vc") {
+		// each credential is signed by its issuer, prompting for the
+		// passphrase of each issuer at most once
+		now := time.Now()
+		signers := make(map[common.Address]func(common.Hash) ([]byte, error))
+		credentials := make([]*api.Credential, len(claims))
+		for i, claim := range claims {
+			sign, ok := signers[claim.Issuer.Address]
+			if !ok {
+				sign = signer(ctx, claim.Issuer.Address)
+				signers[claim.Issuer.Address] = sign
+			}
+			credentials[i], err = claim.Credential(now, sign)
+			if err != nil {
+				return fmt.Errorf("error issuing credential for claim %s: %s", claim.ID().Hex(), err)
+			}
+		}
+		out = credentials
+	}
+	return writeJSON(ctx, out)
+}
+
+func writeJSON(ctx *Context, v interface{}) error {
+	enc := json.NewEncoder(ctx.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func RunClaimImport(ctx *Context) error {
+	id, err := ctx.KordID()
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(ctx.Args.String("<file>"))
+	if err != nil {
+		return err
+	}
+	claims, err := decodeClaims(data)
+	if err != nil {
+		return err
+	}
+	if len(claims) == 0 {
+		return errors.New("no claims to import")
+	}
+
+	apiClient, err := ctx.APIClient()
+	if err != nil {
+		return err
+	}
+	hash, errs, err := apiClient.CreateClaims(id.Hex(), claims)
+	if err != nil {
+		return err
+	}
+	imported := 0
+	for i, err := range errs {
+		if err != nil {
+			log.Warn("error importing claim", "id", claims[i].ID().Hex(), "err", err)
+			continue
+		}
+		imported++
+	}
+	if imported == 0 {
+		return errors.New("no claims were imported")
+	}
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+	if err := setGraph(ctx, client, id, hash); err != nil {
+		return err
+	}
+	log.Info("claims imported successfully", "count", imported, "hash", hash)
+	return nil
+}
+
+// decodeClaims decodes either a single claim or an array of claims, each of
+// which may be a KORD claim or a Verifiable Credential.
+func decodeClaims(data []byte) ([]*api.Claim, error) {
+	raws, err := splitClaims(data)
+	if err != nil {
+		return nil, err
+	}
+	claims := make([]*api.Claim, len(raws))
+	for i, raw := range raws {
+		claims[i], err = decodeClaim(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding claim %d: %s", i, err)
+		}
+	}
+	return claims, nil
+}
+
+// splitClaims splits either a single claim or an array of claims into the
+// JSON encoding of each claim.
+func splitClaims(data []byte) ([]json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("[")) {
+		return []json.RawMessage{data}, nil
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, err
+	}
+	return raws, nil
+}
+
+// decodeClaim decodes either a KORD claim or a Verifiable Credential, which
+// is rejected if its proof is not a valid signature by the issuer.
+func decodeClaim(raw json.RawMessage) (*api.Claim, error) {
+	var v map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if _, ok := v["@context"]; !ok {
+		claim := &api.Claim{}
+		if err := json.Unmarshal(raw, claim); err != nil {
+			return nil, err
+		}
+		return claim, nil
+	}
+	var vc api.Credential
+	if err := json.Unmarshal(raw, &vc); err != nil {
+		return nil, err
+	}
+	return api.ClaimFromCredential(&vc)
+}
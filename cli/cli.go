@@ -38,6 +38,9 @@ Commands:
         help     show usage for a specific command
         node     run a KORD node
         load     load quads into KORD
+        name     register and resolve KORD names
+        registry deploy, query or update the KORD registry
+        claim    create, list, verify, export or import KORD claims
 
 See 'kord help <command>' for more information on a specific command.
 `[1:]
@@ -22,8 +22,11 @@ package cli
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -31,9 +34,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cayleygraph/cayley/quad"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/kord-network/go-kord/api"
+	"github.com/kord-network/go-kord/dapp"
+	"github.com/kord-network/go-kord/registry"
 )
 
 func init() {
@@ -107,6 +116,89 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+// TestGraphReaders tests setting the readers of an encrypted graph using
+// the public key of a KORD ID.
+func TestGraphReaders(t *testing.T) {
+	// create an ID
+	cliCtx := NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	var stdout bytes.Buffer
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"new",
+		"--keystore", n.keystore,
+	); err != nil {
+		t.Fatal(err)
+	}
+	id := common.HexToAddress(strings.TrimSpace(stdout.String()))
+
+	// get the ID's public key
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"pubkey",
+		"--keystore", n.keystore,
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	pubkey := strings.TrimSpace(stdout.String())
+
+	// check the public key belongs to the ID
+	pubBytes, err := hexutil.Decode(pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := crypto.PubkeyToAddress(*crypto.ToECDSAPub(pubBytes)); addr != id {
+		t.Fatalf("expected public key of %s, got public key of %s", id.Hex(), addr.Hex())
+	}
+
+	// set the graph readers and check they are returned
+	if err := Run(
+		NewContext(context.Background()),
+		"graph",
+		"set-readers",
+		"--url", n.ipcPath,
+		id.Hex(),
+		pubkey,
+	); err != nil {
+		t.Fatal(err)
+	}
+	cliCtx = NewContext(context.Background())
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"graph",
+		"readers",
+		"--url", n.ipcPath,
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if readers := strings.TrimSpace(stdout.String()); readers != pubkey {
+		t.Fatalf("expected readers to be %s, got %q", pubkey, readers)
+	}
+
+	// check invalid public keys are rejected
+	if err := Run(
+		NewContext(context.Background()),
+		"graph",
+		"set-readers",
+		"--url", n.ipcPath,
+		id.Hex(),
+		"0x1234",
+	); err == nil {
+		t.Fatal("expected invalid public key to be rejected")
+	}
+}
+
 func TestDapp(t *testing.T) {
 	// create an ID
 	cliCtx := NewContext(context.Background())
@@ -121,78 +213,859 @@ func TestDapp(t *testing.T) {
 	); err != nil {
 		t.Fatal(err)
 	}
-	out := strings.TrimSpace(stdout.String())
-	if !common.IsHexAddress(out) {
-		t.Fatalf("unexpected ID output: %s", out)
+	out := strings.TrimSpace(stdout.String())
+	if !common.IsHexAddress(out) {
+		t.Fatalf("unexpected ID output: %s", out)
+	}
+	id := common.HexToAddress(out)
+
+	// create a graph
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"graph",
+		"create",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// deploy a dapp
+	dappDir, err := ioutil.TempDir("", "kord-cli-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dappHTML := []byte(`<html><head><title>Test Dapp</title><body><h1>Test Dapp</h1></body></html>`)
+	if err := ioutil.WriteFile(filepath.Join(dappDir, "index.html"), dappHTML, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dappURI := fmt.Sprintf("kord://%s/test-dapp", id.Hex())
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"dapp",
+		"deploy",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		dappDir,
+		dappURI,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// set the dapp as root
+	cliCtx = NewContext(context.Background())
+	if err := Run(
+		cliCtx,
+		"dapp",
+		"set-root",
+		"--url", n.ipcPath,
+		dappURI,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// check the dapp is available
+	checkRoot := func(expected []byte) {
+		t.Helper()
+		res, err := http.Get(fmt.Sprintf("http://%s/", n.httpAddr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected HTTP status: %s", res.Status)
+		}
+		html, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(html, expected) {
+			t.Fatalf(`unexpected HTML:\nexpected: %s\nactual:   %s`, expected, html)
+		}
+	}
+	checkRoot(dappHTML)
+
+	// deploy a new version with metadata
+	v1HTML := []byte(`<html><head><title>Test Dapp v1</title><body><h1>Test Dapp v1</h1></body></html>`)
+	if err := ioutil.WriteFile(filepath.Join(dappDir, "index.html"), v1HTML, 0644); err != nil {
+		t.Fatal(err)
+	}
+	meta := fmt.Sprintf(`{"name":"Test Dapp","version":"1.0.0","icon":"icon.png","permissions":["read:%s"]}`, id.Hex())
+	if err := ioutil.WriteFile(filepath.Join(dappDir, "dapp.json"), []byte(meta), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"dapp",
+		"deploy",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		dappDir,
+		dappURI,
+	); err != nil {
+		t.Fatal(err)
+	}
+	checkRoot(v1HTML)
+
+	// check the dapp is listed with its metadata
+	stdout.Reset()
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"dapp",
+		"list",
+		"--url", n.ipcPath,
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var dapps []*dapp.Dapp
+	if err := json.Unmarshal(stdout.Bytes(), &dapps); err != nil {
+		t.Fatal(err)
+	}
+	if len(dapps) != 1 {
+		t.Fatalf("expected 1 dapp, got %d", len(dapps))
+	}
+	d := dapps[0]
+	if d.ID != quad.IRI(dappURI) || d.Name != "Test Dapp" || d.Version != "1.0.0" || d.Icon != "icon.png" {
+		t.Fatalf("unexpected dapp: %+v", d)
+	}
+	if d.Publisher != quad.IRI(id.Hex()) {
+		t.Fatalf("expected publisher %s, got %s", id.Hex(), d.Publisher)
+	}
+	if len(d.Permissions) != 1 || d.Permissions[0] != "read:"+id.Hex() {
+		t.Fatalf("unexpected permissions: %v", d.Permissions)
+	}
+
+	// check both versions are linked
+	stdout.Reset()
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"dapp",
+		"info",
+		"--url", n.ipcPath,
+		dappURI,
+	); err != nil {
+		t.Fatal(err)
+	}
+	var versions []*dapp.Dapp
+	if err := json.Unmarshal(stdout.Bytes(), &versions); err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	if versions[0].Version != "1.0.0" {
+		t.Fatalf("expected deployed version 1.0.0, got %s", versions[0].Version)
+	}
+	if !strings.HasPrefix(versions[1].Version, "0.0.0-") {
+		t.Fatalf("expected default previous version, got %s", versions[1].Version)
+	}
+
+	// rollback to the first version
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"dapp",
+		"rollback",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		dappURI,
+		versions[1].Version,
+	); err != nil {
+		t.Fatal(err)
+	}
+	checkRoot(dappHTML)
+}
+
+func TestName(t *testing.T) {
+	// create an ID
+	cliCtx := NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	var stdout bytes.Buffer
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"new",
+		"--keystore", n.keystore,
+	); err != nil {
+		t.Fatal(err)
+	}
+	id := common.HexToAddress(strings.TrimSpace(stdout.String()))
+
+	// register a name
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"name",
+		"register",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		"test.kord",
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// resolve the name
+	cliCtx = NewContext(context.Background())
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"name",
+		"resolve",
+		"--url", n.ipcPath,
+		"test.kord",
+	); err != nil {
+		t.Fatal(err)
+	}
+	if out := strings.TrimSpace(stdout.String()); out != id.Hex() {
+		t.Fatalf("expected name to resolve to %s, got %s", id.Hex(), out)
+	}
+
+	// check a second ID cannot take over the name
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"new",
+		"--keystore", n.keystore,
+	); err != nil {
+		t.Fatal(err)
+	}
+	other := common.HexToAddress(strings.TrimSpace(stdout.String()))
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"name",
+		"register",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		"test.kord",
+		other.Hex(),
+	); err == nil {
+		t.Fatal("expected registering a taken name to fail")
+	}
+	cliCtx = NewContext(context.Background())
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"name",
+		"resolve",
+		"--url", n.ipcPath,
+		"test.kord",
+	); err != nil {
+		t.Fatal(err)
+	}
+	if out := strings.TrimSpace(stdout.String()); out != id.Hex() {
+		t.Fatalf("expected name to still resolve to %s, got %s", id.Hex(), out)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	// create an ID
+	cliCtx := NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	var stdout bytes.Buffer
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"new",
+		"--keystore", n.keystore,
+	); err != nil {
+		t.Fatal(err)
+	}
+	id := common.HexToAddress(strings.TrimSpace(stdout.String()))
+
+	// set the graph hash
+	hash := common.HexToHash("0x5c1ebf5bc7b0e0f0f6fa3ab7e5a89e8f6a3a2bba5c0bc2a7b2bc4ab0f0d7c4e1")
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"registry",
+		"set",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		id.Hex(),
+		hash.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// get the graph hash
+	cliCtx = NewContext(context.Background())
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"registry",
+		"get",
+		"--url", n.ipcPath,
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if out := strings.TrimSpace(stdout.String()); out != hash.Hex() {
+		t.Fatalf("expected graph hash %s, got %s", hash.Hex(), out)
+	}
+}
+
+func TestClaim(t *testing.T) {
+	// create an ID and a graph
+	cliCtx := NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	var stdout bytes.Buffer
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"new",
+		"--keystore", n.keystore,
+	); err != nil {
+		t.Fatal(err)
+	}
+	id := common.HexToAddress(strings.TrimSpace(stdout.String()))
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"graph",
+		"create",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// import a claim as a Verifiable Credential issued by the dev KORD ID,
+	// whose key is used to sign credentials when they are exported
+	claim := &api.Claim{
+		Issuer:   api.NewID(registry.DevAddr),
+		Subject:  api.NewID(id),
+		Property: "username",
+		Claim:    quad.String("test"),
+	}
+	claimID := claim.ID()
+	sig, err := crypto.Sign(claimID[:], registry.DevKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claim.Signature = sig
+	vc, err := claim.Credential(time.Now(), func(hash common.Hash) ([]byte, error) {
+		return crypto.Sign(hash[:], registry.DevKey)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vcJSON, err := json.Marshal([]*api.Credential{vc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpDir, err := ioutil.TempDir("", "kord-cli-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	vcPath := filepath.Join(tmpDir, "claims.json")
+	if err := ioutil.WriteFile(vcPath, vcJSON, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"claim",
+		"import",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		id.Hex(),
+		vcPath,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// export the claims as Verifiable Credentials
+	cliCtx = NewContext(context.Background())
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"claim",
+		"export",
+		"--url", n.ipcPath,
+		"--vc",
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var vcs []*api.Credential
+	if err := json.Unmarshal(stdout.Bytes(), &vcs); err != nil {
+		t.Fatal(err)
+	}
+	if len(vcs) != 1 {
+		t.Fatalf("expected 1 credential, got %d", len(vcs))
+	}
+	gotClaim, err := api.ClaimFromCredential(vcs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotClaim.ID() != claimID {
+		t.Fatalf("expected claim ID %s, got %s", claimID.Hex(), gotClaim.ID().Hex())
+	}
+}
+
+func TestClaimCreate(t *testing.T) {
+	// create an ID and a graph
+	cliCtx := NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	var stdout bytes.Buffer
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"new",
+		"--keystore", n.keystore,
+	); err != nil {
+		t.Fatal(err)
+	}
+	id := common.HexToAddress(strings.TrimSpace(stdout.String()))
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"graph",
+		"create",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
 	}
-	id := common.HexToAddress(out)
 
-	// create a graph
+	// create a claim
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"claim",
+		"create",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		"--graph", id.Hex(),
+		"--issuer", id.Hex(),
+		"--subject", id.Hex(),
+		"--property", "username",
+		"--value", "test",
+	); err != nil {
+		t.Fatal(err)
+	}
+	claimID := common.HexToHash(strings.TrimSpace(stdout.String()))
+
+	// list the claims
+	cliCtx = NewContext(context.Background())
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"claim",
+		"list",
+		"--url", n.ipcPath,
+		"--property", "username",
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var claims []*api.Claim
+	if err := json.Unmarshal(stdout.Bytes(), &claims); err != nil {
+		t.Fatal(err)
+	}
+	if len(claims) != 1 || claims[0].ID() != claimID {
+		t.Fatalf("expected claim %s, got %v", claimID.Hex(), claims)
+	}
+
+	// verify the listed claims, then a tampered claim
+	tmpDir, err := ioutil.TempDir("", "kord-cli-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	claimsPath := filepath.Join(tmpDir, "claims.json")
+	if err := ioutil.WriteFile(claimsPath, stdout.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cliCtx = NewContext(context.Background())
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(cliCtx, "claim", "verify", claimsPath); err != nil {
+		t.Fatal(err)
+	}
+	claims[0].Claim = quad.String("other")
+	tampered, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(claimsPath, tampered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdout = &stdout
+	if err := Run(cliCtx, "claim", "verify", claimsPath); err == nil {
+		t.Fatal("expected tampered claim to fail verification")
+	}
+
+	// export the claim as a Verifiable Credential signed by the issuer and
+	// verify it
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"claim",
+		"export",
+		"--url", n.ipcPath,
+		"--keystore", n.keystore,
+		"--vc",
+		id.Hex(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var vcs []*api.Credential
+	if err := json.Unmarshal(stdout.Bytes(), &vcs); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(claimsPath, stdout.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cliCtx = NewContext(context.Background())
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(cliCtx, "claim", "verify", claimsPath); err != nil {
+		t.Fatal(err)
+	}
+	if out := strings.TrimSpace(stdout.String()); out != claimID.Hex()+" valid" {
+		t.Fatalf("expected credential to be reported as valid, got %q", out)
+	}
+
+	// check credentials with a tampered issuance date, which only the
+	// credential proof covers, or a tampered claim are reported as invalid
+	checkTampered := func(name string, vc *api.Credential) {
+		t.Helper()
+		tampered, err := json.Marshal([]*api.Credential{vc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(claimsPath, tampered, 0644); err != nil {
+			t.Fatal(err)
+		}
+		cliCtx := NewContext(context.Background())
+		stdout.Reset()
+		cliCtx.Stdout = &stdout
+		if err := Run(cliCtx, "claim", "verify", claimsPath); err == nil {
+			t.Fatalf("expected credential with tampered %s to fail verification", name)
+		}
+		if out := strings.TrimSpace(stdout.String()); out != "0 invalid" {
+			t.Fatalf("expected credential with tampered %s to be reported as invalid, got %q", name, out)
+		}
+	}
+	vcs[0].IssuanceDate = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	checkTampered("issuance date", vcs[0])
+	signCtx := NewContext(context.Background())
+	signCtx.Args = Args{"--keystore": n.keystore}
+	signCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	signCtx.Stderr = ioutil.Discard
+	vc, err := claims[0].Credential(time.Now(), signer(signCtx, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTampered("claim", vc)
+}
+
+func TestNodeStatus(t *testing.T) {
+	// check the node reports it is ready
+	cliCtx := NewContext(context.Background())
+	var stdout bytes.Buffer
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"node",
+		"status",
+		"--url", n.ipcPath,
+	); err != nil {
+		t.Fatalf("expected node to be ready, got %s: %s", err, stdout.String())
+	}
+	for _, check := range []string{"swarm", "pinned graphs", "ethereum", "registry"} {
+		if !strings.Contains(stdout.String(), fmt.Sprintf("%-14s ok", check)) {
+			t.Fatalf("expected %s check to pass, got:\n%s", check, stdout.String())
+		}
+	}
+
+	// check the health and readiness endpoints
+	for _, path := range []string{"/healthz", "/readyz"} {
+		res, err := http.Get(fmt.Sprintf("http://%s%s", n.httpAddr, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected %s to return 200 OK, got %s", path, res.Status)
+		}
+	}
+}
+
+// TestRemoteNode tests running a node which uses the test node's Ethereum
+// HTTP JSON-RPC endpoint and Swarm gateway rather than running its own,
+// with new blocks being polled for since HTTP endpoints do not support
+// subscriptions.
+func TestRemoteNode(t *testing.T) {
+	remote, err := runTestNode([]byte(`
+[Node.P2P]
+ListenAddr = ":0"
+MaxPeers = 0
+NoDiscovery = true
+	`),
+		"--eth-rpc", n.ethRPC,
+		"--swarm-api", "http://"+n.httpAddr,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.stop()
+
+	// check the remote node is ready
+	cliCtx := NewContext(context.Background())
+	var stdout bytes.Buffer
+	cliCtx.Stdout = &stdout
+	if err := Run(cliCtx, "node", "status", "--url", remote.ipcPath); err != nil {
+		t.Fatalf("expected remote node to be ready, got %s: %s", err, stdout.String())
+	}
+
+	// create an ID
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	stdout.Reset()
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"new",
+		"--keystore", n.keystore,
+	); err != nil {
+		t.Fatal(err)
+	}
+	id := common.HexToAddress(strings.TrimSpace(stdout.String()))
+
+	// create a graph using the remote node
 	cliCtx = NewContext(context.Background())
 	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
 	if err := Run(
 		cliCtx,
 		"graph",
 		"create",
-		"--url", n.ipcPath,
+		"--url", remote.ipcPath,
 		"--keystore", n.keystore,
 		id.Hex(),
 	); err != nil {
 		t.Fatal(err)
 	}
 
-	// deploy a dapp
+	// load test data using the remote node
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"graph",
+		"load",
+		"--url", remote.ipcPath,
+		"--keystore", n.keystore,
+		id.Hex(),
+		"../graph/data/testdata.nq",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// check the test node can read the graph, which it fetches from its
+	// Swarm node
+	client := api.NewClient(fmt.Sprintf("http://%s/api/graphql", n.httpAddr))
+	page, err := client.Quads(id.Hex(), nil, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Quads) != 1 {
+		t.Fatalf("expected 1 quad, got %d", len(page.Quads))
+	}
+
+	// deploy a dapp using the remote node, which stores it using the
+	// Swarm gateway
 	dappDir, err := ioutil.TempDir("", "kord-cli-test")
 	if err != nil {
 		t.Fatal(err)
 	}
-	dappHTML := []byte(`<html><head><title>Test Dapp</title><body><h1>Test Dapp</h1></body></html>`)
+	defer os.RemoveAll(dappDir)
+	dappHTML := []byte(`<html><head><title>Remote Dapp</title><body><h1>Remote Dapp</h1></body></html>`)
 	if err := ioutil.WriteFile(filepath.Join(dappDir, "index.html"), dappHTML, 0644); err != nil {
 		t.Fatal(err)
 	}
-	dappURI := fmt.Sprintf("kord://%s/test-dapp", id.Hex())
 	cliCtx = NewContext(context.Background())
 	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
 	if err := Run(
 		cliCtx,
 		"dapp",
 		"deploy",
-		"--url", n.ipcPath,
-		"--swarm-api", fmt.Sprintf("http://%s", n.httpAddr),
+		"--url", remote.ipcPath,
 		"--keystore", n.keystore,
 		dappDir,
-		dappURI,
+		fmt.Sprintf("kord://%s/remote-dapp", id.Hex()),
 	); err != nil {
 		t.Fatal(err)
 	}
+	dapps, err := api.NewClient(fmt.Sprintf("http://%s/api/graphql", remote.httpAddr)).Dapps(id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dapps) != 1 {
+		t.Fatalf("expected 1 dapp, got %d", len(dapps))
+	}
+	res, err := http.Get(fmt.Sprintf("http://%s/bzz:/%s/", n.httpAddr, dapps[0].ManifestHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	html, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(html, dappHTML) {
+		t.Fatalf(`unexpected HTML:\nexpected: %s\nactual:   %s`, dappHTML, html)
+	}
+}
+
+// TestDappDeployIPC tests deploying a dapp using only the IPC endpoint of a
+// node whose HTTP server is disabled, with the dapp being large enough to be
+// sent in multiple chunks.
+func TestDappDeployIPC(t *testing.T) {
+	ipcNode, err := runTestNode([]byte(`
+NoHTTP = true
+
+[Node.P2P]
+ListenAddr = ":0"
+MaxPeers = 0
+NoDiscovery = true
+	`),
+		"--eth-rpc", n.ethRPC,
+		"--swarm-api", "http://"+n.httpAddr,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ipcNode.stop()
+	if ipcNode.httpAddr != "" {
+		t.Fatalf("expected HTTP server to be disabled, got address %s", ipcNode.httpAddr)
+	}
 
-	// set the dapp as root
+	// create an ID and a graph
+	cliCtx := NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n', '\n'})
+	var stdout bytes.Buffer
+	cliCtx.Stdout = &stdout
+	if err := Run(
+		cliCtx,
+		"id",
+		"new",
+		"--keystore", n.keystore,
+	); err != nil {
+		t.Fatal(err)
+	}
+	id := common.HexToAddress(strings.TrimSpace(stdout.String()))
 	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
 	if err := Run(
 		cliCtx,
-		"dapp",
-		"set-root",
-		"--url", n.ipcPath,
-		dappURI,
+		"graph",
+		"create",
+		"--url", ipcNode.ipcPath,
+		"--keystore", n.keystore,
+		id.Hex(),
 	); err != nil {
 		t.Fatal(err)
 	}
 
-	// check the dapp is available
-	res, err := http.Get(fmt.Sprintf("http://%s/", n.httpAddr))
+	// deploy a dapp with a file spanning several upload chunks
+	dappDir, err := ioutil.TempDir("", "kord-cli-test")
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		t.Fatalf("unexpected HTTP status: %s", res.Status)
+	defer os.RemoveAll(dappDir)
+	dappHTML := []byte(`<html><head><title>IPC Dapp</title><body><h1>IPC Dapp</h1></body></html>`)
+	if err := ioutil.WriteFile(filepath.Join(dappDir, "index.html"), dappHTML, 0644); err != nil {
+		t.Fatal(err)
 	}
-	html, err := ioutil.ReadAll(res.Body)
+	data := bytes.Repeat([]byte("KORD"), 3*1024*1024/4)
+	if err := ioutil.WriteFile(filepath.Join(dappDir, "data.bin"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cliCtx = NewContext(context.Background())
+	cliCtx.Stdin = bytes.NewReader([]byte{'\n'})
+	if err := Run(
+		cliCtx,
+		"dapp",
+		"deploy",
+		"--url", ipcNode.ipcPath,
+		"--keystore", n.keystore,
+		dappDir,
+		fmt.Sprintf("kord://%s/ipc-dapp", id.Hex()),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// check the test node serves the deployed dapp
+	dapps, err := api.NewClient(fmt.Sprintf("http://%s/api/graphql", n.httpAddr)).Dapps(id.Hex())
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !bytes.Equal(html, dappHTML) {
-		t.Fatalf(`unexpected HTML:\nexpected: %s\nactual:   %s`, dappHTML, html)
+	if len(dapps) != 1 {
+		t.Fatalf("expected 1 dapp, got %d", len(dapps))
+	}
+	for path, expected := range map[string][]byte{
+		"":         dappHTML,
+		"data.bin": data,
+	} {
+		res, err := http.Get(fmt.Sprintf("http://%s/bzz:/%s/%s", n.httpAddr, dapps[0].ManifestHash, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(actual, expected) {
+			t.Fatalf("unexpected content of %q: got %d bytes, expected %d bytes", path, len(actual), len(expected))
+		}
 	}
 }
 
@@ -200,32 +1073,83 @@ type testNode struct {
 	keystore string
 	ipcPath  string
 	httpAddr string
+	ethRPC   string
 	stop     func()
 }
 
 func startTestNode() (*testNode, error) {
+	// serve the Ethereum JSON-RPC API over HTTP on a free port
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	node, err := runTestNode([]byte(fmt.Sprintf(`
+[Node]
+HTTPHost = "127.0.0.1"
+HTTPPort = %d
+	`, port)), "--dev")
+	if err != nil {
+		return nil, err
+	}
+	node.ethRPC = fmt.Sprintf("http://127.0.0.1:%d", port)
+
+	// wait for the dev contracts to be deployed, the name registry being
+	// deployed last
+	if err := func() error {
+		client, err := rpc.Dial(node.ipcPath)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		for start := time.Now(); time.Since(start) < 30*time.Second; time.Sleep(50 * time.Millisecond) {
+			var code hexutil.Bytes
+			if err := client.Call(&code, "eth_getCode", registry.DevENSAddr, "latest"); err != nil {
+				return err
+			}
+			if len(code) > 0 {
+				return nil
+			}
+		}
+		return errors.New("timed out waiting for dev contracts")
+	}(); err != nil {
+		node.stop()
+		return nil, err
+	}
+	return node, nil
+}
+
+// runTestNode runs a node with the given extra config and arguments, using
+// a random HTTP port.
+func runTestNode(extraCfg []byte, args ...string) (*testNode, error) {
 	// generate test config
 	tmpDir, err := ioutil.TempDir("", "kord-cli-test")
 	if err != nil {
 		return nil, err
 	}
 	cfgPath := filepath.Join(tmpDir, "config")
-	cfgData := []byte(`
+	cfgData := append([]byte(`
 [Kord]
 HTTPPort = 0
-	`)
+	`), extraCfg...)
 	if err := ioutil.WriteFile(cfgPath, cfgData, 0644); err != nil {
 		os.RemoveAll(tmpDir)
 		return nil, err
 	}
 
-	// start a dev node
+	// start the node
 	ctx, stopNode := context.WithCancel(context.Background())
 	go func() {
-		if err := Run(NewContext(ctx), "node", "--dev", "--datadir", tmpDir, "--config", cfgPath); err != nil {
+		args := append([]string{"node", "--datadir", tmpDir, "--config", cfgPath}, args...)
+		if err := Run(NewContext(ctx), args...); err != nil {
 			log.Error("error running node", "err", err)
 		}
 	}()
+	stop := func() {
+		stopNode()
+		os.RemoveAll(tmpDir)
+	}
 
 	// wait for the node to start
 	ipcPath := filepath.Join(tmpDir, "kord.ipc")
@@ -241,10 +1165,10 @@ HTTPPort = 0
 		if err != nil {
 			return err
 		}
+		defer client.Close()
 		return client.Call(&httpAddr, "kord_httpAddr")
 	}(); err != nil {
-		stopNode()
-		os.RemoveAll(tmpDir)
+		stop()
 		return nil, err
 	}
 
@@ -252,9 +1176,6 @@ HTTPPort = 0
 		keystore: filepath.Join(tmpDir, "keystore"),
 		ipcPath:  ipcPath,
 		httpAddr: httpAddr,
-		stop: func() {
-			stopNode()
-			os.RemoveAll(tmpDir)
-		},
+		stop:     stop,
 	}, nil
 }
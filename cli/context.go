@@ -20,11 +20,16 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kord-network/go-kord/api"
 	"github.com/kord-network/go-kord/kord"
 	"github.com/kord-network/go-kord/pkg/uri"
 )
@@ -37,12 +42,23 @@ type Context struct {
 	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
+
+	// stdin buffers Stdin so that commands which prompt for more than
+	// one passphrase do not lose buffered input between prompts
+	stdin *bufio.Reader
 }
 
 func NewContext(ctx context.Context) *Context {
 	return &Context{Context: ctx}
 }
 
+func (c *Context) stdinReader() *bufio.Reader {
+	if c.stdin == nil {
+		c.stdin = bufio.NewReader(c.Stdin)
+	}
+	return c.stdin
+}
+
 func (c *Context) NodeURL() string {
 	if url := c.Args.String("--url"); url != "" {
 		return url
@@ -51,9 +67,52 @@ func (c *Context) NodeURL() string {
 }
 
 func (c *Context) URI() (*uri.URI, error) {
-	return uri.Parse(c.Args.String("<uri>"))
+	return uri.ParseWithResolver(c.Args.String("<uri>"), &nameResolver{c})
+}
+
+// KordID returns the KORD ID given as the <id> argument.
+func (c *Context) KordID() (common.Address, error) {
+	id := c.Args.String("<id>")
+	if !common.IsHexAddress(id) {
+		return common.Address{}, fmt.Errorf("invalid KORD ID, must be a hex string: %s", id)
+	}
+	return common.HexToAddress(id), nil
 }
 
 func (c *Context) Client() (*kord.Client, error) {
 	return kord.NewClient(c.NodeURL())
 }
+
+// APIClient returns a client for the GraphQL API of the KORD node.
+func (c *Context) APIClient() (*api.Client, error) {
+	client, err := c.Client()
+	if err != nil {
+		return nil, err
+	}
+	addr, err := client.HttpAddr(c)
+	if err != nil {
+		return nil, err
+	} else if addr == "" {
+		return nil, errors.New("the KORD node's HTTP server is disabled")
+	}
+	token, err := client.APIToken(c)
+	if err != nil {
+		return nil, err
+	}
+	apiClient := api.NewClient(fmt.Sprintf("http://%s/api/graphql", addr))
+	apiClient.SetToken(token)
+	return apiClient, nil
+}
+
+// nameResolver resolves KORD names using the registry of the KORD node.
+type nameResolver struct {
+	ctx *Context
+}
+
+func (r *nameResolver) ResolveName(name string) (common.Address, error) {
+	client, err := r.ctx.Client()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return client.ResolveName(r.ctx, name)
+}
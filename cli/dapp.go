@@ -20,33 +20,61 @@
 package cli
 
 import (
+	"archive/tar"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
-	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/quad"
-	"github.com/cayleygraph/cayley/schema"
 	"github.com/ethereum/go-ethereum/log"
-	swarm "github.com/ethereum/go-ethereum/swarm/api/client"
 	"github.com/kord-network/go-kord/dapp"
 )
 
 func init() {
 	registerCommand("dapp", RunDapp, `
 usage: kord dapp deploy [options] <dir> <uri>
+       kord dapp list [options] <id>
+       kord dapp info [options] <uri>
+       kord dapp rollback [options] <uri> <version>
        kord dapp set-root [options] <uri>
 
-Deploy a KORD Dapp.
+Deploy and manage KORD Dapps.
+
+The name, description, semantic version, icon and required graph
+permissions of a dapp are read from a dapp.json file in the dapp directory
+if it exists, for example:
+
+        {
+          "name":        "Cool Dapp",
+          "description": "A cool dapp",
+          "version":     "1.2.0",
+          "icon":        "icon.png",
+          "permissions": ["read:0x5ce9454909639d2d17a3f753ce7d93fa0b9ab12e"]
+        }
+
+The directory is sent to the node over RPC as a tar stream in chunks and
+stored using the node's Swarm API, so only the node's RPC endpoint is
+needed.
+
+Each deployed version is kept and linked to the version it replaced, and
+rollback redeploys a previous version.
 
 options:
         -u, --url <url>        URL of the KORD node
-        -s, --swarm-api <url>  URL of the Swarm API [default: http://localhost:5000]
         -k, --keystore <dir>   Keystore directory
 
 example:
         kord dapp deploy path/to/dapp kord://xyz123/cool-dapp
 
+        kord dapp list xyz123
+
+        kord dapp info kord://xyz123/cool-dapp
+
+        kord dapp rollback kord://xyz123/cool-dapp 1.1.0
+
         kord dapp set-root kord://xyz123/cool-dapp
 `[1:])
 }
@@ -55,6 +83,12 @@ func RunDapp(ctx *Context) error {
 	switch {
 	case ctx.Args.Bool("deploy"):
 		return RunDappDeploy(ctx)
+	case ctx.Args.Bool("list"):
+		return RunDappList(ctx)
+	case ctx.Args.Bool("info"):
+		return RunDappInfo(ctx)
+	case ctx.Args.Bool("rollback"):
+		return RunDappRollback(ctx)
 	case ctx.Args.Bool("set-root"):
 		return RunDappSetRoot(ctx)
 	default:
@@ -62,6 +96,32 @@ func RunDapp(ctx *Context) error {
 	}
 }
 
+// dappMetadata is the format of the dapp.json file in a dapp directory.
+type dappMetadata struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Version     string   `json:"version"`
+	Icon        string   `json:"icon"`
+	Permissions []string `json:"permissions"`
+}
+
+// loadDappMetadata loads the dapp.json file from the dapp directory,
+// returning empty metadata if it does not exist.
+func loadDappMetadata(dir string) (*dappMetadata, error) {
+	var meta dappMetadata
+	f, err := os.Open(filepath.Join(dir, "dapp.json"))
+	if os.IsNotExist(err) {
+		return &meta, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("error decoding dapp.json: %s", err)
+	}
+	return &meta, nil
+}
+
 func RunDappDeploy(ctx *Context) error {
 	u, err := ctx.URI()
 	if err != nil {
@@ -69,41 +129,132 @@ func RunDappDeploy(ctx *Context) error {
 	}
 	id := u.ID
 
+	dir := ctx.Args.String("<dir>")
+	meta, err := loadDappMetadata(dir)
+	if err != nil {
+		return err
+	}
+	d := &dapp.Dapp{
+		ID:          quad.IRI(u.String()),
+		Name:        meta.Name,
+		Description: meta.Description,
+		Version:     meta.Version,
+		Publisher:   quad.IRI(id.Hex()),
+		Icon:        meta.Icon,
+		Permissions: meta.Permissions,
+	}
+
 	client, err := ctx.Client()
 	if err != nil {
 		return err
 	}
 
-	swarm := swarm.NewClient(ctx.Args.String("--swarm-api"))
-	dir := ctx.Args.String("<dir>")
-	var defaultPath string
-	if _, err := os.Stat(filepath.Join(dir, "index.html")); err == nil {
-		defaultPath = filepath.Join(dir, "index.html")
+	// stream the tar of the directory to the node, closing the pipe if
+	// the deploy fails so that tarDir returns
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(tarDir(dir, pw))
+	}()
+
+	log.Info("deploying dapp", "uri", d.ID, "version", d.Version)
+	hash, err := client.DeployDapp(ctx, d, pr)
+	if err != nil {
+		return err
 	}
-	manifestHash, err := swarm.UploadDirectory(dir, defaultPath, "")
+
+	if err := setGraph(ctx, client, id, hash); err != nil {
+		return err
+	}
+
+	log.Info("dapp deployed", "uri", d.ID, "hash", hash)
+	return nil
+}
+
+// tarDir writes a tar stream of the regular files in the directory.
+func tarDir(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(relPath)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	}); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func RunDappList(ctx *Context) error {
+	id, err := ctx.KordID()
+	if err != nil {
+		return err
+	}
+
+	client, err := ctx.APIClient()
+	if err != nil {
+		return err
+	}
+	dapps, err := client.Dapps(id.Hex())
+	if err != nil {
+		return err
+	}
+	return writeJSON(ctx, dapps)
+}
+
+func RunDappInfo(ctx *Context) error {
+	u, err := ctx.URI()
 	if err != nil {
 		return err
 	}
 
-	qs := client.QuadStore(id.Hex())
-	qw, err := graph.NewQuadWriter("single", qs, nil)
+	client, err := ctx.APIClient()
 	if err != nil {
 		return err
 	}
-	w := graph.NewWriter(qw)
-	d := dapp.Dapp{
-		ID:           quad.IRI(u.String()),
-		ManifestHash: manifestHash,
+	versions, err := client.DappVersions(u.ID.Hex(), u.String())
+	if err != nil {
+		return err
 	}
-	if _, err := schema.WriteAsQuads(w, d); err != nil {
+	return writeJSON(ctx, versions)
+}
+
+func RunDappRollback(ctx *Context) error {
+	u, err := ctx.URI()
+	if err != nil {
 		return err
 	}
-	if err := w.Flush(); err != nil {
+	id := u.ID
+	version := ctx.Args.String("<version>")
+
+	client, err := ctx.Client()
+	if err != nil {
 		return err
 	}
 
-	log.Info("committing graph")
-	hash, err := client.CommitGraph(ctx, id.Hex())
+	log.Info("rolling back dapp", "uri", u, "version", version)
+	hash, err := client.RollbackDapp(ctx, u.String(), version)
 	if err != nil {
 		return err
 	}
@@ -112,7 +263,7 @@ func RunDappDeploy(ctx *Context) error {
 		return err
 	}
 
-	log.Info("dapp deployed", "uri", d.ID, "hash", hash)
+	log.Info("dapp rolled back", "uri", u, "version", version, "hash", hash)
 	return nil
 }
 
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/quad"
@@ -37,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/kord-network/go-kord/kord"
 	"github.com/kord-network/go-kord/registry"
+	"github.com/kord-network/go-kord/registry/gossip"
 	"github.com/moby/moby/pkg/term"
 )
 
@@ -44,9 +46,18 @@ func init() {
 	registerCommand("graph", RunGraph, `
 usage: kord graph create [options] <id>
        kord graph load [options] <id> <file>
+       kord graph pin [options] <id>
+       kord graph unpin [options] <id>
+       kord graph list-pinned [options]
+       kord graph set-readers [options] <id> [<pubkey>...]
+       kord graph readers [options] <id>
 
 Create, update or query a KORD graph.
 
+Graphs with readers are encrypted when they are committed so that only the
+readers can decrypt them, where readers are hex encoded KORD ID public keys
+as output by 'kord id pubkey'.
+
 options:
         -u, --url <url>        URL of the KORD node
 	-k, --keystore <dir>   Keystore directory
@@ -59,6 +70,16 @@ func RunGraph(ctx *Context) error {
 		return RunGraphCreate(ctx)
 	case ctx.Args.Bool("load"):
 		return RunGraphLoad(ctx)
+	case ctx.Args.Bool("pin"):
+		return RunGraphPin(ctx)
+	case ctx.Args.Bool("unpin"):
+		return RunGraphUnpin(ctx)
+	case ctx.Args.Bool("list-pinned"):
+		return RunGraphListPinned(ctx)
+	case ctx.Args.Bool("set-readers"):
+		return RunGraphSetReaders(ctx)
+	case ctx.Args.Bool("readers"):
+		return RunGraphReaders(ctx)
 	default:
 		return errors.New("unknown graph command")
 	}
@@ -123,6 +144,108 @@ func RunGraphLoad(ctx *Context) error {
 	return nil
 }
 
+func RunGraphPin(ctx *Context) error {
+	idArg := ctx.Args.String("<id>")
+	if !common.IsHexAddress(idArg) {
+		return fmt.Errorf("invalid KORD ID, must be a hex string: %s", idArg)
+	}
+	id := common.HexToAddress(idArg)
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	log.Info("pinning graph", "id", id)
+	if err := client.PinGraph(ctx, id.Hex()); err != nil {
+		return err
+	}
+
+	log.Info("graph pinned successfully", "id", id)
+	return nil
+}
+
+func RunGraphUnpin(ctx *Context) error {
+	idArg := ctx.Args.String("<id>")
+	if !common.IsHexAddress(idArg) {
+		return fmt.Errorf("invalid KORD ID, must be a hex string: %s", idArg)
+	}
+	id := common.HexToAddress(idArg)
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	log.Info("unpinning graph", "id", id)
+	if err := client.UnpinGraph(ctx, id.Hex()); err != nil {
+		return err
+	}
+
+	log.Info("graph unpinned successfully", "id", id)
+	return nil
+}
+
+func RunGraphListPinned(ctx *Context) error {
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	ids, err := client.PinnedGraphs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		fmt.Fprintln(ctx.Stdout, id)
+	}
+	return nil
+}
+
+func RunGraphSetReaders(ctx *Context) error {
+	idArg := ctx.Args.String("<id>")
+	if !common.IsHexAddress(idArg) {
+		return fmt.Errorf("invalid KORD ID, must be a hex string: %s", idArg)
+	}
+	id := common.HexToAddress(idArg)
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	readers := ctx.Args.List("<pubkey>")
+	log.Info("setting graph readers", "id", id, "count", len(readers))
+	if err := client.SetGraphReaders(ctx, id.Hex(), readers); err != nil {
+		return err
+	}
+
+	log.Info("graph readers set successfully", "id", id)
+	return nil
+}
+
+func RunGraphReaders(ctx *Context) error {
+	idArg := ctx.Args.String("<id>")
+	if !common.IsHexAddress(idArg) {
+		return fmt.Errorf("invalid KORD ID, must be a hex string: %s", idArg)
+	}
+	id := common.HexToAddress(idArg)
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	readers, err := client.GraphReaders(ctx, id.Hex())
+	if err != nil {
+		return err
+	}
+	for _, reader := range readers {
+		fmt.Fprintln(ctx.Stdout, reader)
+	}
+	return nil
+}
+
 func loadQuads(ctx *Context, client *kord.Client, id common.Address, file string) (int, error) {
 	var in io.Reader
 	f, err := os.Open(file)
@@ -155,31 +278,64 @@ func loadQuads(ctx *Context, client *kord.Client, id common.Address, file string
 
 func setGraph(ctx *Context, client *kord.Client, id common.Address, hash common.Hash) error {
 	log.Info("signing graph hash", "hash", hash)
-	sig, err := signHash(ctx, id, hash)
+	sign := signer(ctx, id)
+	sig, err := sign(hash)
+	if err != nil {
+		return err
+	}
+
+	// announce the update to peers so they get it before the registry
+	// transaction is mined
+	update, err := gossip.NewUpdate(id, hash, uint64(time.Now().UnixNano()), sign)
 	if err != nil {
 		return err
 	}
+	if err := client.AnnounceGraph(ctx, update); err != nil {
+		log.Warn("error announcing graph update", "err", err)
+	}
+
+	// the update signature covers its nonce, so it is also a signed
+	// record for registries which store records rather than hashes
+	record := &registry.Record{
+		KordID: update.KordID,
+		Hash:   update.Hash,
+		Nonce:  update.Nonce,
+		Sig:    update.Sig,
+	}
 
 	log.Info("updating registry")
-	return client.SetGraph(ctx, hash, sig)
+	return client.SetGraph(ctx, hash, sig, record)
 }
 
 func signHash(ctx *Context, id common.Address, hash common.Hash) ([]byte, error) {
-	if id == registry.DevAddr {
-		return crypto.Sign(hash[:], registry.DevKey)
-	}
-	ks := keystore.NewKeyStore(
-		ctx.Args.String("--keystore"),
-		keystore.StandardScryptN,
-		keystore.StandardScryptP,
-	)
-	account, err := ks.Find(accounts.Account{Address: id})
-	if err != nil {
-		return nil, err
-	}
-	passphrase, err := getPassphrase(ctx, false)
-	if err != nil {
-		return nil, fmt.Errorf("error reading passphrase: %s", err)
+	return signer(ctx, id)(hash)
+}
+
+// signer returns a function which signs hashes with the key of the KORD ID,
+// prompting for the passphrase at most once.
+func signer(ctx *Context, id common.Address) func(common.Hash) ([]byte, error) {
+	var passphrase []byte
+	var unlocked bool
+	return func(hash common.Hash) ([]byte, error) {
+		if id == registry.DevAddr {
+			return crypto.Sign(hash[:], registry.DevKey)
+		}
+		ks := keystore.NewKeyStore(
+			ctx.Args.String("--keystore"),
+			keystore.StandardScryptN,
+			keystore.StandardScryptP,
+		)
+		account, err := ks.Find(accounts.Account{Address: id})
+		if err != nil {
+			return nil, err
+		}
+		if !unlocked {
+			passphrase, err = getPassphrase(ctx, false)
+			if err != nil {
+				return nil, fmt.Errorf("error reading passphrase: %s", err)
+			}
+			unlocked = true
+		}
+		return ks.SignHashWithPassphrase(account, string(passphrase), hash[:])
 	}
-	return ks.SignHashWithPassphrase(account, string(passphrase), hash[:])
 }
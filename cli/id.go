@@ -20,22 +20,29 @@
 package cli
 
 import (
-	"bufio"
 	"bytes"
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/kord-network/go-kord/registry"
 	"github.com/moby/moby/pkg/term"
 )
 
 func init() {
 	registerCommand("id", RunID, `
 usage: kord id new [options]
+       kord id pubkey [options] <id>
 
-Create a new KORD ID.
+Create a new KORD ID or print the hex encoded public key of a KORD ID.
 
 options:
 	-k, --keystore <dir>   Keystore directory
@@ -46,6 +53,8 @@ func RunID(ctx *Context) error {
 	switch {
 	case ctx.Args.Bool("new"):
 		return RunIDNew(ctx)
+	case ctx.Args.Bool("pubkey"):
+		return RunIDPubkey(ctx)
 	default:
 		return errors.New("unknown id command")
 	}
@@ -70,6 +79,49 @@ func RunIDNew(ctx *Context) error {
 	return nil
 }
 
+func RunIDPubkey(ctx *Context) error {
+	key, err := loadKey(ctx, ctx.Args.String("<id>"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.Stdout, hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey)))
+	return nil
+}
+
+// loadKey loads the private key of the KORD ID from the keystore, prompting
+// for the passphrase.
+func loadKey(ctx *Context, idArg string) (*ecdsa.PrivateKey, error) {
+	if !common.IsHexAddress(idArg) {
+		return nil, fmt.Errorf("invalid KORD ID, must be a hex string: %s", idArg)
+	}
+	id := common.HexToAddress(idArg)
+	if id == registry.DevAddr {
+		return registry.DevKey, nil
+	}
+	ks := keystore.NewKeyStore(
+		ctx.Args.String("--keystore"),
+		keystore.StandardScryptN,
+		keystore.StandardScryptP,
+	)
+	account, err := ks.Find(accounts.Account{Address: id})
+	if err != nil {
+		return nil, err
+	}
+	keyjson, err := ioutil.ReadFile(account.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := getPassphrase(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("error reading passphrase: %s", err)
+	}
+	key, err := keystore.DecryptKey(keyjson, string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
 func getPassphrase(ctx *Context, confirm bool) ([]byte, error) {
 	if stdin, ok := ctx.Stdin.(*os.File); ok && term.IsTerminal(stdin.Fd()) {
 		state, err := term.SaveState(stdin.Fd())
@@ -79,7 +131,7 @@ func getPassphrase(ctx *Context, confirm bool) ([]byte, error) {
 		term.DisableEcho(stdin.Fd(), state)
 		defer term.RestoreTerminal(stdin.Fd(), state)
 	}
-	stdin := bufio.NewReader(ctx.Stdin)
+	stdin := ctx.stdinReader()
 	fmt.Fprint(ctx.Stderr, "Passphrase: ")
 	passphrase, err := stdin.ReadBytes('\n')
 	fmt.Fprintln(ctx.Stderr)
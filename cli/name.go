@@ -0,0 +1,102 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/kord-network/go-kord/pkg/uri"
+	"github.com/kord-network/go-kord/registry"
+)
+
+func init() {
+	registerCommand("name", RunName, `
+usage: kord name register [options] <name> <id>
+       kord name resolve [options] <name>
+
+Register or resolve a human-readable KORD name.
+
+options:
+        -u, --url <url>        URL of the KORD node
+        -k, --keystore <dir>   Keystore directory
+
+example:
+        kord name register jaak.kord 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88
+
+        kord name resolve jaak.kord
+`[1:])
+}
+
+func RunName(ctx *Context) error {
+	switch {
+	case ctx.Args.Bool("register"):
+		return RunNameRegister(ctx)
+	case ctx.Args.Bool("resolve"):
+		return RunNameResolve(ctx)
+	default:
+		return errors.New("unknown name command")
+	}
+}
+
+func RunNameRegister(ctx *Context) error {
+	name := ctx.Args.String("<name>")
+	if !uri.IsName(name) {
+		return fmt.Errorf("invalid KORD name, must be of the form <label>.%s: %s", uri.NameDomain, name)
+	}
+	idArg := ctx.Args.String("<id>")
+	if !common.IsHexAddress(idArg) {
+		return fmt.Errorf("invalid KORD ID, must be a hex string: %s", idArg)
+	}
+	id := common.HexToAddress(idArg)
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	log.Info("signing name", "name", name)
+	sig, err := signHash(ctx, id, registry.NameSigHash(name, id))
+	if err != nil {
+		return err
+	}
+
+	log.Info("registering name", "name", name, "id", id)
+	if err := client.RegisterName(ctx, name, id, sig); err != nil {
+		return err
+	}
+	log.Info("name registered successfully", "name", name, "id", id)
+	return nil
+}
+
+func RunNameResolve(ctx *Context) error {
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+	id, err := client.ResolveName(ctx, ctx.Args.String("<name>"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.Stdout, id.Hex())
+	return nil
+}
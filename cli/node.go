@@ -56,22 +56,33 @@ var testnetBootnodes = []string{
 
 func init() {
 	registerCommand("node", RunNode, `
-usage: kord node [--datadir <dir>] [--config <path>] [--dev] [--testnet] [--mine] [--root-dapp <uri>] [--cors-domain <domain>...]
+usage: kord node [--datadir <dir>] [--config <path>] [--dev] [--testnet] [--mine] [--root-dapp <uri>] [--schema-graph <id>] [--cors-domain <domain>...] [--eth-rpc <url>] [--swarm-api <url>] [--registry-addr <address>] [--ens-addr <address>]
+       kord node status [--url <url>]
 
-Run a KORD node.
+Run a KORD node, or report whether a running node is ready.
 
 options:
+	-u, --url <url>             URL of the KORD node
 	-d, --datadir <dir>         Node data directory
 	-c, --config <path>         Path to the TOML config file
 	--dev                       Run a dev node
 	--testnet                   Connect to the testnet
 	--mine                      Mine the Ethereum chain
 	--root-dapp <uri>           Dapp to serve at root of KORD API
+	--schema-graph <id>         Graph of RDF classes to generate GraphQL types from
 	--cors-domain <domain>...   The allowed CORS domains
+	--eth-rpc <url>             Ethereum JSON-RPC URL to use instead of running Ethereum
+	--swarm-api <url>           Swarm HTTP gateway URL to use instead of running Swarm
+	--registry-addr <address>   Address of the KORD registry contract
+	--ens-addr <address>        Address of the ENS registry containing KORD names
 `[1:])
 }
 
 func RunNode(ctx *Context) error {
+	if ctx.Args.Bool("status") {
+		return RunNodeStatus(ctx)
+	}
+
 	cfg := defaultConfig()
 
 	if file := ctx.Args.String("--config"); file != "" {
@@ -97,12 +108,50 @@ func RunNode(ctx *Context) error {
 		cfg.Kord.RootDapp = dapp
 	}
 
+	if id := ctx.Args.String("--schema-graph"); id != "" {
+		cfg.Kord.SchemaGraph = id
+	}
+
 	if _, ok := ctx.Args["--cors-domain"]; ok {
 		domains := ctx.Args.List("--cors-domain")
 		cfg.Swarm.Cors = strings.Join(domains, ",")
 		cfg.Kord.CORSDomains = domains
 	}
 
+	if url := ctx.Args.String("--eth-rpc"); url != "" {
+		cfg.Kord.EthRPC = url
+	}
+
+	if url := ctx.Args.String("--swarm-api"); url != "" {
+		cfg.Kord.SwarmAPI = url
+	}
+
+	if addr := ctx.Args.String("--registry-addr"); addr != "" {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid --registry-addr, must be a hex address: %s", addr)
+		}
+		cfg.Kord.RegistryAddr = common.HexToAddress(addr)
+	}
+
+	if addr := ctx.Args.String("--ens-addr"); addr != "" {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid --ens-addr, must be a hex address: %s", addr)
+		}
+		cfg.Kord.ENSAddr = common.HexToAddress(addr)
+	}
+
+	for _, modules := range [][]string{cfg.Node.HTTPModules, cfg.Node.WSModules} {
+		for _, module := range modules {
+			if module == "kord" {
+				return errors.New("the kord RPC API can only be served over IPC")
+			}
+		}
+	}
+
+	if cfg.Kord.EthRPC != "" && (ctx.Args.Bool("--dev") || ctx.Args.Bool("--mine")) {
+		return errors.New("--dev and --mine require a local Ethereum node so cannot be used with --eth-rpc")
+	}
+
 	if ctx.Args.Bool("--dev") && ctx.Args.Bool("--testnet") {
 		return errors.New("--dev and --testnet cannot both be set")
 	} else if ctx.Args.Bool("--dev") {
@@ -138,10 +187,16 @@ func RunNode(ctx *Context) error {
 		}
 	}
 
-	utils.RegisterEthService(stack, &cfg.Eth)
+	// only run Ethereum and Swarm if the KORD service is not using
+	// external endpoints
+	if cfg.Kord.EthRPC == "" {
+		utils.RegisterEthService(stack, &cfg.Eth)
+	}
 
-	if err := registerSwarmService(stack, &cfg.Swarm); err != nil {
-		return err
+	if cfg.Kord.SwarmAPI == "" {
+		if err := registerSwarmService(stack, &cfg.Swarm); err != nil {
+			return err
+		}
 	}
 
 	if err := registerKordService(stack, &cfg.Kord); err != nil {
@@ -170,6 +225,15 @@ func RunNode(ctx *Context) error {
 			return err
 		}
 		log.Info("deployed KORD registry", "addr", addr)
+
+		log.Info("deploying KORD name registry")
+		addr, err = registry.DeployNames(stack.IPCEndpoint(), registry.DefaultConfig)
+		if err != nil {
+			log.Error("error deploying KORD name registry", "err", err)
+			stack.Stop()
+			return err
+		}
+		log.Info("deployed KORD name registry", "addr", addr)
 	}
 
 	// stop the node if the context is cancelled
@@ -183,6 +247,30 @@ func RunNode(ctx *Context) error {
 	return nil
 }
 
+// RunNodeStatus prints the result of each readiness check of a running
+// node, returning an error if the node is not ready.
+func RunNodeStatus(ctx *Context) error {
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+	status, err := client.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, check := range status.Checks {
+		result := "ok"
+		if !check.OK {
+			result = "error: " + check.Error
+		}
+		fmt.Fprintf(ctx.Stdout, "%-14s %s\n", check.Name, result)
+	}
+	if !status.Ready {
+		return errors.New("node is not ready")
+	}
+	return nil
+}
+
 func registerSwarmService(stack *node.Node, cfg *swarmapi.Config) error {
 	cfg.Path = stack.InstanceDir()
 
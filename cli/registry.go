@@ -0,0 +1,225 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package cli
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/kord-network/go-kord/registry"
+)
+
+func init() {
+	registerCommand("registry", RunRegistry, `
+usage: kord registry deploy [options] [--registry-addr <address>] [--ens-addr <address>]
+       kord registry get [options] <id>
+       kord registry set [options] <id> <hash>
+       kord registry watch [options] <id>
+
+Deploy, query or update the KORD registry.
+
+The deploy command deploys the KORD registry and name registry contracts
+to the Ethereum node at --url using the key of --account, which defaults to
+the dev account, skipping contracts which already exist at --registry-addr
+or --ens-addr, and prints their addresses for use in a node's RegistryAddr
+and ENSAddr config. The other commands use the KORD node at --url.
+
+options:
+        -u, --url <url>              URL of the KORD or Ethereum node
+        -k, --keystore <dir>         Keystore directory
+        -a, --account <address>      Account to deploy the contracts with
+        --registry-addr <address>    Address of an existing KORD registry contract
+        --ens-addr <address>         Address of an existing ENS registry
+
+example:
+        kord registry deploy --url http://localhost:8545
+
+        kord registry get 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88
+
+        kord registry set 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88 0x5c1ebf...
+
+        kord registry watch 0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88
+`[1:])
+}
+
+func RunRegistry(ctx *Context) error {
+	switch {
+	case ctx.Args.Bool("deploy"):
+		return RunRegistryDeploy(ctx)
+	case ctx.Args.Bool("get"):
+		return RunRegistryGet(ctx)
+	case ctx.Args.Bool("set"):
+		return RunRegistrySet(ctx)
+	case ctx.Args.Bool("watch"):
+		return RunRegistryWatch(ctx)
+	default:
+		return errors.New("unknown registry command")
+	}
+}
+
+func RunRegistryDeploy(ctx *Context) error {
+	url := ctx.Args.String("--url")
+	if url == "" {
+		return errors.New("missing --url of the Ethereum node")
+	}
+	key, err := deployKey(ctx)
+	if err != nil {
+		return err
+	}
+	config := registry.Config{Key: key}
+	if addr := ctx.Args.String("--registry-addr"); addr != "" {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid --registry-addr, must be a hex address: %s", addr)
+		}
+		config.ContractAddr = common.HexToAddress(addr)
+	}
+	if addr := ctx.Args.String("--ens-addr"); addr != "" {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid --ens-addr, must be a hex address: %s", addr)
+		}
+		config.ENSAddr = common.HexToAddress(addr)
+	}
+
+	// Deploy and DeployNames return the existing address without deploying
+	// if there is already a contract at the configured address
+	log.Info("deploying KORD registry", "url", url)
+	addr, err := registry.Deploy(url, config)
+	if err != nil {
+		return err
+	}
+	log.Info("deployed KORD registry", "addr", addr)
+	fmt.Fprintf(ctx.Stdout, "RegistryAddr = %q\n", addr.Hex())
+
+	log.Info("deploying KORD name registry", "url", url)
+	addr, err = registry.DeployNames(url, config)
+	if err != nil {
+		return err
+	}
+	log.Info("deployed KORD name registry", "addr", addr)
+	fmt.Fprintf(ctx.Stdout, "ENSAddr = %q\n", addr.Hex())
+	return nil
+}
+
+func RunRegistryGet(ctx *Context) error {
+	id, err := ctx.KordID()
+	if err != nil {
+		return err
+	}
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+	hash, err := client.Graph(ctx, id)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.Stdout, hash.Hex())
+	return nil
+}
+
+func RunRegistrySet(ctx *Context) error {
+	id, err := ctx.KordID()
+	if err != nil {
+		return err
+	}
+	hashArg := ctx.Args.String("<hash>")
+	if len(common.FromHex(hashArg)) != common.HashLength {
+		return fmt.Errorf("invalid hash, must be a 32 byte hex string: %s", hashArg)
+	}
+	hash := common.HexToHash(hashArg)
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+	if err := setGraph(ctx, client, id, hash); err != nil {
+		return err
+	}
+	log.Info("registry updated successfully", "id", id, "hash", hash)
+	return nil
+}
+
+func RunRegistryWatch(ctx *Context) error {
+	id, err := ctx.KordID()
+	if err != nil {
+		return err
+	}
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+	updates := make(chan common.Hash)
+	sub, err := client.SubscribeGraph(ctx, id, updates)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	log.Info("watching registry", "id", id)
+	for {
+		select {
+		case hash := <-updates:
+			fmt.Fprintln(ctx.Stdout, hash.Hex())
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// deployKey returns the private key of the --account used to deploy the
+// registry contracts, defaulting to the dev key.
+func deployKey(ctx *Context) (*ecdsa.PrivateKey, error) {
+	addr := ctx.Args.String("--account")
+	if addr == "" {
+		return registry.DevKey, nil
+	}
+	if !common.IsHexAddress(addr) {
+		return nil, fmt.Errorf("invalid --account, must be a hex address: %s", addr)
+	}
+	ks := keystore.NewKeyStore(
+		ctx.Args.String("--keystore"),
+		keystore.StandardScryptN,
+		keystore.StandardScryptP,
+	)
+	account, err := ks.Find(accounts.Account{Address: common.HexToAddress(addr)})
+	if err != nil {
+		return nil, err
+	}
+	keyjson, err := ioutil.ReadFile(account.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := getPassphrase(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("error reading passphrase: %s", err)
+	}
+	key, err := keystore.DecryptKey(keyjson, string(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
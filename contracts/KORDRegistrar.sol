@@ -0,0 +1,84 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+pragma solidity ^0.4.0;
+
+// The parts of the ENS registry used by the KORD registrar.
+contract ENS {
+    function owner(bytes32 node) constant returns (address);
+    function resolver(bytes32 node) constant returns (address);
+    function setOwner(bytes32 node, address owner);
+    function setSubnodeOwner(bytes32 node, bytes32 label, address owner);
+    function setResolver(bytes32 node, address resolver);
+}
+
+// The parts of the ENS public resolver used by the KORD registrar.
+contract Resolver {
+    function setAddr(bytes32 node, address addr);
+}
+
+// The KORD name registrar, which owns the top-level KORD domain and
+// assigns each name to the first KORD ID which signs it.
+contract KORDRegistrar {
+    // sha3("KORD name registration"), which KORD IDs sign along with the
+    // name so that the signature cannot be used for anything else
+    bytes32 constant DOMAIN = 0x8757c794c95dc744cf205bde3544a61e5a02ce5d04954ca68811553f884186ee;
+
+    ENS ens;
+    bytes32 rootNode;
+
+    function KORDRegistrar(ENS ensAddr, bytes32 node) {
+        ens = ensAddr;
+        rootNode = node;
+    }
+
+    // register assigns the name with the given label to the KORD ID, which
+    // must have signed sha3(DOMAIN, kordID, node), and points the name at
+    // the KORD ID using the top-level domain's resolver. Names which
+    // already have an owner cannot be registered again.
+    function register(bytes32 label, address kordID, bytes sig) {
+        if (kordID == 0) throw;
+        bytes32 node = sha3(rootNode, label);
+        if (ens.owner(node) != 0) throw;
+
+        uint8 v;
+        bytes32 r;
+        bytes32 s;
+
+        if (sig.length != 65) throw;
+
+        assembly {
+            r := mload(add(sig, 32))
+            s := mload(add(sig, 64))
+            v := byte(0, mload(add(sig, 96)))
+        }
+
+        if (v < 27) v += 27;
+
+        if (v != 27 && v != 28) throw;
+
+        if (ecrecover(sha3(DOMAIN, kordID, node), v, r, s) != kordID) throw;
+
+        address resolver = ens.resolver(rootNode);
+        ens.setSubnodeOwner(rootNode, label, this);
+        ens.setResolver(node, resolver);
+        Resolver(resolver).setAddr(node, kordID);
+        ens.setOwner(node, kordID);
+    }
+}
@@ -22,19 +22,29 @@ pragma solidity ^0.4.0;
 // The KORD registry contract.
 contract KORDRegistry {
     mapping(address=>bytes32) graphs;
+    mapping(address=>uint64) nonces;
 
     function graph(address kordID) constant returns (bytes32) {
         return graphs[kordID];
     }
 
-    // ref: https://gist.github.com/axic/5b33912c6f61ae6fd96d6c4a47afde6d
+    function nonce(address kordID) constant returns (uint64) {
+        return nonces[kordID];
+    }
+
+    // setGraph sets the graph hash of the KORD ID, which must have signed
+    // sha3(kordID, hash, nonce) as it does for off-chain registry records,
+    // and which replaces the current record if it has a higher nonce or the
+    // same nonce and a higher hash, so old records cannot be replayed.
     //
-    // TODO: prevent replay attacks with sha3(hash || nonce)
-    function setGraph(bytes32 hash, bytes sig) {
+    // ref: https://gist.github.com/axic/5b33912c6f61ae6fd96d6c4a47afde6d
+    function setGraph(address kordID, bytes32 hash, uint64 nonce, bytes sig) {
         uint8 v;
         bytes32 r;
         bytes32 s;
 
+        if (kordID == 0) throw;
+
         if (sig.length != 65) throw;
 
         assembly {
@@ -47,8 +57,12 @@ contract KORDRegistry {
 
         if (v != 27 && v != 28) throw;
 
-        address kordID = ecrecover(hash, v, r, s);
+        if (ecrecover(sha3(kordID, hash, nonce), v, r, s) != kordID) throw;
+
+        if (nonce < nonces[kordID]) throw;
+        if (nonce == nonces[kordID] && uint(hash) <= uint(graphs[kordID])) throw;
 
         graphs[kordID] = hash;
+        nonces[kordID] = nonce;
     }
 }
@@ -20,15 +20,316 @@
 package dapp
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/schema"
 	"github.com/cayleygraph/cayley/voc"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func init() {
 	voc.RegisterPrefix("dapp:", "http://schema.kord-network.io/dapp/")
 }
 
+// ErrNotFound is returned when a dapp or dapp version does not exist.
+var ErrNotFound = errors.New("dapp not found")
+
+// Dapp is the record of a dapp stored in the graph of its publisher.
+//
+// The record stored at the dapp's URI is the deployed version, and every
+// version is also stored at the URI returned by VersionID, linked to the
+// version it replaced by Previous.
 type Dapp struct {
-	ID           quad.IRI `quad:"@id"`
-	ManifestHash string   `quad:"dapp:manifestHash"`
+	ID           quad.IRI `quad:"@id" json:"uri"`
+	ManifestHash string   `quad:"dapp:manifestHash" json:"manifestHash"`
+
+	Name        string `quad:"dapp:name,optional" json:"name,omitempty"`
+	Description string `quad:"dapp:description,optional" json:"description,omitempty"`
+
+	// Version is the semantic version of the dapp.
+	Version string `quad:"dapp:version,optional" json:"version,omitempty"`
+
+	// Publisher is the KORD ID which published the dapp.
+	Publisher quad.IRI `quad:"dapp:publisher,optional" json:"publisher,omitempty"`
+
+	// Icon is the path of the dapp's icon in its manifest.
+	Icon string `quad:"dapp:icon,optional" json:"icon,omitempty"`
+
+	// Permissions are the graph permissions the dapp requires, in the
+	// form "read:<graph>" or "write:<graph>".
+	Permissions []string `quad:"dapp:permission" json:"permissions,omitempty"`
+
+	// Previous is the ID of the record of the version which this version
+	// replaced.
+	Previous quad.IRI `quad:"dapp:previous,optional" json:"previous,omitempty"`
+
+	// VersionOf is the URI of the dapp if this is a version record.
+	VersionOf quad.IRI `quad:"dapp:versionOf,optional" json:"versionOf,omitempty"`
+}
+
+// URI returns the URI of the dapp, which for version records is the URI
+// of the dapp they are a version of.
+func (d *Dapp) URI() quad.IRI {
+	if d.VersionOf != "" {
+		return d.VersionOf
+	}
+	return d.ID
+}
+
+// Validate checks the dapp's metadata.
+func (d *Dapp) Validate() error {
+	if d.ID == "" {
+		return errors.New("missing dapp URI")
+	}
+	if d.ManifestHash == "" {
+		return errors.New("missing dapp manifest hash")
+	}
+	if d.Version != "" && !IsVersion(d.Version) {
+		return fmt.Errorf("invalid dapp version, must be a semantic version: %s", d.Version)
+	}
+	if d.Publisher != "" && !common.IsHexAddress(string(d.Publisher)) {
+		return fmt.Errorf("invalid dapp publisher, must be a KORD ID: %s", d.Publisher)
+	}
+	for _, p := range d.Permissions {
+		if err := validatePermission(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePermission(p string) error {
+	parts := strings.SplitN(p, ":", 2)
+	if len(parts) != 2 || (parts[0] != "read" && parts[0] != "write") || parts[1] == "" {
+		return fmt.Errorf("invalid dapp permission, must be read:<graph> or write:<graph>: %s", p)
+	}
+	return nil
+}
+
+var versionPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+// IsVersion reports whether s is a semantic version like "1.2.3" or
+// "1.2.3-beta.1".
+func IsVersion(s string) bool {
+	return versionPattern.MatchString(s)
+}
+
+// DefaultVersion returns the version of a dapp deployed without one, which
+// is a pre-release of 0.0.0 identified by the manifest hash.
+func DefaultVersion(manifestHash string) string {
+	id := manifestHash
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return "0.0.0-" + id
+}
+
+// VersionID returns the ID of the record of the given version of the dapp.
+func VersionID(uri quad.IRI, version string) quad.IRI {
+	return quad.IRI(string(uri) + "?version=" + version)
+}
+
+// Load loads the deployed version of the dapp with the given URI.
+func Load(ctx context.Context, qs graph.QuadStore, uri quad.IRI) (*Dapp, error) {
+	return load(ctx, qs, uri)
+}
+
+// LoadVersion loads the given version of the dapp with the given URI.
+func LoadVersion(ctx context.Context, qs graph.QuadStore, uri quad.IRI, version string) (*Dapp, error) {
+	return load(ctx, qs, VersionID(uri, version))
+}
+
+// LoadPrevious loads the version which the given version of a dapp
+// replaced.
+func LoadPrevious(ctx context.Context, qs graph.QuadStore, d *Dapp) (*Dapp, error) {
+	if d.Previous == "" {
+		return nil, ErrNotFound
+	}
+	return load(ctx, qs, d.Previous)
+}
+
+func load(ctx context.Context, qs graph.QuadStore, id quad.IRI) (*Dapp, error) {
+	var dapps []Dapp
+	if err := schema.LoadToDepth(ctx, qs, &dapps, 0, id); err != nil {
+		return nil, err
+	}
+	if len(dapps) == 0 {
+		return nil, ErrNotFound
+	}
+	return &dapps[0], nil
+}
+
+// List loads the deployed version of all the dapps in the graph, sorted by
+// URI.
+func List(ctx context.Context, qs graph.QuadStore) ([]*Dapp, error) {
+	var all []Dapp
+	if err := schema.LoadToDepth(ctx, qs, &all, 0); err != nil {
+		return nil, err
+	}
+	dapps := make([]*Dapp, 0, len(all))
+	for i := range all {
+		if all[i].VersionOf == "" {
+			dapps = append(dapps, &all[i])
+		}
+	}
+	sort.Slice(dapps, func(i, j int) bool { return dapps[i].ID < dapps[j].ID })
+	return dapps, nil
+}
+
+// Versions loads the version records of the dapp with the given URI,
+// starting with the deployed version and following the links to previous
+// versions.
+func Versions(ctx context.Context, qs graph.QuadStore, uri quad.IRI) ([]*Dapp, error) {
+	d, err := Load(ctx, qs, uri)
+	if err != nil {
+		return nil, err
+	}
+	if d.Version == "" {
+		return []*Dapp{d}, nil
+	}
+	var versions []*Dapp
+	seen := make(map[quad.IRI]struct{})
+	id := VersionID(uri, d.Version)
+	for id != "" {
+		if _, ok := seen[id]; ok {
+			break
+		}
+		seen[id] = struct{}{}
+		v, err := load(ctx, qs, id)
+		if err == ErrNotFound {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+		id = v.Previous
+	}
+	return versions, nil
+}
+
+// Deploy stores a new version of the dapp, linking it to the currently
+// deployed version and replacing the deployed record. Dapps without a
+// version get a pre-release version derived from their manifest hash.
+//
+// Deploying an existing version is only allowed if it has the same
+// manifest hash, in which case that version becomes the deployed version.
+func Deploy(ctx context.Context, qs graph.QuadStore, d *Dapp) error {
+	d.VersionOf = ""
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if d.Version == "" {
+		d.Version = DefaultVersion(d.ManifestHash)
+	}
+	existing, err := LoadVersion(ctx, qs, d.ID, d.Version)
+	if err == nil {
+		if existing.ManifestHash != d.ManifestHash {
+			return fmt.Errorf("version %s of dapp %s already exists", d.Version, d.ID)
+		}
+		_, err := Rollback(ctx, qs, d.ID, d.Version)
+		return err
+	} else if err != ErrNotFound {
+		return err
+	}
+
+	d.Previous = ""
+	current, err := Load(ctx, qs, d.ID)
+	if err == nil && current.Version != "" {
+		d.Previous = VersionID(d.ID, current.Version)
+	} else if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	version := *d
+	version.ID = VersionID(d.ID, d.Version)
+	version.VersionOf = d.ID
+	deltas, err := replaceDeltas(ctx, qs, d.ID, d)
+	if err != nil {
+		return err
+	}
+	add, err := quadDeltas(&version, graph.Add)
+	if err != nil {
+		return err
+	}
+	return qs.ApplyDeltas(append(deltas, add...), graph.IgnoreOpts{})
+}
+
+// Rollback makes the given version of the dapp the deployed version.
+func Rollback(ctx context.Context, qs graph.QuadStore, uri quad.IRI, version string) (*Dapp, error) {
+	d, err := LoadVersion(ctx, qs, uri, version)
+	if err != nil {
+		return nil, err
+	}
+	d.ID = uri
+	d.VersionOf = ""
+	deltas, err := replaceDeltas(ctx, qs, uri, d)
+	if err != nil {
+		return nil, err
+	}
+	if err := qs.ApplyDeltas(deltas, graph.IgnoreOpts{}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// replaceDeltas returns the deltas which replace the quads with the given
+// subject with the dapp record, leaving quads which are in both untouched.
+func replaceDeltas(ctx context.Context, qs graph.QuadStore, subject quad.IRI, d *Dapp) ([]graph.Delta, error) {
+	add, err := quadDeltas(d, graph.Add)
+	if err != nil {
+		return nil, err
+	}
+	keep := make(map[string]struct{}, len(add))
+	for _, delta := range add {
+		keep[delta.Quad.NQuad()] = struct{}{}
+	}
+	var deltas []graph.Delta
+	if node := qs.ValueOf(subject); node != nil {
+		it := qs.QuadIterator(quad.Subject, node)
+		defer it.Close()
+		for it.Next(ctx) {
+			q := qs.Quad(it.Result())
+			if _, ok := keep[q.NQuad()]; ok {
+				delete(keep, q.NQuad())
+				continue
+			}
+			deltas = append(deltas, graph.Delta{Quad: q, Action: graph.Delete})
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for _, delta := range add {
+		if _, ok := keep[delta.Quad.NQuad()]; ok {
+			deltas = append(deltas, delta)
+		}
+	}
+	return deltas, nil
+}
+
+func quadDeltas(d *Dapp, action graph.Procedure) ([]graph.Delta, error) {
+	var w quadCollector
+	if _, err := schema.WriteAsQuads(&w, d); err != nil {
+		return nil, err
+	}
+	deltas := make([]graph.Delta, len(w))
+	for i, q := range w {
+		deltas[i] = graph.Delta{Quad: q, Action: action}
+	}
+	return deltas, nil
+}
+
+// quadCollector is a quad.Writer which collects quads in memory.
+type quadCollector []quad.Quad
+
+func (c *quadCollector) WriteQuad(q quad.Quad) error {
+	*c = append(*c, q)
+	return nil
 }
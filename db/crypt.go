@@ -0,0 +1,276 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package db
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+)
+
+// Encrypted graph databases are stored as the encryptedMagic header, the
+// length of the key envelope as a big-endian uint32, the JSON encoded key
+// envelope and then the database encrypted with AES-256-GCM in segments of
+// segmentSize bytes.
+//
+// Each commit uses a new random key, so segment nonces are the segment
+// index with the last byte set for the final segment, which prevents
+// segments being reordered or the database being truncated.
+const (
+	encryptedMagic = "KORDENC1"
+	segmentSize    = 64 * 1024
+	maxEnvelope    = 1024 * 1024
+)
+
+// ErrNoReaderKey is returned when fetching an encrypted graph database
+// which none of the driver's reader keys can decrypt.
+var ErrNoReaderKey = errors.New("graph is encrypted and no reader key is available")
+
+// ErrNotEncrypted is returned when fetching an unencrypted database for a
+// graph which has readers.
+var ErrNotEncrypted = errors.New("graph has readers but its database is not encrypted")
+
+// envelope contains the symmetric key of an encrypted graph database
+// encrypted using ECIES to the public key of each reader, keyed by the
+// reader's KORD ID.
+type envelope struct {
+	Readers map[common.Address]hexutil.Bytes `json:"readers"`
+}
+
+// SetReaders sets the public keys of the readers of the graph database with
+// the given name, which is encrypted when it is committed so that only
+// those readers can decrypt it, with no readers meaning the database is
+// stored unencrypted.
+func (d *Driver) SetReaders(name string, readers []*ecdsa.PublicKey) {
+	d.cryptMtx.Lock()
+	defer d.cryptMtx.Unlock()
+	if len(readers) == 0 {
+		delete(d.readers, name)
+		return
+	}
+	d.readers[name] = readers
+}
+
+// Readers returns the public keys of the readers of the graph database with
+// the given name.
+func (d *Driver) Readers(name string) []*ecdsa.PublicKey {
+	d.cryptMtx.Lock()
+	defer d.cryptMtx.Unlock()
+	return d.readers[name]
+}
+
+// AddReaderKey adds a KORD ID private key which is used to decrypt
+// encrypted graph databases which list the KORD ID as a reader.
+func (d *Driver) AddReaderKey(key *ecdsa.PrivateKey) {
+	d.cryptMtx.Lock()
+	defer d.cryptMtx.Unlock()
+	d.keys[crypto.PubkeyToAddress(key.PublicKey)] = key
+}
+
+// encrypt writes the database read from src to dst encrypted with a new
+// key which is sealed to each of the readers.
+func encrypt(dst io.Writer, src io.Reader, readers []*ecdsa.PublicKey) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	env := &envelope{Readers: make(map[common.Address]hexutil.Bytes, len(readers))}
+	for _, pub := range readers {
+		sealed, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(pub), key, nil, nil)
+		if err != nil {
+			return err
+		}
+		env.Readers[crypto.PubkeyToAddress(*pub)] = sealed
+	}
+	envJSON, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, len(encryptedMagic)+4)
+	copy(header, encryptedMagic)
+	binary.BigEndian.PutUint32(header[len(encryptedMagic):], uint32(len(envJSON)))
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+	if _, err := dst.Write(envJSON); err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	// read one byte ahead so that the final segment is known when it is
+	// sealed, sealing an empty final segment for an empty database
+	r := bufio.NewReaderSize(src, segmentSize+1)
+	buf := make([]byte, segmentSize)
+	for i := uint64(0); ; i++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		_, peekErr := r.Peek(1)
+		final := peekErr != nil
+		if peekErr != nil && peekErr != io.EOF {
+			return peekErr
+		}
+		if _, err := dst.Write(aead.Seal(nil, segmentNonce(i, final), buf[:n], nil)); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// decryptFile decrypts the database at path in place if it is encrypted,
+// using one of the driver's reader keys, and rejects an unencrypted
+// database for a graph which has readers.
+func (d *Driver) decryptFile(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	magic := make([]byte, len(encryptedMagic))
+	if _, err := io.ReadFull(f, magic); err != nil || string(magic) != encryptedMagic {
+		if len(d.Readers(name)) > 0 {
+			return ErrNotEncrypted
+		}
+		return nil
+	}
+	tmp, err := ioutil.TempFile("", "kord-db")
+	if err != nil {
+		return err
+	}
+	err = d.decrypt(tmp, f)
+	tmp.Close()
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// decrypt writes the database read from src, positioned after the
+// encryptedMagic header, to dst.
+func (d *Driver) decrypt(dst io.Writer, src io.Reader) error {
+	var envLen uint32
+	if err := binary.Read(src, binary.BigEndian, &envLen); err != nil {
+		return err
+	}
+	if envLen > maxEnvelope {
+		return fmt.Errorf("encrypted database key envelope too large: %d bytes", envLen)
+	}
+	envJSON := make([]byte, envLen)
+	if _, err := io.ReadFull(src, envJSON); err != nil {
+		return err
+	}
+	var env envelope
+	if err := json.Unmarshal(envJSON, &env); err != nil {
+		return fmt.Errorf("invalid encrypted database key envelope: %s", err)
+	}
+	key, err := d.openEnvelope(&env)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(src, segmentSize+aead.Overhead()+1)
+	buf := make([]byte, segmentSize+aead.Overhead())
+	for i := uint64(0); ; i++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		_, peekErr := r.Peek(1)
+		final := peekErr != nil
+		if peekErr != nil && peekErr != io.EOF {
+			return peekErr
+		}
+		plain, err := aead.Open(buf[:0], segmentNonce(i, final), buf[:n], nil)
+		if err != nil {
+			return fmt.Errorf("error decrypting database: %s", err)
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// openEnvelope decrypts the symmetric key from the envelope using one of
+// the driver's reader keys, trying each reader which has a key before
+// returning the last error.
+func (d *Driver) openEnvelope(env *envelope) ([]byte, error) {
+	d.cryptMtx.Lock()
+	defer d.cryptMtx.Unlock()
+	err := ErrNoReaderKey
+	for addr, sealed := range env.Readers {
+		key, ok := d.keys[addr]
+		if !ok {
+			continue
+		}
+		var symKey []byte
+		symKey, err = ecies.ImportECDSA(key).Decrypt(rand.Reader, sealed, nil, nil)
+		if err == nil {
+			return symKey, nil
+		}
+	}
+	return nil, err
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func segmentNonce(i uint64, final bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce, i)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
@@ -21,6 +21,7 @@ package db
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
@@ -28,20 +29,37 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/swarm/storage"
-	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/kord-network/go-kord/metrics"
 	"github.com/kord-network/go-kord/registry"
+	"github.com/kord-network/go-kord/store"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+var (
+	openTimer    = metrics.NewTimer("kord_graph_open_duration_seconds", "Time taken to open graph databases, including fetching them from Swarm.")
+	openErrors   = metrics.NewCounter("kord_graph_open_errors_total", "Number of graph databases which failed to open.")
+	fetchTimer   = metrics.NewTimer("kord_graph_fetch_duration_seconds", "Time taken to fetch and verify graph databases from Swarm.")
+	fetchErrors  = metrics.NewCounter("kord_graph_fetch_errors_total", "Number of graph databases which failed to fetch or verify.")
+	commitTimer  = metrics.NewTimer("kord_graph_commit_duration_seconds", "Time taken to commit graph databases to Swarm.")
+	commitErrors = metrics.NewCounter("kord_graph_commit_errors_total", "Number of graph databases which failed to commit.")
+	storedBytes  = metrics.NewCounter("kord_swarm_stored_bytes_total", "Number of bytes of graph databases stored in Swarm.")
+	fetchedBytes = metrics.NewCounter("kord_swarm_retrieved_bytes_total", "Number of bytes of graph databases retrieved from Swarm.")
+	connsGauge   = metrics.NewGaugeVec("kord_graph_connections", "Number of open connections to each graph database.", "graph")
 )
 
 // Driver implements the driver.Conn interface by wrapping a SQLite3 driver
-// with connections that use databases stored in Swarm.
+// with connections that use databases stored in a content store.
 type Driver struct {
 	name     string
-	dpa      *storage.DPA
+	store    store.ContentStore
 	registry registry.Registry
 	dir      string
 
@@ -49,16 +67,40 @@ type Driver struct {
 
 	dbs   map[string]*db
 	dbMtx sync.Mutex
+
+	fetchErrs event.Feed
+
+	// readers are the public keys which graph databases are encrypted to
+	// when committed, and keys are the private keys used to decrypt
+	// fetched graph databases
+	readers  map[string][]*ecdsa.PublicKey
+	keys     map[common.Address]*ecdsa.PrivateKey
+	cryptMtx sync.Mutex
+}
+
+// FetchError is sent to fetch error subscribers when fetching or verifying
+// an updated graph database fails, in which case the previous version of the
+// database continues to be used.
+type FetchError struct {
+	Name string
+	Hash common.Hash
+	Err  error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("error fetching database %s (%s): %s", e.Name, e.Hash.Hex(), e.Err)
 }
 
 // NewDriver creates and registers a new database driver.
-func NewDriver(name string, dpa *storage.DPA, registry registry.Registry, dir string) *Driver {
+func NewDriver(name string, store store.ContentStore, registry registry.Registry, dir string) *Driver {
 	d := &Driver{
 		name:     name,
-		dpa:      dpa,
+		store:    store,
 		registry: registry,
 		dir:      dir,
 		dbs:      make(map[string]*db),
+		readers:  make(map[string][]*ecdsa.PublicKey),
+		keys:     make(map[common.Address]*ecdsa.PrivateKey),
 	}
 	sql.Register(name, d)
 	return d
@@ -77,6 +119,17 @@ func (d *Driver) Open(name string) (driver.Conn, error) {
 // Commit commits the SQLite graph database with the given name by storing it
 // in Swarm and returning the resulting Swarm hash.
 func (d *Driver) Commit(name string) (common.Hash, error) {
+	start := time.Now()
+	hash, err := d.commit(name)
+	if err != nil {
+		commitErrors.Inc(1)
+		return common.Hash{}, err
+	}
+	commitTimer.UpdateSince(start)
+	return hash, nil
+}
+
+func (d *Driver) commit(name string) (common.Hash, error) {
 	path := filepath.Join(d.dir, name)
 	f, err := os.Open(path)
 	if err != nil {
@@ -87,11 +140,126 @@ func (d *Driver) Commit(name string) (common.Hash, error) {
 	if err != nil {
 		return common.Hash{}, err
 	}
-	key, err := d.dpa.Store(f, info.Size(), &sync.WaitGroup{}, &sync.WaitGroup{})
+	var content io.Reader = f
+	if readers := d.Readers(name); len(readers) > 0 {
+		pr, pw := io.Pipe()
+		go func() { pw.CloseWithError(encrypt(pw, f, readers)) }()
+		defer pr.Close()
+		content = pr
+	}
+	hash, err := d.store.Store(content)
 	if err != nil {
 		return common.Hash{}, err
 	}
-	return common.BytesToHash(key[:]), nil
+	storedBytes.Inc(info.Size())
+	if err := writeHash(path, hash); err != nil {
+		return common.Hash{}, err
+	}
+
+	// record the hash so that the registry update for the commit does not
+	// trigger a fetch of content we already have
+	d.dbMtx.Lock()
+	if db, ok := d.dbs[name]; ok {
+		db.setHash(hash)
+	}
+	d.dbMtx.Unlock()
+
+	return hash, nil
+}
+
+// SubscribeFetchErrors subscribes to errors fetching updated graph databases.
+func (d *Driver) SubscribeFetchErrors(ch chan<- *FetchError) event.Subscription {
+	return d.fetchErrs.Subscribe(ch)
+}
+
+// File is a graph database stored in the driver's directory.
+type File struct {
+	Name string
+
+	// Size is the size of the database including any SQLite journal
+	Size int64
+
+	// ModTime is when the database was last fetched or written to
+	ModTime time.Time
+
+	// Hash is the hash of the database when it was last committed to or
+	// fetched from the content store, which is zero if the database has
+	// never been stored
+	Hash common.Hash
+}
+
+// hashSuffix is the suffix of the file next to each database which records
+// the hash it was last committed or fetched with.
+const hashSuffix = "-hash"
+
+// writeHash records the hash which the database at path was committed or
+// fetched with.
+func writeHash(path string, hash common.Hash) error {
+	return ioutil.WriteFile(path+hashSuffix, []byte(hash.Hex()), 0644)
+}
+
+// Files returns the graph databases stored in the driver's directory.
+func (d *Driver) Files() ([]*File, error) {
+	infos, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*File)
+	var names []string
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		name := info.Name()
+		for _, suffix := range []string{"-journal", "-wal", "-shm", hashSuffix} {
+			name = strings.TrimSuffix(name, suffix)
+		}
+		f, ok := files[name]
+		if !ok {
+			f = &File{Name: name}
+			files[name] = f
+			names = append(names, name)
+		}
+		if info.Name() == name+hashSuffix {
+			hash, err := ioutil.ReadFile(filepath.Join(d.dir, info.Name()))
+			if err != nil {
+				return nil, err
+			}
+			f.Hash = common.HexToHash(string(hash))
+		}
+		f.Size += info.Size()
+		if info.ModTime().After(f.ModTime) {
+			f.ModTime = info.ModTime()
+		}
+	}
+	sort.Strings(names)
+	list := make([]*File, len(names))
+	for i, name := range names {
+		list[i] = files[name]
+	}
+	return list, nil
+}
+
+// Remove closes the graph database with the given name and removes it from
+// the driver's directory, so that it is fetched again if it is re-opened.
+// Any connections to the database are closed.
+func (d *Driver) Remove(name string) error {
+	d.dbMtx.Lock()
+	db, ok := d.dbs[name]
+	delete(d.dbs, name)
+	d.dbMtx.Unlock()
+	if ok {
+		db.close()
+		<-db.done
+	}
+	connsGauge.Delete(name)
+	path := filepath.Join(d.dir, name)
+	for _, p := range []string{path, path + "-journal", path + "-wal", path + "-shm", path + hashSuffix} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
 }
 
 func (d *Driver) openDB(name string) (*db, error) {
@@ -103,6 +271,19 @@ func (d *Driver) openDB(name string) (*db, error) {
 		return db, nil
 	}
 
+	start := time.Now()
+	db, err := d.loadDB(name)
+	if err != nil {
+		openErrors.Inc(1)
+		return nil, err
+	}
+	openTimer.UpdateSince(start)
+	return db, nil
+}
+
+// loadDB fetches the database with the given name and starts a goroutine
+// which fetches updates, and must be called with dbMtx held.
+func (d *Driver) loadDB(name string) (*db, error) {
 	// get the current hash from the registry
 	addr := common.HexToAddress(name)
 	hash, err := d.registry.Graph(addr)
@@ -110,10 +291,16 @@ func (d *Driver) openDB(name string) (*db, error) {
 		return nil, err
 	}
 
-	// fetch the database
+	// fetch the database, falling back to a previously fetched version
+	// if there is one
 	path := filepath.Join(d.dir, name)
+	fetched := true
 	if err := d.fetchDB(hash, path); err != nil {
-		return nil, err
+		if _, statErr := os.Stat(path); statErr != nil {
+			return nil, err
+		}
+		d.fetchErrs.Send(&FetchError{Name: name, Hash: hash, Err: err})
+		fetched = false
 	}
 
 	// subscribe to registry updates
@@ -124,14 +311,20 @@ func (d *Driver) openDB(name string) (*db, error) {
 	}
 
 	db := newDB(d, path)
+	if fetched {
+		db.setHash(hash)
+	}
 	d.dbs[name] = db
 	go func() {
 		defer func() {
 			sub.Close()
 			db.close()
 			d.dbMtx.Lock()
-			delete(d.dbs, name)
+			if d.dbs[name] == db {
+				delete(d.dbs, name)
+			}
 			d.dbMtx.Unlock()
+			close(db.done)
 		}()
 		for {
 			select {
@@ -139,9 +332,14 @@ func (d *Driver) openDB(name string) (*db, error) {
 				if !ok {
 					return
 				}
+				if hash == db.currentHash() {
+					continue
+				}
 				if err := d.fetchDB(hash, path); err != nil {
-					return
+					d.fetchErrs.Send(&FetchError{Name: name, Hash: hash, Err: err})
+					continue
 				}
+				db.setHash(hash)
 				if err := db.reopenConns(); err != nil {
 					return
 				}
@@ -154,58 +352,135 @@ func (d *Driver) openDB(name string) (*db, error) {
 	return db, nil
 }
 
+// fetchDB fetches the database with the given hash to a temporary file and
+// only moves it to path once it has been verified, so that a bad update
+// leaves the existing database in place.
 func (d *Driver) fetchDB(hash common.Hash, path string) error {
+	start := time.Now()
+	if err := d.fetchVerifyDB(hash, path); err != nil {
+		fetchErrors.Inc(1)
+		return err
+	}
+	fetchTimer.UpdateSince(start)
+	return nil
+}
+
+func (d *Driver) fetchVerifyDB(hash common.Hash, path string) error {
 	tmp, err := ioutil.TempFile("", "kord-db")
 	if err != nil {
 		return err
 	}
-	defer tmp.Close()
-	if err := d.fetchHash(hash, tmp); err != nil {
-		os.Remove(tmp.Name())
-		return err
+	err = d.fetchHash(hash, tmp)
+	tmp.Close()
+	if err == nil {
+		err = d.verifyDB(filepath.Base(path), hash, tmp.Name())
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
 	}
-	if err := os.Rename(tmp.Name(), path); err != nil {
+	if err != nil {
 		os.Remove(tmp.Name())
 		return err
 	}
-	return nil
+	return writeHash(path, hash)
 }
 
-func (d *Driver) fetchHash(hash common.Hash, dst io.Writer) error {
+// verifyDB checks that the database at path has the given Swarm hash,
+// decrypts it if it is encrypted and checks that it is a valid SQLite graph
+// database.
+func (d *Driver) verifyDB(name string, hash common.Hash, path string) error {
 	if common.EmptyHash(hash) {
 		return nil
 	}
-	reader := d.dpa.Retrieve(storage.Key(hash[:]))
-	size, err := reader.Size(nil)
+
+	// check the content hashes to the expected Swarm key
+	f, err := os.Open(path)
 	if err != nil {
 		return err
 	}
-	n, err := io.Copy(dst, io.LimitReader(reader, size))
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	actual, err := store.Hash(f, info.Size())
+	if err != nil {
+		return err
+	}
+	if actual != hash {
+		return fmt.Errorf("database hash mismatch, expected %s, got %s", hash.Hex(), actual.Hex())
+	}
+	if err := d.decryptFile(name, path); err != nil {
+		return err
+	}
+
+	// check the SQLite database integrity and schema
+	sqlDB, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return err
-	} else if n != size {
-		return fmt.Errorf("failed to fetch database, expected %d bytes, copied %d", size, n)
+	}
+	defer sqlDB.Close()
+	var result string
+	if err := sqlDB.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("database integrity check failed: %s", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("database integrity check failed: %s", result)
+	}
+	var tables int
+	if err := sqlDB.QueryRow(
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('nodes', 'quads')`,
+	).Scan(&tables); err != nil {
+		return err
+	}
+	if tables != 2 {
+		return fmt.Errorf("database schema check failed: missing nodes or quads table")
 	}
 	return nil
 }
 
+func (d *Driver) fetchHash(hash common.Hash, dst io.Writer) error {
+	if common.EmptyHash(hash) {
+		return nil
+	}
+	reader, err := d.store.Retrieve(hash)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	n, err := io.Copy(dst, reader)
+	fetchedBytes.Inc(n)
+	return err
+}
+
 type db struct {
 	driver *Driver
 
 	path string
 
-	conns    map[*Conn]struct{}
-	connsMtx sync.RWMutex
+	// hash is the Swarm hash of the current database content
+	hash    common.Hash
+	hashMtx sync.Mutex
+
+	conns      map[*Conn]struct{}
+	connsMtx   sync.RWMutex
+	connsGauge *metrics.Gauge
+
+	closeOnce sync.Once
+	closed    chan struct{}
 
-	closed chan struct{}
+	// done is closed once the database has stopped receiving updates
+	done chan struct{}
 }
 
 func newDB(driver *Driver, path string) *db {
 	return &db{
-		driver: driver,
-		path:   path,
-		conns:  make(map[*Conn]struct{}),
-		closed: make(chan struct{}),
+		driver:     driver,
+		path:       path,
+		conns:      make(map[*Conn]struct{}),
+		connsGauge: connsGauge.With(filepath.Base(path)),
+		closed:     make(chan struct{}),
+		done:       make(chan struct{}),
 	}
 }
 
@@ -220,16 +495,33 @@ func (db *db) newConn() (driver.Conn, error) {
 	return conn, nil
 }
 
+func (db *db) currentHash() common.Hash {
+	db.hashMtx.Lock()
+	defer db.hashMtx.Unlock()
+	return db.hash
+}
+
+func (db *db) setHash(hash common.Hash) {
+	db.hashMtx.Lock()
+	defer db.hashMtx.Unlock()
+	db.hash = hash
+}
+
 func (db *db) addConn(conn *Conn) {
 	db.connsMtx.Lock()
 	defer db.connsMtx.Unlock()
 	db.conns[conn] = struct{}{}
+	db.connsGauge.Update(int64(len(db.conns)))
 }
 
 func (db *db) removeConn(conn *Conn) {
 	db.connsMtx.Lock()
 	defer db.connsMtx.Unlock()
+	if db.conns == nil {
+		return
+	}
 	delete(db.conns, conn)
+	db.connsGauge.Update(int64(len(db.conns)))
 }
 
 func (db *db) reopenConns() error {
@@ -246,13 +538,17 @@ func (db *db) reopenConns() error {
 }
 
 func (db *db) close() {
-	close(db.closed)
-	db.connsMtx.Lock()
-	for conn := range db.conns {
-		conn.Close()
-	}
-	db.conns = nil
-	db.connsMtx.Unlock()
+	db.closeOnce.Do(func() {
+		close(db.closed)
+		db.connsMtx.Lock()
+		conns := db.conns
+		db.conns = nil
+		db.connsGauge.Update(0)
+		db.connsMtx.Unlock()
+		for conn := range conns {
+			conn.Close()
+		}
+	})
 }
 
 type Conn struct {
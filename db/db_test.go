@@ -0,0 +1,364 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package db
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cayleygraph/cayley/graph"
+	cayleysql "github.com/cayleygraph/cayley/graph/sql"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+// TestFetchVerify tests that graph updates which fail verification are
+// reported and that the previous version of the database is kept.
+func TestFetchVerify(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	registry := testutil.NewTestRegistry()
+	driver := NewDriver("kord-db-test", ts, registry, ts.Dir)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	setGraph := func(hash common.Hash) error {
+		sig, err := crypto.Sign(hash[:], key)
+		if err != nil {
+			return err
+		}
+		return registry.SetGraph(hash, sig)
+	}
+
+	// create a graph database with a quad
+	db, err := sql.Open("kord-db-test", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE nodes (hash BLOB PRIMARY KEY)`,
+		`CREATE TABLE quads (subject_hash BLOB)`,
+		`INSERT INTO quads (subject_hash) VALUES ('test')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := driver.Commit(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setGraph(hash); err != nil {
+		t.Fatal(err)
+	}
+
+	// update the graph to point at invalid databases
+	fetchErrs := make(chan *FetchError)
+	sub := driver.SubscribeFetchErrors(fetchErrs)
+	defer sub.Unsubscribe()
+	for _, data := range [][]byte{
+		[]byte("not a SQLite database"),
+		make([]byte, 4096),
+	} {
+		badHash, err := ts.Store(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		errC := make(chan error, 1)
+		go func() { errC <- setGraph(badHash) }()
+		select {
+		case fetchErr := <-fetchErrs:
+			if fetchErr.Hash != badHash {
+				t.Fatalf("expected fetch error for %s, got %s", badHash.Hex(), fetchErr.Hash.Hex())
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for fetch error")
+		}
+		if err := <-errC; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// check the previous version is still used
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM quads`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 quad, got %d", count)
+	}
+}
+
+// TestNodeRefs tests that node reference counts are incremented when quads
+// sharing nodes are added in separate transactions so that deleting one of
+// the quads does not delete the shared nodes.
+func TestNodeRefs(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	driver := NewDriver("kord-refs-test", ts, testutil.NewTestRegistry(), ts.Dir)
+	cayleysql.Register("kord-refs-test", driver.GraphRegistration())
+
+	name := common.Address{}.Hex()
+	if err := graph.InitQuadStore("kord-refs-test", name, graph.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	qs, err := graph.NewQuadStore("kord-refs-test", name, graph.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer qs.Close()
+
+	subject := quad.IRI("http://example.com/subject")
+	predicate := quad.IRI("http://example.com/predicate")
+	quads := []quad.Quad{
+		quad.Make(subject, predicate, quad.String("a"), nil),
+		quad.Make(subject, predicate, quad.String("b"), nil),
+	}
+	for _, q := range quads {
+		if err := qs.ApplyDeltas([]graph.Delta{{Quad: q, Action: graph.Add}}, graph.IgnoreOpts{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := qs.ApplyDeltas([]graph.Delta{{Quad: quads[0], Action: graph.Delete}}, graph.IgnoreOpts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("kord-refs-test", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, v := range []quad.Value{subject, predicate} {
+		var refs int
+		hash := cayleysql.NodeHash{ValueHash: graph.HashOf(v)}.SQLValue()
+		if err := db.QueryRow(`SELECT refs FROM nodes WHERE hash = $1`, hash).Scan(&refs); err != nil {
+			t.Fatalf("error loading node %s: %s", v, err)
+		}
+		if refs != 1 {
+			t.Fatalf("expected node %s to have 1 ref, got %d", v, refs)
+		}
+	}
+}
+
+// TestEncryptedGraph tests that graph databases with readers are stored
+// encrypted and can only be fetched by drivers with a reader key.
+func TestEncryptedGraph(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	registry := testutil.NewTestRegistry()
+	writer := NewDriver("kord-crypt-writer", ts, registry, ts.Dir)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	reader, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.SetReaders(name, []*ecdsa.PublicKey{&key.PublicKey, &reader.PublicKey})
+
+	// create a graph database spanning multiple encrypted segments
+	db, err := sql.Open("kord-crypt-writer", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	value := bytes.Repeat([]byte("KORD"), segmentSize/2)
+	for _, stmt := range []string{
+		`CREATE TABLE nodes (hash BLOB PRIMARY KEY)`,
+		`CREATE TABLE quads (subject_hash BLOB)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO quads (subject_hash) VALUES ($1)`, value); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := writer.Commit(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.SetGraph(hash, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// check the stored content is encrypted
+	r, err := ts.Retrieve(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(content, []byte(encryptedMagic)) {
+		t.Fatal("expected stored database to be encrypted")
+	}
+	if bytes.Contains(content, []byte("SQLite format 3")) || bytes.Contains(content, value[:64]) {
+		t.Fatal("expected stored database not to contain plaintext")
+	}
+
+	dir, err := ioutil.TempDir("", "kord-crypt-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// check a driver with the reader key can fetch the database
+	readerDir := filepath.Join(dir, "reader")
+	if err := os.Mkdir(readerDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	NewDriver("kord-crypt-reader", ts, registry, readerDir).AddReaderKey(reader)
+	readerDB, err := sql.Open("kord-crypt-reader", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readerDB.Close()
+	var actual []byte
+	if err := readerDB.QueryRow(`SELECT subject_hash FROM quads`).Scan(&actual); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, value) {
+		t.Fatal("decrypted database does not match committed database")
+	}
+
+	// check a driver without a reader key cannot fetch the database
+	otherDir := filepath.Join(dir, "other")
+	if err := os.Mkdir(otherDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	NewDriver("kord-crypt-other", ts, registry, otherDir)
+	otherDB, err := sql.Open("kord-crypt-other", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer otherDB.Close()
+	if err := otherDB.Ping(); err != ErrNoReaderKey {
+		t.Fatalf("expected ErrNoReaderKey, got %v", err)
+	}
+
+	// check an unencrypted database is rejected by a driver which expects
+	// the graph to have readers
+	writer.SetReaders(name, nil)
+	if _, err := db.Exec(`INSERT INTO quads (subject_hash) VALUES ($1)`, value[:64]); err != nil {
+		t.Fatal(err)
+	}
+	hash, err = writer.Commit(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err = crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.SetGraph(hash, sig); err != nil {
+		t.Fatal(err)
+	}
+	plainDir := filepath.Join(dir, "plain")
+	if err := os.Mkdir(plainDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	plainDriver := NewDriver("kord-crypt-plain", ts, registry, plainDir)
+	plainDriver.SetReaders(name, []*ecdsa.PublicKey{&reader.PublicKey})
+	plainDriver.AddReaderKey(reader)
+	plainDB, err := sql.Open("kord-crypt-plain", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plainDB.Close()
+	if err := plainDB.Ping(); err != ErrNotEncrypted {
+		t.Fatalf("expected ErrNotEncrypted, got %v", err)
+	}
+}
+
+// TestOpenEnvelope tests that every envelope entry which has a reader key
+// is tried when opening an envelope.
+func TestOpenEnvelope(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	d := NewDriver("kord-crypt-envelope", ts, testutil.NewTestRegistry(), ts.Dir)
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.AddReaderKey(key)
+		keys[i] = key
+	}
+	symKey := bytes.Repeat([]byte{1}, 32)
+	sealed, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(&keys[1].PublicKey), symKey, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := &envelope{Readers: map[common.Address]hexutil.Bytes{
+		crypto.PubkeyToAddress(keys[0].PublicKey): hexutil.Bytes("invalid"),
+		crypto.PubkeyToAddress(keys[1].PublicKey): sealed,
+	}}
+	actual, err := d.openEnvelope(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, symKey) {
+		t.Fatalf("expected key %x, got %x", symKey, actual)
+	}
+
+	// check the decryption error is returned if no entry can be opened
+	delete(env.Readers, crypto.PubkeyToAddress(keys[1].PublicKey))
+	if _, err := d.openEnvelope(env); err == nil || err == ErrNoReaderKey {
+		t.Fatalf("expected decryption error, got %v", err)
+	}
+}
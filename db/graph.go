@@ -113,7 +113,7 @@ func runTx(tx *sql.Tx, nodes []graphlog.NodeUpdate, quads []graphlog.QuadUpdate,
 		_, err = stmt.Exec(values...)
 		if isUniqueErr(err) {
 			if updateValue == nil {
-				updateValue, err = tx.Prepare(`UPDATE nodes SET refs = $1 WHERE hash = $2`)
+				updateValue, err = tx.Prepare(`UPDATE nodes SET refs = refs + $1 WHERE hash = $2`)
 				if err != nil {
 					return err
 				}
@@ -20,14 +20,19 @@
 package graph
 
 import (
+	"crypto/ecdsa"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/cayleygraph/cayley/graph"
 	cayleysql "github.com/cayleygraph/cayley/graph/sql"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/swarm/storage"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/kord-network/go-kord/db"
 	"github.com/kord-network/go-kord/registry"
+	"github.com/kord-network/go-kord/store"
 )
 
 type Driver struct {
@@ -37,11 +42,30 @@ type Driver struct {
 
 	stores   map[string]graph.QuadStore
 	storeMtx sync.Mutex
+
+	// pinned is the set of graphs which are never evicted
+	pinned map[string]struct{}
+
+	// lastUsed records when each graph was last opened or committed, and
+	// is used to evict the least recently used graphs
+	lastUsed map[string]time.Time
+
+	// refs counts the callers of Get which have not yet released each
+	// graph, which is never evicted while it is in use
+	refs map[string]int
+
+	// evicting is the set of graphs being evicted, with each channel
+	// being closed once the graph has been removed
+	evicting map[string]chan struct{}
+
+	// quota is the disk space in bytes which graph databases may use
+	// before unpinned graphs are evicted, with zero meaning no limit
+	quota int64
 }
 
-func NewDriver(name string, dpa *storage.DPA, registry registry.Registry, tmpDir string) *Driver {
-	// create a Swarm backed SQLite database driver
-	db := db.NewDriver(name, dpa, registry, tmpDir)
+func NewDriver(name string, store store.ContentStore, registry registry.Registry, tmpDir string) *Driver {
+	// create a content store backed SQLite database driver
+	db := db.NewDriver(name, store, registry, tmpDir)
 
 	// register the db driver as a Cayley SQL backend
 	cayleysql.Register(name, db.GraphRegistration())
@@ -52,6 +76,10 @@ func NewDriver(name string, dpa *storage.DPA, registry registry.Registry, tmpDir
 		db:       db,
 		registry: registry,
 		stores:   make(map[string]graph.QuadStore),
+		pinned:   make(map[string]struct{}),
+		lastUsed: make(map[string]time.Time),
+		refs:     make(map[string]int),
+		evicting: make(map[string]chan struct{}),
 	}
 }
 
@@ -67,20 +95,247 @@ func (d *Driver) SetGraph(hash common.Hash, sig []byte) error {
 	return d.registry.SetGraph(hash, sig)
 }
 
+// SetRecord sets a record of a graph hash signed with a nonce, returning
+// registry.ErrRecordsUnsupported if the registry only stores signed hashes.
+func (d *Driver) SetRecord(record *registry.Record) error {
+	return registry.SetRecord(d.registry, record)
+}
+
+// Get opens the graph, fetching it if it is not stored locally. The graph is
+// not evicted until each call to Get is followed by a call to Release.
 func (d *Driver) Get(name string) (graph.QuadStore, error) {
 	d.storeMtx.Lock()
-	defer d.storeMtx.Unlock()
+	for {
+		evicted, ok := d.evicting[name]
+		if !ok {
+			break
+		}
+		d.storeMtx.Unlock()
+		<-evicted
+		d.storeMtx.Lock()
+	}
 	if store, ok := d.stores[name]; ok {
+		d.lastUsed[name] = time.Now()
+		d.refs[name]++
+		d.storeMtx.Unlock()
 		return store, nil
 	}
 	store, err := graph.NewQuadStore(d.name, name, graph.Options{})
 	if err != nil {
+		d.storeMtx.Unlock()
 		return nil, err
 	}
 	d.stores[name] = store
+	d.lastUsed[name] = time.Now()
+	d.refs[name]++
+	d.storeMtx.Unlock()
+
+	// opening the graph may have fetched it, so make sure the disk quota
+	// has not been exceeded
+	d.evict()
 	return store, nil
 }
 
+// Release releases a graph returned by Get, allowing it to be evicted once
+// every caller has released it.
+func (d *Driver) Release(name string) {
+	d.storeMtx.Lock()
+	d.refs[name]--
+	idle := d.refs[name] <= 0
+	if idle {
+		delete(d.refs, name)
+	}
+	d.storeMtx.Unlock()
+	if idle {
+		d.evict()
+	}
+}
+
+// Graphs returns the sorted names of the graphs which have been opened.
+func (d *Driver) Graphs() []string {
+	d.storeMtx.Lock()
+	defer d.storeMtx.Unlock()
+	names := make([]string, 0, len(d.stores))
+	for name := range d.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (d *Driver) Commit(name string) (common.Hash, error) {
-	return d.db.Commit(name)
+	hash, err := d.db.Commit(name)
+	if err != nil {
+		return hash, err
+	}
+	d.storeMtx.Lock()
+	d.lastUsed[name] = time.Now()
+	d.storeMtx.Unlock()
+	d.evict()
+	return hash, nil
+}
+
+// Pin protects the graph from eviction and opens it, which fetches the
+// graph if it is not stored locally and keeps it up to date by subscribing
+// to updates in the registry.
+func (d *Driver) Pin(name string) error {
+	d.storeMtx.Lock()
+	d.pinned[name] = struct{}{}
+	d.storeMtx.Unlock()
+	if _, err := d.Get(name); err != nil {
+		d.Unpin(name)
+		return err
+	}
+	d.Release(name)
+	return nil
+}
+
+// Unpin allows the graph to be evicted once the disk quota is exceeded.
+func (d *Driver) Unpin(name string) {
+	d.storeMtx.Lock()
+	delete(d.pinned, name)
+	d.storeMtx.Unlock()
+	d.evict()
+}
+
+// Pinned returns the sorted names of the pinned graphs.
+func (d *Driver) Pinned() []string {
+	d.storeMtx.Lock()
+	defer d.storeMtx.Unlock()
+	names := make([]string, 0, len(d.pinned))
+	for name := range d.pinned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDiskQuota sets the disk space in bytes which graph databases may use
+// before the least recently used unpinned graphs are evicted, with zero
+// meaning no limit.
+func (d *Driver) SetDiskQuota(quota int64) {
+	d.storeMtx.Lock()
+	d.quota = quota
+	d.storeMtx.Unlock()
+	d.evict()
+}
+
+// evict removes the least recently used unpinned graphs from disk until
+// the disk usage is within the quota. Graphs which are in use, which are
+// being evicted or which have never been stored in the content store are
+// not evicted, and the graphs are removed without holding storeMtx since
+// removing a graph waits for its connections to close.
+func (d *Driver) evict() {
+	d.storeMtx.Lock()
+	quota := d.quota
+	d.storeMtx.Unlock()
+	if quota <= 0 {
+		return
+	}
+	files, err := d.db.Files()
+	if err != nil {
+		log.Error("error checking graph disk usage", "err", err)
+		return
+	}
+	var usage int64
+	for _, f := range files {
+		usage += f.Size
+	}
+	if usage <= quota {
+		return
+	}
+
+	// choose the graphs to evict, marking them as being evicted so that
+	// Get waits for them to be removed rather than re-opening them
+	d.storeMtx.Lock()
+
+	// graphs which have not been used since startup fall back to the
+	// time they were last written to disk
+	lastUsed := func(f *db.File) time.Time {
+		if t, ok := d.lastUsed[f.Name]; ok {
+			return t
+		}
+		return f.ModTime
+	}
+	candidates := make([]*db.File, 0, len(files))
+	for _, f := range files {
+		if _, ok := d.pinned[f.Name]; ok {
+			continue
+		}
+		if _, ok := d.evicting[f.Name]; ok {
+			continue
+		}
+		if d.refs[f.Name] > 0 || common.EmptyHash(f.Hash) {
+			continue
+		}
+		candidates = append(candidates, f)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return lastUsed(candidates[i]).Before(lastUsed(candidates[j]))
+	})
+	var (
+		victims []*db.File
+		stores  []graph.QuadStore
+		done    = make(chan struct{})
+	)
+	for _, f := range candidates {
+		if usage <= quota {
+			break
+		}
+		if store, ok := d.stores[f.Name]; ok {
+			stores = append(stores, store)
+			delete(d.stores, f.Name)
+		}
+		delete(d.lastUsed, f.Name)
+		d.evicting[f.Name] = done
+		victims = append(victims, f)
+		usage -= f.Size
+	}
+	d.storeMtx.Unlock()
+	if len(victims) == 0 {
+		log.Warn("graph disk usage exceeds quota", "usage", usage, "quota", quota)
+		return
+	}
+
+	for _, store := range stores {
+		store.Close()
+	}
+	for _, f := range victims {
+		if err := d.db.Remove(f.Name); err != nil {
+			log.Error("error evicting graph", "name", f.Name, "err", err)
+			usage += f.Size
+			continue
+		}
+		log.Info("evicted graph", "name", f.Name, "size", f.Size)
+	}
+	d.storeMtx.Lock()
+	for _, f := range victims {
+		delete(d.evicting, f.Name)
+	}
+	d.storeMtx.Unlock()
+	close(done)
+	if usage > quota {
+		log.Warn("graph disk usage exceeds quota", "usage", usage, "quota", quota)
+	}
+}
+
+// SetReaders sets the public keys of the readers of the graph, which is
+// encrypted to them when it is committed.
+func (d *Driver) SetReaders(name string, readers []*ecdsa.PublicKey) {
+	d.db.SetReaders(name, readers)
+}
+
+// Readers returns the public keys of the readers of the graph.
+func (d *Driver) Readers(name string) []*ecdsa.PublicKey {
+	return d.db.Readers(name)
+}
+
+// AddReaderKey adds a private key used to decrypt encrypted graphs.
+func (d *Driver) AddReaderKey(key *ecdsa.PrivateKey) {
+	d.db.AddReaderKey(key)
+}
+
+// SubscribeFetchErrors subscribes to errors fetching updated graphs.
+func (d *Driver) SubscribeFetchErrors(ch chan<- *db.FetchError) event.Subscription {
+	return d.db.SubscribeFetchErrors(ch)
 }
@@ -21,12 +21,15 @@ package graph
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/sql/sqltest"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/kord-network/go-kord/testutil"
 )
 
@@ -39,13 +42,13 @@ var testDriver *Driver
 //       https://github.com/cayleygraph/cayley/blob/v0.7.1/graph/graphtest/graphtest.go#L655-L673
 func TestMain(m *testing.M) {
 	os.Exit(func() int {
-		dpa, err := testutil.NewTestDPA()
+		ts, err := testutil.NewTestStore()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "error creating test storage:", err)
 			return 1
 		}
-		defer dpa.Cleanup()
-		testDriver = NewDriver("kord-test", dpa.DPA, testutil.NewTestRegistry(), dpa.Dir)
+		defer ts.Cleanup()
+		testDriver = NewDriver("kord-test", ts, testutil.NewTestRegistry(), ts.Dir)
 		return m.Run()
 	}())
 }
@@ -57,3 +60,86 @@ func TestSQLBackend(t *testing.T) {
 func newTestDB(t testing.TB) (string, graph.Options, func()) {
 	return fmt.Sprintf("%d.test.kord", rand.Int()), nil, func() {}
 }
+
+// TestEviction tests that the least recently used unpinned graphs are
+// evicted once the disk quota is exceeded, and that graphs which are in use
+// or have never been committed are not evicted.
+func TestEviction(t *testing.T) {
+	ts, err := testutil.NewTestStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Cleanup()
+	dir, err := ioutil.TempDir("", "kord-evict-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	driver := NewDriver("kord-evict-test", ts, testutil.NewTestRegistry(), dir)
+
+	// create three graphs, pinning the first and using the second
+	names := make([]string, 3)
+	for i := range names {
+		names[i] = common.BytesToAddress([]byte{byte(i + 1)}).Hex()
+		if _, err := driver.Create(names[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := driver.Pin(names[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := driver.Get(names[1]); err != nil {
+		t.Fatal(err)
+	}
+	usage := func() (names []string, size int64) {
+		files, err := driver.db.Files()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			names = append(names, f.Name)
+			size += f.Size
+		}
+		return
+	}
+
+	// check exceeding the quota evicts the least recently used graph
+	_, size := usage()
+	driver.SetDiskQuota(size - 1)
+	if stored, _ := usage(); !reflect.DeepEqual(stored, names[:2]) {
+		t.Fatalf("expected graphs %v to be stored, got %v", names[:2], stored)
+	}
+	if opened := driver.Graphs(); !reflect.DeepEqual(opened, names[:2]) {
+		t.Fatalf("expected graphs %v to be open, got %v", names[:2], opened)
+	}
+
+	// check pinned graphs and graphs in use are never evicted, and that
+	// releasing a graph allows it to be evicted
+	driver.SetDiskQuota(1)
+	if stored, _ := usage(); !reflect.DeepEqual(stored, names[:2]) {
+		t.Fatalf("expected graphs %v to be stored, got %v", names[:2], stored)
+	}
+	driver.Release(names[1])
+	if stored, _ := usage(); !reflect.DeepEqual(stored, names[:1]) {
+		t.Fatalf("expected graphs %v to be stored, got %v", names[:1], stored)
+	}
+	if pinned := driver.Pinned(); !reflect.DeepEqual(pinned, names[:1]) {
+		t.Fatalf("expected graphs %v to be pinned, got %v", names[:1], pinned)
+	}
+
+	// check unpinning allows the graph to be evicted
+	driver.Unpin(names[0])
+	if stored, _ := usage(); len(stored) != 0 {
+		t.Fatalf("expected no graphs to be stored, got %v", stored)
+	}
+
+	// check graphs which have never been committed are not evicted
+	uncommitted := common.BytesToAddress([]byte{4}).Hex()
+	if err := graph.InitQuadStore(driver.name, uncommitted, graph.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	driver.evict()
+	if stored, _ := usage(); !reflect.DeepEqual(stored, []string{uncommitted}) {
+		t.Fatalf("expected graphs %v to be stored, got %v", []string{uncommitted}, stored)
+	}
+}
@@ -44,10 +44,14 @@ type Response struct {
 
 type Client struct {
 	url string
+
+	// Authorize, if set, is called with each request and its body to add
+	// authentication headers
+	Authorize func(req *http.Request, body []byte) error
 }
 
 func NewClient(url string) *Client {
-	return &Client{url}
+	return &Client{url: url}
 }
 
 func (c *Client) Do(query string, variables Variables, out interface{}) (*Response, error) {
@@ -63,6 +67,11 @@ func (c *Client) Do(query string, variables Variables, out interface{}) (*Respon
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if c.Authorize != nil {
+		if err := c.Authorize(httpReq, data); err != nil {
+			return nil, err
+		}
+	}
 	httpRes, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
 		return nil, err
@@ -20,8 +20,17 @@
 package kord
 
 import (
+	"context"
+	"errors"
+
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/kord-network/go-kord/dapp"
+	"github.com/kord-network/go-kord/pkg/did"
+	"github.com/kord-network/go-kord/registry"
+	"github.com/kord-network/go-kord/registry/gossip"
 )
 
 type PublicAPI struct {
@@ -40,15 +49,179 @@ func (api *PublicAPI) CommitGraph(name string) (common.Hash, error) {
 	return api.kord.driver.Commit(name)
 }
 
-func (api *PublicAPI) SetGraph(hash common.Hash, sig []byte) error {
+// SetGraph sets the graph hash of the KORD ID which signed it. Registries
+// which store signed records, like the registry contract and the off-chain
+// registry, use the record instead, whose signature covers a nonce chosen by
+// the KORD ID.
+func (api *PublicAPI) SetGraph(hash common.Hash, sig []byte, record *registry.Record) error {
+	if record != nil {
+		if record.Hash != hash {
+			return errors.New("record hash does not match graph hash")
+		}
+		if err := registry.SetRecord(api.kord.registry, record); err != registry.ErrRecordsUnsupported {
+			return err
+		}
+	}
 	return api.kord.registry.SetGraph(hash, sig)
 }
 
+// AnnounceGraph sends a signed graph update to peers interested in the
+// graph ahead of it being confirmed by the registry contract. Updates are
+// not announced when using the off-chain registry, which gossips the
+// records set using SetGraph.
+func (api *PublicAPI) AnnounceGraph(update *gossip.Update) error {
+	if api.kord.gossip == nil {
+		return nil
+	}
+	return api.kord.gossip.Announce(update)
+}
+
+func (api *PublicAPI) Graph(kordID common.Address) (common.Hash, error) {
+	return api.kord.registry.Graph(kordID)
+}
+
+// GraphUpdates creates a subscription which is notified of updates to the
+// graph hash of the KORD ID.
+func (api *PublicAPI) GraphUpdates(ctx context.Context, kordID common.Address) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	updates := make(chan common.Hash)
+	sub, err := api.kord.registry.SubscribeGraph(kordID, updates)
+	if err != nil {
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case hash := <-updates:
+				notifier.Notify(rpcSub.ID, hash)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// PinGraph pins the graph so that it is fetched, kept up to date and never
+// evicted, including after the node restarts.
+func (api *PublicAPI) PinGraph(name string) error {
+	return api.kord.pinGraph(name)
+}
+
+// UnpinGraph allows the graph to be evicted once the disk quota is
+// exceeded.
+func (api *PublicAPI) UnpinGraph(name string) error {
+	return api.kord.unpinGraph(name)
+}
+
+// PinnedGraphs returns the names of the pinned graphs.
+func (api *PublicAPI) PinnedGraphs() []string {
+	return api.kord.driver.Pinned()
+}
+
+// SetGraphReaders sets the hex encoded public keys of the readers of the
+// graph, which is encrypted to them when it is next committed, with no
+// readers meaning the graph is committed unencrypted.
+func (api *PublicAPI) SetGraphReaders(name string, readers []string) error {
+	return api.kord.setGraphReaders(name, readers)
+}
+
+// GraphReaders returns the hex encoded public keys of the readers of the
+// graph.
+func (api *PublicAPI) GraphReaders(name string) ([]string, error) {
+	return api.kord.graphReaders(name)
+}
+
+// NewDappUpload starts an upload of the tar stream of a dapp directory,
+// which is sent in chunks using WriteDappUpload and then deployed using
+// DeployDapp, returning the ID of the upload.
+func (api *PublicAPI) NewDappUpload() (string, error) {
+	return api.kord.uploads.create()
+}
+
+// WriteDappUpload appends a chunk of at most 1MiB to the dapp upload.
+func (api *PublicAPI) WriteDappUpload(uploadID string, chunk hexutil.Bytes) error {
+	return api.kord.uploads.write(uploadID, chunk)
+}
+
+// DeployDapp stores the files in the tar stream of the dapp upload in
+// Swarm, stores the dapp with the resulting manifest hash as a new version
+// in the graph of the KORD ID in its URI and returns the graph hash which
+// the KORD ID must sign.
+func (api *PublicAPI) DeployDapp(d *dapp.Dapp, uploadID string) (common.Hash, error) {
+	return api.kord.deployDapp(d, uploadID)
+}
+
+// PublishDapp stores a new version of the dapp in the graph of the KORD ID
+// in its URI and returns the graph hash which the KORD ID must sign.
+func (api *PublicAPI) PublishDapp(d *dapp.Dapp) (common.Hash, error) {
+	return api.kord.publishDapp(d)
+}
+
+// RollbackDapp makes the given version of the dapp the deployed version
+// and returns the graph hash which the KORD ID must sign.
+func (api *PublicAPI) RollbackDapp(dappURI, version string) (common.Hash, error) {
+	return api.kord.rollbackDapp(dappURI, version)
+}
+
 func (api *PublicAPI) SetRootDapp(dappURI string) error {
 	return api.kord.setRootDapp(dappURI)
 }
 
+func (api *PublicAPI) ResolveName(name string) (common.Address, error) {
+	names, err := api.kord.names()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return names.ResolveName(name)
+}
+
+// RegisterName registers the name for the KORD ID, which must have signed
+// the NameSigHash of the name.
+func (api *PublicAPI) RegisterName(name string, kordID common.Address, sig []byte) error {
+	signer, err := registry.RecoverKordID(registry.NameSigHash(name, kordID), sig)
+	if err != nil {
+		return err
+	}
+	if signer != kordID {
+		return errors.New("invalid name signature")
+	}
+	names, err := api.kord.names()
+	if err != nil {
+		return err
+	}
+	return names.RegisterName(name, kordID, sig)
+}
+
+// ResolveDID resolves a "did:kord:<address>" DID to its DID document.
+func (api *PublicAPI) ResolveDID(d string) (*did.Document, error) {
+	return did.Resolve(api.kord.registry, d)
+}
+
+// ApiToken returns a token which authenticates the node operator to the
+// HTTP API.
+func (api *PublicAPI) ApiToken() string {
+	return api.kord.apiToken
+}
+
+// Status reports whether the node is ready to serve requests.
+func (api *PublicAPI) Status(ctx context.Context) *Status {
+	return api.kord.status(ctx)
+}
+
+// HttpAddr returns the address of the HTTP server, or an empty string if
+// it is disabled.
 func (api *PublicAPI) HttpAddr() string {
+	if api.kord.srv == nil {
+		return ""
+	}
 	return api.kord.srv.Addr
 }
 
@@ -57,6 +230,7 @@ func (api *PublicAPI) ApplyDeltas(name string, in []graph.Delta, opts graph.Igno
 	if err != nil {
 		return common.Hash{}, err
 	}
+	defer api.kord.driver.Release(name)
 	if err := qs.ApplyDeltas(in, opts); err != nil {
 		return common.Hash{}, err
 	}
@@ -21,11 +21,17 @@ package kord
 
 import (
 	"context"
+	"io"
 
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/quad"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/kord-network/go-kord/dapp"
+	"github.com/kord-network/go-kord/pkg/did"
+	"github.com/kord-network/go-kord/registry"
+	"github.com/kord-network/go-kord/registry/gossip"
 )
 
 type Client struct {
@@ -50,14 +56,116 @@ func (c *Client) CommitGraph(ctx context.Context, id string) (common.Hash, error
 	return hash, c.client.CallContext(ctx, &hash, "kord_commitGraph", id)
 }
 
-func (c *Client) SetGraph(ctx context.Context, hash common.Hash, sig []byte) error {
-	return c.client.CallContext(ctx, nil, "kord_setGraph", hash, sig)
+// SetGraph sets the graph hash signed by a KORD ID, along with a record of
+// the hash signed with a nonce for registries which store signed records.
+func (c *Client) SetGraph(ctx context.Context, hash common.Hash, sig []byte, record *registry.Record) error {
+	return c.client.CallContext(ctx, nil, "kord_setGraph", hash, sig, record)
+}
+
+func (c *Client) AnnounceGraph(ctx context.Context, update *gossip.Update) error {
+	return c.client.CallContext(ctx, nil, "kord_announceGraph", update)
+}
+
+func (c *Client) Graph(ctx context.Context, kordID common.Address) (common.Hash, error) {
+	var hash common.Hash
+	return hash, c.client.CallContext(ctx, &hash, "kord_graph", kordID)
+}
+
+func (c *Client) SubscribeGraph(ctx context.Context, kordID common.Address, updates chan common.Hash) (*rpc.ClientSubscription, error) {
+	return c.client.Subscribe(ctx, "kord", updates, "graphUpdates", kordID)
+}
+
+func (c *Client) PinGraph(ctx context.Context, id string) error {
+	return c.client.CallContext(ctx, nil, "kord_pinGraph", id)
+}
+
+func (c *Client) UnpinGraph(ctx context.Context, id string) error {
+	return c.client.CallContext(ctx, nil, "kord_unpinGraph", id)
+}
+
+func (c *Client) PinnedGraphs(ctx context.Context) ([]string, error) {
+	var ids []string
+	return ids, c.client.CallContext(ctx, &ids, "kord_pinnedGraphs")
+}
+
+func (c *Client) SetGraphReaders(ctx context.Context, id string, readers []string) error {
+	return c.client.CallContext(ctx, nil, "kord_setGraphReaders", id, readers)
+}
+
+func (c *Client) GraphReaders(ctx context.Context, id string) ([]string, error) {
+	var readers []string
+	return readers, c.client.CallContext(ctx, &readers, "kord_graphReaders", id)
+}
+
+// DeployDapp sends the tar stream of a dapp directory to the node in
+// chunks, so that no single request holds the whole directory, and then
+// deploys the dapp, returning the graph hash which the KORD ID must sign.
+func (c *Client) DeployDapp(ctx context.Context, d *dapp.Dapp, tarball io.Reader) (common.Hash, error) {
+	var uploadID string
+	if err := c.client.CallContext(ctx, &uploadID, "kord_newDappUpload"); err != nil {
+		return common.Hash{}, err
+	}
+	chunk := make([]byte, maxDappChunkSize)
+	for {
+		n, err := io.ReadFull(tarball, chunk)
+		if n > 0 {
+			if err := c.client.CallContext(ctx, nil, "kord_writeDappUpload", uploadID, hexutil.Bytes(chunk[:n])); err != nil {
+				return common.Hash{}, err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return common.Hash{}, err
+		}
+	}
+	var hash common.Hash
+	return hash, c.client.CallContext(ctx, &hash, "kord_deployDapp", d, uploadID)
+}
+
+func (c *Client) PublishDapp(ctx context.Context, d *dapp.Dapp) (common.Hash, error) {
+	var hash common.Hash
+	return hash, c.client.CallContext(ctx, &hash, "kord_publishDapp", d)
+}
+
+func (c *Client) RollbackDapp(ctx context.Context, uri, version string) (common.Hash, error) {
+	var hash common.Hash
+	return hash, c.client.CallContext(ctx, &hash, "kord_rollbackDapp", uri, version)
 }
 
 func (c *Client) SetRootDapp(ctx context.Context, uri string) error {
 	return c.client.CallContext(ctx, nil, "kord_setRootDapp", uri)
 }
 
+func (c *Client) ResolveName(ctx context.Context, name string) (common.Address, error) {
+	var kordID common.Address
+	return kordID, c.client.CallContext(ctx, &kordID, "kord_resolveName", name)
+}
+
+func (c *Client) RegisterName(ctx context.Context, name string, kordID common.Address, sig []byte) error {
+	return c.client.CallContext(ctx, nil, "kord_registerName", name, kordID, sig)
+}
+
+func (c *Client) ResolveDID(ctx context.Context, d string) (*did.Document, error) {
+	var doc did.Document
+	return &doc, c.client.CallContext(ctx, &doc, "kord_resolveDID", d)
+}
+
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	return &status, c.client.CallContext(ctx, &status, "kord_status")
+}
+
+func (c *Client) HttpAddr(ctx context.Context) (string, error) {
+	var addr string
+	return addr, c.client.CallContext(ctx, &addr, "kord_httpAddr")
+}
+
+func (c *Client) APIToken(ctx context.Context) (string, error) {
+	var token string
+	return token, c.client.CallContext(ctx, &token, "kord_apiToken")
+}
+
 func (c *Client) QuadStore(name string) graph.QuadStore {
 	return &clientQuadStore{c.client, name}
 }
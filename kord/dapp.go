@@ -0,0 +1,272 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package kord
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/ethereum/go-ethereum/common"
+	swarmapi "github.com/ethereum/go-ethereum/swarm/api"
+	swarmclient "github.com/ethereum/go-ethereum/swarm/api/client"
+	"github.com/kord-network/go-kord/dapp"
+	"github.com/kord-network/go-kord/pkg/uri"
+)
+
+// maxDappChunkSize is the maximum size of each chunk of a dapp upload, which
+// bounds the size of the JSON-RPC requests used to send a dapp to the node.
+const maxDappChunkSize = 1024 * 1024
+
+// dappUploads are the tar streams of dapp directories which are being sent
+// to the node in chunks, which are buffered in temporary files until the
+// dapp is deployed.
+type dappUploads struct {
+	files map[string]*os.File
+	mtx   sync.Mutex
+}
+
+func newDappUploads() *dappUploads {
+	return &dappUploads{files: make(map[string]*os.File)}
+}
+
+// create starts a new upload, returning its ID.
+func (u *dappUploads) create() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	f, err := ioutil.TempFile("", "kord-dapp-upload")
+	if err != nil {
+		return "", err
+	}
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	u.files[hex.EncodeToString(id)] = f
+	return hex.EncodeToString(id), nil
+}
+
+// write appends a chunk to the upload.
+func (u *dappUploads) write(id string, chunk []byte) error {
+	if len(chunk) > maxDappChunkSize {
+		return fmt.Errorf("dapp upload chunk is %d bytes, exceeding the maximum of %d bytes", len(chunk), maxDappChunkSize)
+	}
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	f, ok := u.files[id]
+	if !ok {
+		return fmt.Errorf("unknown dapp upload: %s", id)
+	}
+	_, err := f.Write(chunk)
+	return err
+}
+
+// take removes the upload, returning its file positioned at the start of
+// the tar stream, which the caller must remove once it is read.
+func (u *dappUploads) take(id string) (*os.File, error) {
+	u.mtx.Lock()
+	f, ok := u.files[id]
+	delete(u.files, id)
+	u.mtx.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown dapp upload: %s", id)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		removeDappUpload(f)
+		return nil, err
+	}
+	return f, nil
+}
+
+// close removes any uploads which were never deployed.
+func (u *dappUploads) close() {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	for id, f := range u.files {
+		removeDappUpload(f)
+		delete(u.files, id)
+	}
+}
+
+func removeDappUpload(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// deployDapp stores the files in the tar stream of the upload in Swarm,
+// sets the dapp's manifest hash and publishes it.
+func (m *Kord) deployDapp(d *dapp.Dapp, uploadID string) (common.Hash, error) {
+	f, err := m.uploads.take(uploadID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer removeDappUpload(f)
+	manifestHash, err := m.storeDappFiles(f)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	d.ManifestHash = manifestHash
+	return m.publishDapp(d)
+}
+
+// storeDappFiles stores the files in the tar stream using the local Swarm
+// node, or the Swarm gateway if the node has none, and returns the hash of
+// a manifest containing them.
+func (m *Kord) storeDappFiles(tarball io.Reader) (string, error) {
+	if m.kordSrv.swarm == nil {
+		client := swarmclient.NewClient(m.config.SwarmAPI)
+		return client.TarUpload("", swarmclient.UploaderFunc(func(upload swarmclient.UploadFn) error {
+			return walkDappTar(tarball, func(entry *swarmapi.ManifestEntry, r io.Reader) error {
+				return upload(&swarmclient.File{
+					ReadCloser:    ioutil.NopCloser(r),
+					ManifestEntry: *entry,
+				})
+			})
+		}))
+	}
+	swarm := m.kordSrv.swarm
+	key, err := swarm.NewManifest()
+	if err != nil {
+		return "", err
+	}
+	mw, err := swarm.NewManifestWriter(key, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := walkDappTar(tarball, func(entry *swarmapi.ManifestEntry, r io.Reader) error {
+		_, err := mw.AddEntry(r, entry)
+		return err
+	}); err != nil {
+		return "", err
+	}
+	key, err = mw.Store()
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
+// walkDappTar calls fn with a manifest entry for each regular file in the
+// tar stream, also calling it with an entry at the root of the manifest
+// for index.html so that it is served by default.
+func walkDappTar(tarball io.Reader, fn func(*swarmapi.ManifestEntry, io.Reader) error) error {
+	tr := tar.NewReader(tarball)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error reading dapp tar stream: %s", err)
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		entry := &swarmapi.ManifestEntry{
+			Path:        name,
+			ContentType: mime.TypeByExtension(path.Ext(name)),
+			Mode:        hdr.Mode,
+			Size:        hdr.Size,
+			ModTime:     hdr.ModTime,
+		}
+		var r io.Reader = tr
+		if name == "index.html" {
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("error reading dapp tar stream: %s", err)
+			}
+			root := *entry
+			root.Path = ""
+			if err := fn(&root, bytes.NewReader(data)); err != nil {
+				return err
+			}
+			r = bytes.NewReader(data)
+		}
+		if err := fn(entry, r); err != nil {
+			return err
+		}
+	}
+}
+
+// publishDapp stores a new version of the dapp in the graph of the KORD ID
+// in its URI, commits the graph and returns the hash which the KORD ID must
+// sign to publish it.
+func (m *Kord) publishDapp(d *dapp.Dapp) (common.Hash, error) {
+	u, err := uri.ParseWithResolver(string(d.ID), m.resolver)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	id := u.ID.Hex()
+	qs, err := m.driver.Get(id)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer m.driver.Release(id)
+	if err := dapp.Deploy(context.Background(), qs, d); err != nil {
+		return common.Hash{}, err
+	}
+	return m.commitDapp(id, d.ID)
+}
+
+// rollbackDapp makes the given version of the dapp the deployed version,
+// commits the graph and returns the hash which the KORD ID must sign to
+// publish it.
+func (m *Kord) rollbackDapp(dappURI, version string) (common.Hash, error) {
+	u, err := uri.ParseWithResolver(dappURI, m.resolver)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	id := u.ID.Hex()
+	qs, err := m.driver.Get(id)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer m.driver.Release(id)
+	if _, err := dapp.Rollback(context.Background(), qs, quad.IRI(dappURI), version); err != nil {
+		return common.Hash{}, err
+	}
+	return m.commitDapp(id, quad.IRI(dappURI))
+}
+
+// commitDapp commits the graph containing the dapp, reloading the root dapp
+// if it is the one which changed.
+func (m *Kord) commitDapp(id string, dappURI quad.IRI) (common.Hash, error) {
+	hash, err := m.driver.Commit(id)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if root := m.kordSrv.rootDapp(); root != nil && root.ID == dappURI {
+		if err := m.setRootDapp(string(dappURI)); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return hash, nil
+}
@@ -20,7 +20,11 @@
 package kord
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -30,27 +34,59 @@ import (
 	swarmhttp "github.com/ethereum/go-ethereum/swarm/api/http"
 	"github.com/kord-network/go-kord/api"
 	"github.com/kord-network/go-kord/dapp"
+	"github.com/kord-network/go-kord/metrics"
 )
 
+var dappRequests = metrics.NewCounterVec("kord_dapp_requests_total", "Number of requests for dapp files by HTTP status code.", "code")
+
 type Server struct {
 	mux *http.ServeMux
 
 	dapp    *dapp.Dapp
 	dappMtx sync.RWMutex
 
+	// swarm is the API of the local Swarm node, or nil if requests are
+	// proxied to a Swarm gateway
 	swarm *swarmapi.Api
+
+	gateway *httputil.ReverseProxy
 }
 
+// NewServer returns a server which serves Swarm requests and dapps using
+// the local Swarm node.
 func NewServer(api *api.API, swarm *swarmapi.Api) *Server {
+	s := newServer(api, swarmhttp.NewServer(swarm))
+	s.swarm = swarm
+	return s
+}
+
+// NewGatewayServer returns a server which proxies Swarm requests and dapp
+// requests to a Swarm HTTP gateway.
+func NewGatewayServer(api *api.API, gateway string) (*Server, error) {
+	u, err := url.Parse(gateway)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Swarm gateway URL: %s", err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Host = u.Host
+	}
+	s := newServer(api, proxy)
+	s.gateway = proxy
+	return s, nil
+}
+
+func newServer(api *api.API, swarmSrv http.Handler) *Server {
 	s := &Server{
-		mux:   http.NewServeMux(),
-		swarm: swarm,
+		mux: http.NewServeMux(),
 	}
-	swarmSrv := swarmhttp.NewServer(swarm)
 	s.mux.Handle("/bzz:/", swarmSrv)
 	s.mux.Handle("/bzzr:/", swarmSrv)
 	s.mux.Handle("/bzz-raw:/", swarmSrv)
 	s.mux.Handle("/api/graphql", api)
+	s.mux.Handle("/metrics", metrics.DefaultRegistry)
 	s.mux.HandleFunc("/", s.ServeDapp)
 	return s
 }
@@ -59,7 +95,14 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "OPTIONS" {
 		w.Header().Set("Allow", "OPTIONS, GET, HEAD, POST")
 		w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET, POST")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join([]string{
+			"Content-Type",
+			"Authorization",
+			api.HeaderKordID,
+			api.HeaderKordTimestamp,
+			api.HeaderKordNonce,
+			api.HeaderKordSignature,
+		}, ", "))
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -67,6 +110,12 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) ServeDapp(w http.ResponseWriter, r *http.Request) {
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	defer func() { dappRequests.With(strconv.Itoa(sw.status)).Inc(1) }()
+	s.serveDapp(sw, r)
+}
+
+func (s *Server) serveDapp(w http.ResponseWriter, r *http.Request) {
 	s.dappMtx.RLock()
 	dapp := s.dapp
 	s.dappMtx.RUnlock()
@@ -82,8 +131,13 @@ func (s *Server) ServeDapp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	key := common.Hex2Bytes(dapp.ManifestHash)
 	path := strings.TrimLeft(r.URL.Path, "/")
+	if s.swarm == nil {
+		s.serveGatewayDapp(w, r, dapp, path)
+		return
+	}
+
+	key := common.Hex2Bytes(dapp.ManifestHash)
 	reader, contentType, status, err := s.swarm.Get(key, path)
 	if err != nil {
 		switch status {
@@ -105,8 +159,37 @@ func (s *Server) ServeDapp(w http.ResponseWriter, r *http.Request) {
 	http.ServeContent(w, r, "", time.Now(), reader)
 }
 
+// serveGatewayDapp serves the file from the dapp's manifest by proxying the
+// request to the Swarm gateway.
+func (s *Server) serveGatewayDapp(w http.ResponseWriter, r *http.Request, dapp *dapp.Dapp, path string) {
+	req := new(http.Request)
+	*req = *r
+	u := *r.URL
+	u.Path = "/bzz:/" + dapp.ManifestHash + "/" + path
+	u.RawPath = ""
+	req.URL = &u
+	s.gateway.ServeHTTP(w, req)
+}
+
+func (s *Server) rootDapp() *dapp.Dapp {
+	s.dappMtx.RLock()
+	defer s.dappMtx.RUnlock()
+	return s.dapp
+}
+
 func (s *Server) setDapp(dapp *dapp.Dapp) {
 	s.dappMtx.Lock()
 	s.dapp = dapp
 	s.dappMtx.Unlock()
 }
+
+// statusWriter records the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,59 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package kord
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kord-network/go-kord/api"
+)
+
+// TestCORSPreflight tests that preflight requests allow the headers of
+// signed API requests.
+func TestCORSPreflight(t *testing.T) {
+	srv := newServer(nil, http.NotFoundHandler())
+	req := httptest.NewRequest("OPTIONS", "/api/graphql", nil)
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	allowed := make(map[string]bool)
+	for _, h := range strings.Split(w.Header().Get("Access-Control-Allow-Headers"), ",") {
+		allowed[http.CanonicalHeaderKey(strings.TrimSpace(h))] = true
+	}
+	for _, h := range []string{
+		"Content-Type",
+		"Authorization",
+		api.HeaderKordID,
+		api.HeaderKordTimestamp,
+		api.HeaderKordNonce,
+		api.HeaderKordSignature,
+	} {
+		if !allowed[http.CanonicalHeaderKey(h)] {
+			t.Fatalf("expected %s to be an allowed header, got %q", h, w.Header().Get("Access-Control-Allow-Headers"))
+		}
+	}
+}
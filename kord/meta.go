@@ -21,17 +21,22 @@ package kord
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/cayleygraph/cayley/graph/path"
 	"github.com/cayleygraph/cayley/quad"
-	"github.com/cayleygraph/cayley/schema"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -39,9 +44,13 @@ import (
 	"github.com/ethereum/go-ethereum/swarm"
 	"github.com/kord-network/go-kord/api"
 	"github.com/kord-network/go-kord/dapp"
+	"github.com/kord-network/go-kord/db"
 	"github.com/kord-network/go-kord/graph"
 	"github.com/kord-network/go-kord/pkg/uri"
 	"github.com/kord-network/go-kord/registry"
+	"github.com/kord-network/go-kord/registry/gossip"
+	"github.com/kord-network/go-kord/registry/offchain"
+	"github.com/kord-network/go-kord/store"
 	"github.com/rs/cors"
 )
 
@@ -50,46 +59,280 @@ type Config struct {
 	HTTPPort    int
 	RootDapp    string
 	CORSDomains []string
+
+	// NoHTTP disables the HTTP server, so that the node is only used
+	// through the JSON-RPC API
+	NoHTTP bool
+
+	// Registry is the registry backend, either RegistryContract or
+	// RegistryOffchain
+	Registry string
+
+	// RegistryAddr and ENSAddr are the addresses of the KORD registry
+	// contract and of the ENS registry containing KORD names, which
+	// default to the addresses the dev node deploys them at
+	RegistryAddr common.Address
+	ENSAddr      common.Address
+
+	// RegistryCache caches graph hashes read from the registry,
+	// invalidating them when the registry sends an update
+	RegistryCache bool
+
+	// RegistryProofs verifies graph hashes read from the registry
+	// contract using eth_getProof against block headers from
+	// TrustedHeaderRPC, or from the local Ethereum node if not set
+	RegistryProofs   bool
+	TrustedHeaderRPC string
+
+	// SchemaGraph is the ID of a graph containing RDF classes and
+	// properties from which GraphQL types are generated at startup
+	SchemaGraph string
+
+	// GraphQLLimits bounds the depth, complexity, execution time and
+	// request size of GraphQL queries
+	GraphQLLimits api.Limits
+
+	// APITokens are tokens which authenticate the node operator to the
+	// HTTP API, in addition to a token generated at startup which the
+	// kord command gets using the kord_apiToken RPC method
+	APITokens []string
+
+	// PinnedGraphs are graphs which are fetched at startup, kept up to
+	// date and never evicted, in addition to graphs pinned using
+	// kord_pinGraph
+	PinnedGraphs []string
+
+	// DiskQuota is the disk space in bytes which graph databases may use
+	// before the least recently used unpinned graphs are evicted, with
+	// zero meaning no limit
+	DiskQuota int64
+
+	// EthRPC is the URL of an Ethereum JSON-RPC endpoint used for the
+	// registry contract instead of the local Ethereum node
+	EthRPC string
+
+	// SwarmAPI is the URL of a Swarm HTTP gateway used to store graphs
+	// and serve dapps instead of the local Swarm node
+	SwarmAPI string
+
+	// ContentStore is where graph databases are stored, either
+	// ContentStoreSwarm, ContentStoreFS or ContentStoreS3, with graphs
+	// identified by their Swarm hash whichever store is used
+	ContentStore string
+
+	// ContentDir is the directory graph databases are stored in when
+	// using ContentStoreFS, defaulting to "content" in the data directory
+	ContentDir string
+
+	// S3 is the object store graph databases are stored in when using
+	// ContentStoreS3
+	S3 store.S3Config
+
+	// ReaderIDs are KORD IDs in the node's keystore whose keys are used
+	// to decrypt encrypted graphs listing the KORD ID as a reader
+	ReaderIDs []string
+
+	// ReaderPassphraseFile is a file containing the passphrase which
+	// unlocks the keys of the ReaderIDs
+	ReaderPassphraseFile string
 }
 
+const (
+	// RegistryContract stores graph hashes in the KORD registry contract
+	RegistryContract = "contract"
+
+	// RegistryOffchain stores signed graph records in a local database
+	// and gossips them between nodes
+	RegistryOffchain = "offchain"
+)
+
+const (
+	// ContentStoreSwarm stores graph databases in Swarm, either using the
+	// local Swarm node or the Swarm gateway if SwarmAPI is set
+	ContentStoreSwarm = "swarm"
+
+	// ContentStoreFS stores graph databases in a local directory
+	ContentStoreFS = "fs"
+
+	// ContentStoreS3 stores graph databases in an S3 compatible object
+	// store
+	ContentStoreS3 = "s3"
+)
+
 var DefaultConfig = Config{
-	HTTPAddr: "localhost",
-	HTTPPort: 5000,
+	HTTPAddr:     "localhost",
+	HTTPPort:     5000,
+	Registry:     RegistryContract,
+	RegistryAddr: registry.DevContractAddr,
+	ENSAddr:      registry.DevENSAddr,
+	ContentStore: ContentStoreSwarm,
+
+	GraphQLLimits: api.DefaultLimits,
+}
+
+// RegistryConfig returns the config used to connect to the KORD registry
+// and ENS contracts.
+func (c *Config) RegistryConfig() registry.Config {
+	config := registry.DefaultConfig
+	config.ContractAddr = c.RegistryAddr
+	config.ENSAddr = c.ENSAddr
+	return config
+}
+
+// newContentStore returns the store graph databases are stored in.
+func newContentStore(ctx *node.ServiceContext, cfg *Config, swarm *swarm.Swarm) (store.ContentStore, error) {
+	switch cfg.ContentStore {
+	case ContentStoreSwarm, "":
+		if swarm != nil {
+			return store.NewDPA(swarm.DPA()), nil
+		}
+		return store.NewHTTP(cfg.SwarmAPI), nil
+	case ContentStoreFS:
+		dir := cfg.ContentDir
+		if dir == "" {
+			dir = ctx.ResolvePath("content")
+		}
+		return store.NewFS(dir)
+	case ContentStoreS3:
+		if cfg.S3.Endpoint == "" || cfg.S3.Bucket == "" {
+			return nil, errors.New("S3 content store requires an endpoint and bucket")
+		}
+		return store.NewS3(cfg.S3), nil
+	default:
+		return nil, fmt.Errorf("unknown content store: %q", cfg.ContentStore)
+	}
+}
+
+// driverCount is the number of database drivers created by KORD services,
+// which is used to give each driver a unique name as SQL drivers are
+// registered globally.
+var driverCount uint64
+
+func driverName() string {
+	if n := atomic.AddUint64(&driverCount, 1); n > 1 {
+		return fmt.Sprintf("kord-%d", n)
+	}
+	return "kord"
 }
 
+// nameCacheTTL is how long resolved KORD names are cached for.
+const nameCacheTTL = time.Minute
+
 type Kord struct {
 	driver   *graph.Driver
 	registry registry.Registry
+	offchain *offchain.Registry
+	gossip   *gossip.Registry
+	cache    *registry.Cache
 	config   *Config
 	srv      *http.Server
 	kordSrv  *Server
+	api      *api.API
+	apiToken string
+	stack    *node.Node
+
+	// resolver resolves KORD names in URIs, and is nil if the registry
+	// backend does not support names
+	resolver uri.Resolver
+
+	// pins is the file of graphs pinned at runtime, and pinStatus is the
+	// result of fetching each pinned graph
+	pins      *pinFile
+	pinStatus map[string]error
+	pinMtx    sync.Mutex
+
+	// readers is the file of public keys of the readers of graphs which
+	// are encrypted when committed
+	readers *readersFile
+
+	// uploads are the dapp uploads which have not yet been deployed
+	uploads *dappUploads
+
+	fetchErrSub event.Subscription
 }
 
 func New(ctx *node.ServiceContext, stack *node.Node, cfg *Config) (*Kord, error) {
 	var swarm *swarm.Swarm
-	if err := ctx.Service(&swarm); err != nil {
-		return nil, fmt.Errorf("error getting Swarm service: %s", err)
+	if cfg.SwarmAPI == "" {
+		if err := ctx.Service(&swarm); err != nil {
+			return nil, fmt.Errorf("error getting Swarm service: %s", err)
+		}
 	}
 	dir := ctx.ResolvePath("db")
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, err
 	}
-	registry := &lazyRegistry{stack: stack}
-	driver := graph.NewDriver("kord", swarm.DPA(), registry, dir)
-	api, err := api.NewAPI(driver)
+	kord := &Kord{
+		config:    cfg,
+		stack:     stack,
+		pins:      newPinFile(ctx.ResolvePath("pinned.json")),
+		pinStatus: make(map[string]error),
+		readers:   newReadersFile(ctx.ResolvePath("readers.json")),
+		uploads:   newDappUploads(),
+	}
+	switch cfg.Registry {
+	case RegistryContract, "":
+		backend := &lazyRegistry{stack: stack, config: cfg}
+		kord.resolver = uri.NewCachingResolver(backend, nameCacheTTL)
+		kord.gossip = gossip.New(registry.NewMetrics(backend))
+		kord.registry = kord.gossip
+	case RegistryOffchain:
+		backend, err := offchain.New(ctx.ResolvePath("registry"))
+		if err != nil {
+			return nil, err
+		}
+		kord.registry = registry.NewMetrics(backend)
+		kord.offchain = backend
+	default:
+		return nil, fmt.Errorf("unknown registry backend: %q", cfg.Registry)
+	}
+	if cfg.RegistryCache {
+		kord.cache = registry.NewCache(kord.registry)
+		kord.registry = kord.cache
+	}
+	content, err := newContentStore(ctx, cfg, swarm)
+	if err != nil {
+		return nil, err
+	}
+	kord.driver = graph.NewDriver(driverName(), content, kord.registry, dir)
+	kord.driver.SetDiskQuota(cfg.DiskQuota)
+	if err := kord.loadReaders(); err != nil {
+		return nil, err
+	}
+	api, err := api.NewAPI(kord.driver)
 	if err != nil {
 		return nil, err
 	}
-	return &Kord{
-		driver:   driver,
-		registry: registry,
-		config:   cfg,
-		kordSrv:  NewServer(api, swarm.Api()),
-	}, nil
+	api.Limits = cfg.GraphQLLimits
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	kord.apiToken = hex.EncodeToString(token)
+	api.Auth.Tokens = append([]string{kord.apiToken}, cfg.APITokens...)
+	kord.api = api
+	if swarm != nil {
+		kord.kordSrv = NewServer(api, swarm.Api())
+	} else {
+		kord.kordSrv, err = NewGatewayServer(api, cfg.SwarmAPI)
+		if err != nil {
+			return nil, err
+		}
+	}
+	kord.kordSrv.mux.HandleFunc("/healthz", kord.serveHealth)
+	kord.kordSrv.mux.HandleFunc("/readyz", kord.serveReady)
+	return kord, nil
 }
 
 func (m *Kord) Protocols() []p2p.Protocol {
-	return nil
+	switch {
+	case m.offchain != nil:
+		return []p2p.Protocol{m.offchain.Protocol()}
+	case m.gossip != nil:
+		return []p2p.Protocol{m.gossip.Protocol()}
+	default:
+		return nil
+	}
 }
 
 func (m *Kord) APIs() []rpc.API {
@@ -104,12 +347,40 @@ func (m *Kord) APIs() []rpc.API {
 }
 
 func (m *Kord) Start(_ *p2p.Server) error {
+	fetchErrs := make(chan *db.FetchError)
+	m.fetchErrSub = m.driver.SubscribeFetchErrors(fetchErrs)
+	go func() {
+		for {
+			select {
+			case fetchErr := <-fetchErrs:
+				log.Error("error fetching graph, using previous version", "id", fetchErr.Name, "hash", fetchErr.Hash, "err", fetchErr.Err)
+			case <-m.fetchErrSub.Err():
+				return
+			}
+		}
+	}()
+
 	if m.config.RootDapp != "" {
 		if err := m.setRootDapp(m.config.RootDapp); err != nil {
 			return err
 		}
 	}
 
+	if m.config.SchemaGraph != "" {
+		if err := m.loadRDFSchema(m.config.SchemaGraph); err != nil {
+			return err
+		}
+	}
+
+	if err := m.pinGraphs(); err != nil {
+		return err
+	}
+
+	if m.config.NoHTTP {
+		log.Info("KORD HTTP server disabled")
+		return nil
+	}
+
 	addr := fmt.Sprintf("%s:%d", m.config.HTTPAddr, m.config.HTTPPort)
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
@@ -141,6 +412,16 @@ func (m *Kord) Start(_ *p2p.Server) error {
 }
 
 func (m *Kord) Stop() error {
+	m.uploads.close()
+	if m.fetchErrSub != nil {
+		m.fetchErrSub.Unsubscribe()
+	}
+	if m.offchain != nil {
+		defer m.offchain.Close()
+	}
+	if m.cache != nil {
+		defer m.cache.Close()
+	}
 	if m.srv != nil {
 		log.Info("stopping KORD HTTP server")
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -150,8 +431,29 @@ func (m *Kord) Stop() error {
 	return nil
 }
 
+func (m *Kord) names() (registry.NameRegistry, error) {
+	// unwrap the cache, gossip and metrics registries to get the registry
+	// backend
+	reg := m.registry
+	for {
+		if names, ok := reg.(registry.NameRegistry); ok {
+			return names, nil
+		}
+		switch r := reg.(type) {
+		case *registry.Cache:
+			reg = r.Registry
+		case *gossip.Registry:
+			reg = r.Registry
+		case *registry.Metrics:
+			reg = r.Registry
+		default:
+			return nil, errors.New("registry does not support KORD names")
+		}
+	}
+}
+
 func (m *Kord) setRootDapp(dappURI string) error {
-	u, err := uri.Parse(dappURI)
+	u, err := uri.ParseWithResolver(dappURI, m.resolver)
 	if err != nil {
 		return err
 	}
@@ -159,20 +461,50 @@ func (m *Kord) setRootDapp(dappURI string) error {
 	if err != nil {
 		return err
 	}
-	var dapp dapp.Dapp
-	path := path.StartPathNodes(qs, qs.ValueOf(quad.IRI(dappURI)))
-	if err := schema.LoadPathTo(context.Background(), qs, &dapp, path); err != nil {
+	defer m.driver.Release(u.ID.Hex())
+	d, err := dapp.Load(context.Background(), qs, quad.IRI(dappURI))
+	if err != nil {
+		return err
+	}
+	m.kordSrv.setDapp(d)
+	return nil
+}
+
+// loadRDFSchema generates GraphQL types from the RDF classes and properties
+// in the given graph and serves them from the GraphQL API.
+func (m *Kord) loadRDFSchema(id string) error {
+	qs, err := m.driver.Get(id)
+	if err != nil {
 		return err
 	}
-	m.kordSrv.setDapp(&dapp)
+	defer m.driver.Release(id)
+	s, err := api.LoadRDFSchema(context.Background(), qs)
+	if err != nil {
+		return fmt.Errorf("error loading schema graph %s: %s", id, err)
+	}
+	if err := m.api.SetRDFSchema(s); err != nil {
+		return fmt.Errorf("error serving schema graph %s: %s", id, err)
+	}
+	log.Info("serving GraphQL types generated from schema graph", "id", id, "types", len(s.Types))
 	return nil
 }
 
+// dialEthereum connects to the Ethereum JSON-RPC endpoint in the config, or
+// to the local Ethereum node if there is none.
+func dialEthereum(stack *node.Node, cfg *Config) (*rpc.Client, error) {
+	if cfg.EthRPC != "" {
+		return rpc.Dial(cfg.EthRPC)
+	}
+	return stack.Attach()
+}
+
 type lazyRegistry struct {
 	registry.Registry
 
-	mtx   sync.Mutex
-	stack *node.Node
+	mtx    sync.Mutex
+	stack  *node.Node
+	config *Config
+	client *registry.Client
 }
 
 func (r *lazyRegistry) registry() (registry.Registry, error) {
@@ -181,16 +513,35 @@ func (r *lazyRegistry) registry() (registry.Registry, error) {
 	if r.Registry != nil {
 		return r.Registry, nil
 	}
-	client, err := r.stack.Attach()
+	rpcClient, err := dialEthereum(r.stack, r.config)
 	if err != nil {
 		return nil, err
 	}
-	registry, err := registry.NewClient(client, registry.DefaultConfig)
+	client, err := registry.NewClient(rpcClient, r.config.RegistryConfig())
 	if err != nil {
 		return nil, err
 	}
-	r.Registry = registry
-	return registry, nil
+	r.client = client
+	r.Registry = client
+	if r.config.RegistryProofs {
+		headers := client.Client
+		if r.config.TrustedHeaderRPC != "" {
+			trusted, err := ethclient.Dial(r.config.TrustedHeaderRPC)
+			if err != nil {
+				return nil, err
+			}
+			headers = trusted
+		}
+		r.Registry = registry.NewProofVerifier(
+			client,
+			rpcClient,
+			r.config.RegistryAddr,
+			func(ctx context.Context) (*types.Header, error) {
+				return headers.HeaderByNumber(ctx, nil)
+			},
+		)
+	}
+	return r.Registry, nil
 }
 
 func (r *lazyRegistry) Graph(kordID common.Address) (common.Hash, error) {
@@ -209,6 +560,15 @@ func (r *lazyRegistry) SetGraph(graph common.Hash, sig []byte) error {
 	return registry.SetGraph(graph, sig)
 }
 
+// SetRecord sets the record using the registry client, since the proof
+// verifier only verifies reads.
+func (r *lazyRegistry) SetRecord(record *registry.Record) error {
+	if _, err := r.registry(); err != nil {
+		return err
+	}
+	return r.client.SetRecord(record)
+}
+
 func (r *lazyRegistry) SubscribeGraph(kordID common.Address, updates chan common.Hash) (registry.Subscription, error) {
 	registry, err := r.registry()
 	if err != nil {
@@ -216,3 +576,26 @@ func (r *lazyRegistry) SubscribeGraph(kordID common.Address, updates chan common
 	}
 	return registry.SubscribeGraph(kordID, updates)
 }
+
+func (r *lazyRegistry) ResolveName(name string) (common.Address, error) {
+	names, err := r.names()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return names.ResolveName(name)
+}
+
+func (r *lazyRegistry) RegisterName(name string, kordID common.Address, sig []byte) error {
+	names, err := r.names()
+	if err != nil {
+		return err
+	}
+	return names.RegisterName(name, kordID, sig)
+}
+
+func (r *lazyRegistry) names() (registry.NameRegistry, error) {
+	if _, err := r.registry(); err != nil {
+		return nil, err
+	}
+	return r.client, nil
+}
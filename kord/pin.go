@@ -0,0 +1,168 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package kord
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// pinGraphs pins the graphs from the config and the pin file, fetching them
+// in the background so that startup is not delayed by slow fetches.
+func (m *Kord) pinGraphs() error {
+	pinned, err := m.pins.load()
+	if err != nil {
+		return err
+	}
+	names := append(pinned, m.config.PinnedGraphs...)
+	for i, name := range names {
+		if !common.IsHexAddress(name) {
+			return fmt.Errorf("invalid pinned graph, must be a KORD ID: %s", name)
+		}
+		names[i] = common.HexToAddress(name).Hex()
+	}
+	for _, name := range names {
+		m.setPinStatus(name, errPinPending)
+	}
+	go func() {
+		for _, name := range names {
+			log.Info("fetching pinned graph", "id", name)
+			err := m.driver.Pin(name)
+			if err != nil {
+				log.Error("error fetching pinned graph", "id", name, "err", err)
+			}
+			m.setPinStatus(name, err)
+		}
+	}()
+	return nil
+}
+
+// setPinStatus records the result of fetching a pinned graph, which is
+// reported by the readiness checks.
+func (m *Kord) setPinStatus(name string, err error) {
+	m.pinMtx.Lock()
+	defer m.pinMtx.Unlock()
+	m.pinStatus[name] = err
+}
+
+// pinGraph pins the graph and records it in the pin file so that it remains
+// pinned when the node restarts.
+func (m *Kord) pinGraph(name string) error {
+	if !common.IsHexAddress(name) {
+		return fmt.Errorf("invalid KORD ID, must be a hex string: %s", name)
+	}
+	name = common.HexToAddress(name).Hex()
+	if err := m.authorizeRPCRead(name); err != nil {
+		return err
+	}
+	if err := m.driver.Pin(name); err != nil {
+		return err
+	}
+	m.setPinStatus(name, nil)
+	return m.pins.add(name)
+}
+
+// unpinGraph unpins the graph and removes it from the pin file, though
+// graphs pinned in the config are pinned again when the node restarts.
+func (m *Kord) unpinGraph(name string) error {
+	if !common.IsHexAddress(name) {
+		return fmt.Errorf("invalid KORD ID, must be a hex string: %s", name)
+	}
+	name = common.HexToAddress(name).Hex()
+	m.driver.Unpin(name)
+	m.pinMtx.Lock()
+	delete(m.pinStatus, name)
+	m.pinMtx.Unlock()
+	return m.pins.remove(name)
+}
+
+// pinFile is a JSON file which stores the names of graphs pinned at
+// runtime.
+type pinFile struct {
+	path string
+	mtx  sync.Mutex
+}
+
+func newPinFile(path string) *pinFile {
+	return &pinFile{path: path}
+}
+
+func (f *pinFile) load() ([]string, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.read()
+}
+
+func (f *pinFile) add(name string) error {
+	return f.update(func(names map[string]struct{}) {
+		names[name] = struct{}{}
+	})
+}
+
+func (f *pinFile) remove(name string) error {
+	return f.update(func(names map[string]struct{}) {
+		delete(names, name)
+	})
+}
+
+func (f *pinFile) update(fn func(map[string]struct{})) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	list, err := f.read()
+	if err != nil {
+		return err
+	}
+	names := make(map[string]struct{}, len(list))
+	for _, name := range list {
+		names[name] = struct{}{}
+	}
+	fn(names)
+	list = make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.path, data, 0644)
+}
+
+func (f *pinFile) read() ([]string, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("error reading pinned graphs from %s: %s", f.path, err)
+	}
+	return names, nil
+}
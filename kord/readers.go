@@ -0,0 +1,210 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package kord
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/api"
+)
+
+// loadReaders loads the reader keys from the config, which are used to
+// decrypt encrypted graphs, and the readers of graphs which this node
+// encrypts when they are committed.
+func (m *Kord) loadReaders() error {
+	if err := m.loadReaderKeys(); err != nil {
+		return err
+	}
+	readers, err := m.readers.load()
+	if err != nil {
+		return err
+	}
+	for name, hexKeys := range readers {
+		keys, err := parsePublicKeys(hexKeys)
+		if err != nil {
+			return fmt.Errorf("error loading readers of %s: %s", name, err)
+		}
+		m.driver.SetReaders(name, keys)
+	}
+	return nil
+}
+
+// loadReaderKeys decrypts the keys of the configured reader KORD IDs from
+// the node's keystore using the configured passphrase.
+func (m *Kord) loadReaderKeys() error {
+	if len(m.config.ReaderIDs) == 0 {
+		return nil
+	}
+	var passphrase string
+	if m.config.ReaderPassphraseFile != "" {
+		data, err := ioutil.ReadFile(m.config.ReaderPassphraseFile)
+		if err != nil {
+			return fmt.Errorf("error reading reader passphrase: %s", err)
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	}
+	ks := m.stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	for _, id := range m.config.ReaderIDs {
+		if !common.IsHexAddress(id) {
+			return fmt.Errorf("invalid reader KORD ID, must be a hex string: %s", id)
+		}
+		account, err := ks.Find(accounts.Account{Address: common.HexToAddress(id)})
+		if err != nil {
+			return fmt.Errorf("error loading reader key for %s: %s", id, err)
+		}
+		keyjson, err := ioutil.ReadFile(account.URL.Path)
+		if err != nil {
+			return fmt.Errorf("error loading reader key for %s: %s", id, err)
+		}
+		key, err := keystore.DecryptKey(keyjson, passphrase)
+		if err != nil {
+			return fmt.Errorf("error decrypting reader key for %s: %s", id, err)
+		}
+		m.driver.AddReaderKey(key.PrivateKey)
+	}
+	return nil
+}
+
+// setGraphReaders sets the public keys of the readers of the graph, which
+// is encrypted to them when it is next committed, and records them in the
+// readers file so that they are used when the node restarts.
+func (m *Kord) setGraphReaders(name string, hexKeys []string) error {
+	if !common.IsHexAddress(name) {
+		return fmt.Errorf("invalid KORD ID, must be a hex string: %s", name)
+	}
+	name = common.HexToAddress(name).Hex()
+	keys, err := parsePublicKeys(hexKeys)
+	if err != nil {
+		return err
+	}
+	if err := m.readers.set(name, hexKeys); err != nil {
+		return err
+	}
+	m.driver.SetReaders(name, keys)
+	return nil
+}
+
+// graphReaders returns the hex encoded public keys of the readers of the
+// graph.
+func (m *Kord) graphReaders(name string) ([]string, error) {
+	if !common.IsHexAddress(name) {
+		return nil, fmt.Errorf("invalid KORD ID, must be a hex string: %s", name)
+	}
+	name = common.HexToAddress(name).Hex()
+	if err := m.authorizeRPCRead(name); err != nil {
+		return nil, err
+	}
+	keys := m.driver.Readers(name)
+	hexKeys := make([]string, len(keys))
+	for i, key := range keys {
+		hexKeys[i] = hexutil.Encode(crypto.FromECDSAPub(key))
+	}
+	return hexKeys, nil
+}
+
+// rpcPrincipal is the principal of JSON-RPC requests, which are sent by the
+// node operator since the kord RPC API hands out the operator's API token
+// and so is only served over IPC.
+var rpcPrincipal = &api.Principal{Operator: true}
+
+// authorizeRPCRead returns an error unless the JSON-RPC caller is allowed
+// to read the graph by its read policy.
+func (m *Kord) authorizeRPCRead(name string) error {
+	return api.AuthorizeRead(context.Background(), m.driver, name, rpcPrincipal)
+}
+
+// parsePublicKeys parses hex encoded uncompressed secp256k1 public keys.
+func parsePublicKeys(hexKeys []string) ([]*ecdsa.PublicKey, error) {
+	keys := make([]*ecdsa.PublicKey, len(hexKeys))
+	for i, hexKey := range hexKeys {
+		data, err := hexutil.Decode(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %q: %s", hexKey, err)
+		}
+		key := crypto.ToECDSAPub(data)
+		if key == nil || key.X == nil {
+			return nil, fmt.Errorf("invalid public key %q", hexKey)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// readersFile is a JSON file which stores the hex encoded public keys of
+// the readers of encrypted graphs.
+type readersFile struct {
+	path string
+	mtx  sync.Mutex
+}
+
+func newReadersFile(path string) *readersFile {
+	return &readersFile{path: path}
+}
+
+func (f *readersFile) load() (map[string][]string, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.read()
+}
+
+func (f *readersFile) set(name string, hexKeys []string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	readers, err := f.read()
+	if err != nil {
+		return err
+	}
+	if len(hexKeys) == 0 {
+		delete(readers, name)
+	} else {
+		readers[name] = hexKeys
+	}
+	data, err := json.MarshalIndent(readers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.path, data, 0644)
+}
+
+func (f *readersFile) read() (map[string][]string, error) {
+	readers := make(map[string][]string)
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return readers, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &readers); err != nil {
+		return nil, fmt.Errorf("error reading graph readers from %s: %s", f.path, err)
+	}
+	return readers, nil
+}
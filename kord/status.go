@@ -0,0 +1,211 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package kord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/swarm"
+)
+
+// statusTimeout is how long the readiness checks are given to complete
+// when requested over HTTP.
+const statusTimeout = 5 * time.Second
+
+// errPinPending is the status of a pinned graph which is still being
+// fetched.
+var errPinPending = errors.New("fetch in progress")
+
+// Status reports whether the node is ready to serve requests along with
+// the result of each readiness check.
+type Status struct {
+	Ready  bool           `json:"ready"`
+	Checks []*StatusCheck `json:"checks"`
+}
+
+// StatusCheck is the result of a readiness check, with Error set if the
+// check failed.
+type StatusCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type statusCheck struct {
+	name  string
+	check func(context.Context) error
+}
+
+// status runs the readiness checks, which check that Swarm is running, the
+// pinned graphs have been fetched and, when using the registry contract,
+// that Ethereum is synced and the contract is reachable.
+func (m *Kord) status(ctx context.Context) *Status {
+	checks := []statusCheck{
+		{"swarm", m.checkSwarm},
+		{"pinned graphs", m.checkPins},
+	}
+	if m.gossip != nil {
+		checks = append(checks,
+			statusCheck{"ethereum", m.checkEthereum},
+			statusCheck{"registry", m.checkRegistry},
+		)
+	}
+	status := &Status{Ready: true}
+	for _, c := range checks {
+		result := &StatusCheck{Name: c.name, OK: true}
+		if err := c.check(ctx); err != nil {
+			result.OK = false
+			result.Error = err.Error()
+			status.Ready = false
+		}
+		status.Checks = append(status.Checks, result)
+	}
+	return status
+}
+
+// checkSwarm checks that the Swarm service is running and that its DPA
+// stores content, or that the Swarm gateway is reachable.
+func (m *Kord) checkSwarm(ctx context.Context) error {
+	if m.config.SwarmAPI != "" {
+		req, err := http.NewRequest("GET", m.config.SwarmAPI, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected gateway status: %s", res.Status)
+		}
+		return nil
+	}
+	var swarm *swarm.Swarm
+	if err := m.stack.Service(&swarm); err != nil {
+		return fmt.Errorf("swarm service not running: %s", err)
+	}
+	dpa := swarm.DPA()
+	if dpa == nil {
+		return errors.New("swarm DPA not running")
+	}
+
+	// store a small probe, which only completes if the DPA's store
+	// workers are running
+	errc := make(chan error, 1)
+	go func() {
+		swg := &sync.WaitGroup{}
+		_, err := dpa.Store(bytes.NewReader(swarmProbe), int64(len(swarmProbe)), swg, nil)
+		swg.Wait()
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		if err != nil {
+			return fmt.Errorf("swarm DPA error: %s", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return errors.New("swarm DPA not responding")
+	}
+}
+
+// swarmProbe is the content stored to check the Swarm DPA is running,
+// which is always the same so that it is only stored once.
+var swarmProbe = []byte("kord readiness check")
+
+func (m *Kord) checkPins(ctx context.Context) error {
+	m.pinMtx.Lock()
+	defer m.pinMtx.Unlock()
+	var errs []string
+	for name, err := range m.pinStatus {
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (m *Kord) checkEthereum(ctx context.Context) error {
+	rpcClient, err := dialEthereum(m.stack, m.config)
+	if err != nil {
+		return err
+	}
+	defer rpcClient.Close()
+	client := ethclient.NewClient(rpcClient)
+	progress, err := client.SyncProgress(ctx)
+	if err != nil {
+		return err
+	}
+	if progress != nil {
+		return fmt.Errorf("syncing, at block %d of %d", progress.CurrentBlock, progress.HighestBlock)
+	}
+	return nil
+}
+
+func (m *Kord) checkRegistry(ctx context.Context) error {
+	rpcClient, err := dialEthereum(m.stack, m.config)
+	if err != nil {
+		return err
+	}
+	defer rpcClient.Close()
+	client := ethclient.NewClient(rpcClient)
+	addr := m.config.RegistryAddr
+	code, err := client.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return err
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no contract code at %s", addr.Hex())
+	}
+	return nil
+}
+
+// serveHealth responds with 200 OK while the HTTP server is alive.
+func (m *Kord) serveHealth(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+// serveReady responds with the node status, using 503 Service Unavailable
+// if the node is not ready.
+func (m *Kord) serveReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), statusTimeout)
+	defer cancel()
+	status := m.status(ctx)
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
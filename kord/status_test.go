@@ -0,0 +1,56 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package kord
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// TestStatusSwarmDisabled tests that the node is not ready if the Swarm
+// service is not running.
+func TestStatusSwarmDisabled(t *testing.T) {
+	stack, err := node.New(&node.Config{P2P: p2p.Config{NoDiscovery: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer stack.Stop()
+
+	m := &Kord{stack: stack, config: &Config{}}
+	status := m.status(context.Background())
+	if status.Ready {
+		t.Fatal("expected node without Swarm not to be ready")
+	}
+	for _, check := range status.Checks {
+		if check.Name == "swarm" {
+			if check.OK {
+				t.Fatal("expected swarm check to fail")
+			}
+			return
+		}
+	}
+	t.Fatal("missing swarm check")
+}
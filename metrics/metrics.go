@@ -0,0 +1,378 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+// Package metrics implements counters, gauges and timers which are exported
+// in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// DefaultRegistry is the registry which metrics created using the package
+// level functions are registered with.
+var DefaultRegistry = NewRegistry()
+
+// Counter is a metric which only increases.
+type Counter struct {
+	count int64
+}
+
+// Inc increments the counter by n.
+func (c *Counter) Inc(n int64) {
+	atomic.AddInt64(&c.count, n)
+}
+
+// Count returns the current count.
+func (c *Counter) Count() int64 {
+	return atomic.LoadInt64(&c.count)
+}
+
+func (c *Counter) write(w io.Writer, name, labels string) {
+	fmt.Fprintf(w, "%s%s %d\n", name, wrapLabels(labels), c.Count())
+}
+
+// Gauge is a metric which can increase and decrease.
+type Gauge struct {
+	value int64
+}
+
+// Update sets the gauge to v.
+func (g *Gauge) Update(v int64) {
+	atomic.StoreInt64(&g.value, v)
+}
+
+// Inc adjusts the gauge by n, which may be negative.
+func (g *Gauge) Inc(n int64) {
+	atomic.AddInt64(&g.value, n)
+}
+
+// Value returns the current value.
+func (g *Gauge) Value() int64 {
+	return atomic.LoadInt64(&g.value)
+}
+
+func (g *Gauge) write(w io.Writer, name, labels string) {
+	fmt.Fprintf(w, "%s%s %d\n", name, wrapLabels(labels), g.Value())
+}
+
+// timerQuantiles are the quantiles which timers are exported with.
+var timerQuantiles = []float64{0.5, 0.9, 0.99}
+
+// Timer is a metric which records the count, total and distribution of
+// durations, and is exported as a Prometheus summary in seconds.
+type Timer struct {
+	count  int64
+	sum    int64
+	sample gometrics.Sample
+}
+
+func newTimer() *Timer {
+	return &Timer{sample: gometrics.NewExpDecaySample(1028, 0.015)}
+}
+
+// Update records a duration.
+func (t *Timer) Update(d time.Duration) {
+	atomic.AddInt64(&t.count, 1)
+	atomic.AddInt64(&t.sum, int64(d))
+	t.sample.Update(int64(d))
+}
+
+// UpdateSince records the duration since start.
+func (t *Timer) UpdateSince(start time.Time) {
+	t.Update(time.Since(start))
+}
+
+// Count returns the number of recorded durations.
+func (t *Timer) Count() int64 {
+	return atomic.LoadInt64(&t.count)
+}
+
+func (t *Timer) write(w io.Writer, name, labels string) {
+	for i, v := range t.sample.Percentiles(timerQuantiles) {
+		quantile := fmt.Sprintf(`quantile="%g"`, timerQuantiles[i])
+		if labels != "" {
+			quantile = labels + "," + quantile
+		}
+		fmt.Fprintf(w, "%s{%s} %g\n", name, quantile, seconds(int64(v)))
+	}
+	fmt.Fprintf(w, "%s_sum%s %g\n", name, wrapLabels(labels), seconds(atomic.LoadInt64(&t.sum)))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, wrapLabels(labels), t.Count())
+}
+
+func seconds(ns int64) float64 {
+	return time.Duration(ns).Seconds()
+}
+
+// CounterVec is a set of counters partitioned by label values.
+type CounterVec struct {
+	f *family
+}
+
+// With returns the counter with the given label values.
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.f.with(values).(*Counter)
+}
+
+// Delete deletes the counter with the given label values.
+func (v *CounterVec) Delete(values ...string) {
+	v.f.delete(values)
+}
+
+// GaugeVec is a set of gauges partitioned by label values.
+type GaugeVec struct {
+	f *family
+}
+
+// With returns the gauge with the given label values.
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.f.with(values).(*Gauge)
+}
+
+// Delete deletes the gauge with the given label values.
+func (v *GaugeVec) Delete(values ...string) {
+	v.f.delete(values)
+}
+
+// TimerVec is a set of timers partitioned by label values.
+type TimerVec struct {
+	f *family
+}
+
+// With returns the timer with the given label values.
+func (v *TimerVec) With(values ...string) *Timer {
+	return v.f.with(values).(*Timer)
+}
+
+// Delete deletes the timer with the given label values.
+func (v *TimerVec) Delete(values ...string) {
+	v.f.delete(values)
+}
+
+// Registry is a set of named metric families.
+type Registry struct {
+	mtx      sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// NewCounter registers and returns a counter.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+// NewCounterVec registers and returns a set of counters partitioned by the
+// given labels.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, "counter", labels, func() metric { return &Counter{} })}
+}
+
+// NewGauge registers and returns a gauge.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).With()
+}
+
+// NewGaugeVec registers and returns a set of gauges partitioned by the
+// given labels.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, "gauge", labels, func() metric { return &Gauge{} })}
+}
+
+// NewTimer registers and returns a timer.
+func (r *Registry) NewTimer(name, help string) *Timer {
+	return r.NewTimerVec(name, help).With()
+}
+
+// NewTimerVec registers and returns a set of timers partitioned by the
+// given labels.
+func (r *Registry) NewTimerVec(name, help string, labels ...string) *TimerVec {
+	return &TimerVec{r.register(name, help, "summary", labels, func() metric { return newTimer() })}
+}
+
+func (r *Registry) register(name, help, typ string, labels []string, newMetric func() metric) *family {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.families[name]; ok {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	f := &family{
+		name:      name,
+		help:      help,
+		typ:       typ,
+		labels:    labels,
+		newMetric: newMetric,
+		metrics:   make(map[string]metric),
+	}
+	r.families[name] = f
+	return f
+}
+
+// WritePrometheus writes the metrics in the Prometheus text format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mtx.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]*family, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		families[i] = r.families[name]
+	}
+	r.mtx.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WritePrometheus(w)
+}
+
+// NewCounter registers and returns a counter with the DefaultRegistry.
+func NewCounter(name, help string) *Counter {
+	return DefaultRegistry.NewCounter(name, help)
+}
+
+// NewCounterVec registers and returns a set of counters with the
+// DefaultRegistry.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labels...)
+}
+
+// NewGauge registers and returns a gauge with the DefaultRegistry.
+func NewGauge(name, help string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help)
+}
+
+// NewGaugeVec registers and returns a set of gauges with the
+// DefaultRegistry.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return DefaultRegistry.NewGaugeVec(name, help, labels...)
+}
+
+// NewTimer registers and returns a timer with the DefaultRegistry.
+func NewTimer(name, help string) *Timer {
+	return DefaultRegistry.NewTimer(name, help)
+}
+
+// NewTimerVec registers and returns a set of timers with the
+// DefaultRegistry.
+func NewTimerVec(name, help string, labels ...string) *TimerVec {
+	return DefaultRegistry.NewTimerVec(name, help, labels...)
+}
+
+type metric interface {
+	write(w io.Writer, name, labels string)
+}
+
+// family is a named set of metrics of the same type, partitioned by label
+// values.
+type family struct {
+	name      string
+	help      string
+	typ       string
+	labels    []string
+	newMetric func() metric
+
+	mtx     sync.Mutex
+	metrics map[string]metric
+}
+
+func (f *family) with(values []string) metric {
+	key := f.key(values)
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	m, ok := f.metrics[key]
+	if !ok {
+		m = f.newMetric()
+		f.metrics[key] = m
+	}
+	return m
+}
+
+func (f *family) delete(values []string) {
+	key := f.key(values)
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	delete(f.metrics, key)
+}
+
+// key returns the formatted labels for the given label values, which is
+// used both to identify the metric and when writing it.
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	pairs := make([]string, len(values))
+	for i, v := range values {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, f.labels[i], labelEscaper.Replace(v))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f *family) write(w io.Writer) {
+	f.mtx.Lock()
+	keys := make([]string, 0, len(f.metrics))
+	for key := range f.metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	metrics := make([]metric, len(keys))
+	for i, key := range keys {
+		metrics[i] = f.metrics[key]
+	}
+	f.mtx.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+	for i, m := range metrics {
+		m.write(w, f.name, keys[i])
+	}
+}
+
+// labelEscaper escapes label values as required by the Prometheus text
+// format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
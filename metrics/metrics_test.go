@@ -0,0 +1,72 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "A counter.").Inc(3)
+	gauges := r.NewGaugeVec("test_gauge", "A gauge.", "name")
+	gauges.With("b").Update(2)
+	gauges.With(`a"\`).Update(1)
+	gauges.With("c").Update(3)
+	gauges.Delete("c")
+	timer := r.NewTimerVec("test_seconds", "A timer.", "op").With("x")
+	timer.Update(time.Second)
+	timer.Update(3 * time.Second)
+
+	var buf bytes.Buffer
+	if err := r.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+# HELP test_gauge A gauge.
+# TYPE test_gauge gauge
+test_gauge{name="a\"\\"} 1
+test_gauge{name="b"} 2
+# HELP test_seconds A timer.
+# TYPE test_seconds summary
+test_seconds{op="x",quantile="0.5"} 2
+test_seconds{op="x",quantile="0.9"} 3
+test_seconds{op="x",quantile="0.99"} 3
+test_seconds_sum{op="x"} 4
+test_seconds_count{op="x"} 2
+# HELP test_total A counter.
+# TYPE test_total counter
+test_total 3
+`[1:]
+	if buf.String() != expected {
+		t.Fatalf("unexpected output:\nexpected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	// check registering a duplicate metric panics
+	defer func() {
+		if err := recover(); err == nil || !strings.Contains(err.(string), "duplicate") {
+			t.Fatalf("expected duplicate metric panic, got %v", err)
+		}
+	}()
+	r.NewCounter("test_total", "A duplicate counter.")
+}
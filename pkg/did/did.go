@@ -0,0 +1,129 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package did
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kord-network/go-kord/registry"
+)
+
+// Prefix is the prefix of KORD DIDs, which have the form
+// "did:kord:<address>".
+const Prefix = "did:kord:"
+
+// Context is the JSON-LD context of DID documents.
+const Context = "https://www.w3.org/ns/did/v1"
+
+// New returns the DID of the given KORD ID.
+func New(kordID common.Address) string {
+	return Prefix + kordID.Hex()
+}
+
+// Parse parses a KORD DID, returning the KORD ID.
+func Parse(did string) (common.Address, error) {
+	if !strings.HasPrefix(did, Prefix) {
+		return common.Address{}, fmt.Errorf("invalid KORD DID: %s", did)
+	}
+	id := strings.TrimPrefix(did, Prefix)
+	if !common.IsHexAddress(id) {
+		return common.Address{}, fmt.Errorf("invalid KORD ID in DID: %s", did)
+	}
+	return common.HexToAddress(id), nil
+}
+
+// Document is a DID document describing a KORD ID.
+type Document struct {
+	Context            string                `json:"@context"`
+	ID                 string                `json:"id"`
+	VerificationMethod []*VerificationMethod `json:"verificationMethod"`
+	Authentication     []string              `json:"authentication"`
+	AssertionMethod    []string              `json:"assertionMethod"`
+	Service            []*Service            `json:"service,omitempty"`
+}
+
+// VerificationMethod is a key which can be used to verify signatures made by
+// the DID subject, which for KORD IDs is the secp256k1 key whose Ethereum
+// address is the KORD ID.
+type VerificationMethod struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	Controller      string `json:"controller"`
+	EthereumAddress string `json:"ethereumAddress"`
+}
+
+// Service is a service endpoint of the DID subject, which for KORD IDs is
+// the KORD graph registered for the ID.
+type Service struct {
+	ID              string      `json:"id"`
+	Type            string      `json:"type"`
+	ServiceEndpoint string      `json:"serviceEndpoint"`
+	GraphHash       common.Hash `json:"graphHash"`
+}
+
+// VerificationMethodID returns the ID of the verification method of the
+// given KORD ID.
+func VerificationMethodID(kordID common.Address) string {
+	return New(kordID) + "#controller"
+}
+
+// NewDocument returns the DID document of the KORD ID with the given graph
+// hash, which is omitted if empty.
+func NewDocument(kordID common.Address, graphHash common.Hash) *Document {
+	id := New(kordID)
+	methodID := VerificationMethodID(kordID)
+	doc := &Document{
+		Context: Context,
+		ID:      id,
+		VerificationMethod: []*VerificationMethod{{
+			ID:              methodID,
+			Type:            "EcdsaSecp256k1RecoveryMethod2020",
+			Controller:      id,
+			EthereumAddress: kordID.Hex(),
+		}},
+		Authentication:  []string{methodID},
+		AssertionMethod: []string{methodID},
+	}
+	if !common.EmptyHash(graphHash) {
+		doc.Service = []*Service{{
+			ID:              id + "#graph",
+			Type:            "KordGraph",
+			ServiceEndpoint: "kord://" + kordID.Hex(),
+			GraphHash:       graphHash,
+		}}
+	}
+	return doc
+}
+
+// Resolve resolves a KORD DID to a DID document using the registry to lookup
+// the graph registered for the ID.
+func Resolve(reg registry.Registry, did string) (*Document, error) {
+	kordID, err := Parse(did)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := reg.Graph(kordID)
+	if err != nil {
+		return nil, err
+	}
+	return NewDocument(kordID, hash), nil
+}
@@ -0,0 +1,114 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package did
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+func TestParse(t *testing.T) {
+	id := common.HexToAddress("0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88")
+	did := New(id)
+	if expected := "did:kord:0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88"; did != expected {
+		t.Fatalf("expected DID %s, got %s", expected, did)
+	}
+	parsed, err := Parse(did)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != id {
+		t.Fatalf("expected %s to have ID %s, got %s", did, id.Hex(), parsed.Hex())
+	}
+	for _, s := range []string{
+		"",
+		"did:kord:",
+		"did:kord:0x1234",
+		"did:ethr:0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88",
+		"0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Fatalf("expected error parsing %q", s)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := crypto.PubkeyToAddress(key.PublicKey)
+	did := New(id)
+	reg := testutil.NewTestRegistry()
+
+	// check the document of an ID without a graph has no service
+	doc, err := Resolve(reg, did)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.ID != did {
+		t.Fatalf("expected document ID %s, got %s", did, doc.ID)
+	}
+	if len(doc.VerificationMethod) != 1 {
+		t.Fatalf("expected 1 verification method, got %d", len(doc.VerificationMethod))
+	}
+	method := doc.VerificationMethod[0]
+	if method.ID != VerificationMethodID(id) || method.Controller != did || method.EthereumAddress != id.Hex() {
+		t.Fatalf("unexpected verification method: %+v", method)
+	}
+	for _, refs := range [][]string{doc.Authentication, doc.AssertionMethod} {
+		if len(refs) != 1 || refs[0] != method.ID {
+			t.Fatalf("expected verification method %s to be referenced, got %v", method.ID, refs)
+		}
+	}
+	if len(doc.Service) != 0 {
+		t.Fatalf("expected no services, got %d", len(doc.Service))
+	}
+
+	// check the document of an ID with a graph has a graph service
+	hash := common.HexToHash("0x5c1ebf5c1ebf5c1ebf5c1ebf5c1ebf5c1ebf5c1ebf5c1ebf5c1ebf5c1ebf5c1e")
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetGraph(hash, sig); err != nil {
+		t.Fatal(err)
+	}
+	doc, err = Resolve(reg, did)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Service) != 1 {
+		t.Fatalf("expected 1 service, got %d", len(doc.Service))
+	}
+	service := doc.Service[0]
+	if service.ServiceEndpoint != "kord://"+id.Hex() || service.GraphHash != hash {
+		t.Fatalf("unexpected graph service: %+v", service)
+	}
+
+	// check invalid DIDs are not resolved
+	if _, err := Resolve(reg, "did:kord:invalid"); err == nil {
+		t.Fatal("expected error resolving invalid DID")
+	}
+}
@@ -0,0 +1,70 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package uri
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type Resolver interface {
+	ResolveName(name string) (common.Address, error)
+}
+
+// CachingResolver caches successful name resolutions for a fixed TTL.
+type CachingResolver struct {
+	resolver Resolver
+	ttl      time.Duration
+
+	cache    map[string]cachedName
+	cacheMtx sync.Mutex
+}
+
+type cachedName struct {
+	id      common.Address
+	expires time.Time
+}
+
+func NewCachingResolver(resolver Resolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{
+		resolver: resolver,
+		ttl:      ttl,
+		cache:    make(map[string]cachedName),
+	}
+}
+
+func (c *CachingResolver) ResolveName(name string) (common.Address, error) {
+	c.cacheMtx.Lock()
+	entry, ok := c.cache[name]
+	c.cacheMtx.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.id, nil
+	}
+	id, err := c.resolver.ResolveName(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	c.cacheMtx.Lock()
+	c.cache[name] = cachedName{id: id, expires: time.Now().Add(c.ttl)}
+	c.cacheMtx.Unlock()
+	return id, nil
+}
@@ -20,18 +20,31 @@
 package uri
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// NameDomain is the top-level domain of human-readable KORD names.
+const NameDomain = "kord"
+
 type URI struct {
 	ID   common.Address
+	Name string
 	Path string
 }
 
+// Parse parses a KORD URI which uses a KORD ID as its host.
 func Parse(s string) (*URI, error) {
+	return ParseWithResolver(s, nil)
+}
+
+// ParseWithResolver parses a KORD URI, resolving a human-readable host like
+// "jaak.kord" to a KORD ID using the given resolver.
+func ParseWithResolver(s string, resolver Resolver) (*URI, error) {
 	u, err := url.Parse(s)
 	if err != nil {
 		return nil, err
@@ -39,15 +52,31 @@ func Parse(s string) (*URI, error) {
 	if u.Scheme != "kord" {
 		return nil, fmt.Errorf("invalid KORD URI scheme: %s", u.Scheme)
 	}
-	if !common.IsHexAddress(u.Host) {
+	if common.IsHexAddress(u.Host) {
+		return &URI{
+			ID:   common.HexToAddress(u.Host),
+			Path: u.Path,
+		}, nil
+	}
+	if !IsName(u.Host) {
 		return nil, fmt.Errorf("invalid KORD ID in uri: %s", u.Host)
 	}
+	if resolver == nil {
+		return nil, errors.New("unable to resolve KORD name: no resolver")
+	}
+	id, err := resolver.ResolveName(u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve KORD name %q: %s", u.Host, err)
+	}
 	return &URI{
-		ID:   common.HexToAddress(u.Host),
+		ID:   id,
+		Name: u.Host,
 		Path: u.Path,
 	}, nil
 }
 
+// String returns the canonical form of the URI which always uses the
+// KORD ID as the host, even if the URI was parsed from a name.
 func (u *URI) String() string {
 	return (&url.URL{
 		Scheme: "kord",
@@ -55,3 +84,21 @@ func (u *URI) String() string {
 		Path:   u.Path,
 	}).String()
 }
+
+// IsName reports whether s is a valid KORD name, which is a single label
+// of lowercase letters, digits and hyphens under the KORD domain.
+func IsName(s string) bool {
+	label := strings.TrimSuffix(s, "."+NameDomain)
+	if label == s || label == "" {
+		return false
+	}
+	if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return false
+	}
+	for _, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package uri
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParse(t *testing.T) {
+	id := common.HexToAddress("0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88")
+	resolver := &testResolver{names: map[string]common.Address{"jaak.kord": id}}
+	for _, s := range []string{
+		"kord://0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88/path",
+		"kord://jaak.kord/path",
+	} {
+		u, err := ParseWithResolver(s, resolver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.ID != id {
+			t.Fatalf("expected %s to have ID %s, got %s", s, id.Hex(), u.ID.Hex())
+		}
+		if u.Path != "/path" {
+			t.Fatalf("expected %s to have path /path, got %s", s, u.Path)
+		}
+		if expected := "kord://0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88/path"; u.String() != expected {
+			t.Fatalf("expected %s to have canonical form %s, got %s", s, expected, u.String())
+		}
+	}
+	for _, s := range []string{
+		"http://jaak.kord/path",
+		"kord://jaak.eth/path",
+		"kord://Jaak.kord/path",
+		"kord://-jaak.kord/path",
+		"kord://unknown.kord/path",
+	} {
+		if _, err := ParseWithResolver(s, resolver); err == nil {
+			t.Fatalf("expected error parsing %s", s)
+		}
+	}
+}
+
+func TestCachingResolver(t *testing.T) {
+	id := common.HexToAddress("0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88")
+	resolver := &testResolver{names: map[string]common.Address{"jaak.kord": id}}
+	cache := NewCachingResolver(resolver, time.Minute)
+	for i := 0; i < 3; i++ {
+		got, err := cache.ResolveName("jaak.kord")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != id {
+			t.Fatalf("expected %s, got %s", id.Hex(), got.Hex())
+		}
+	}
+	if resolver.calls != 1 {
+		t.Fatalf("expected 1 registry lookup, got %d", resolver.calls)
+	}
+}
+
+type testResolver struct {
+	names map[string]common.Address
+	calls int
+}
+
+func (r *testResolver) ResolveName(name string) (common.Address, error) {
+	r.calls++
+	id, ok := r.names[name]
+	if !ok {
+		return common.Address{}, errors.New("not found")
+	}
+	return id, nil
+}
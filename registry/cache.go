@@ -0,0 +1,261 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package registry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// DefaultCacheSize is the default maximum number of graph hashes
+	// cached by a Cache.
+	DefaultCacheSize = 1024
+
+	// DefaultCacheTTL is the default time after which a cached graph hash
+	// is read from the registry again even if no update has been received.
+	DefaultCacheTTL = 10 * time.Minute
+)
+
+// Cache is a Registry which caches graph hashes, invalidating them when
+// the underlying registry sends an update for the graph.
+//
+// Cached hashes are also refreshed once they are older than TTL, and if the
+// subscription to updates for a graph fails then its entry is dropped and
+// the registry is subscribed to again on the next lookup. The least
+// recently used entries are evicted once there are more than Size.
+type Cache struct {
+	Registry
+
+	// Size is the maximum number of cached graph hashes
+	Size int
+
+	// TTL is the maximum age of a cached graph hash
+	TTL time.Duration
+
+	entries map[common.Address]*cacheEntry
+	mtx     sync.Mutex
+}
+
+type cacheEntry struct {
+	hash  common.Hash
+	valid bool
+
+	// fetched is when the hash was read from the registry
+	fetched time.Time
+
+	// used is when the entry was last looked up
+	used time.Time
+
+	// gen is incremented when the entry is invalidated, so that a hash
+	// read from the registry before an update is not cached
+	gen uint64
+
+	// sub is the subscription to updates of the graph, which is nil
+	// while the subscription is being created
+	sub Subscription
+}
+
+func NewCache(registry Registry) *Cache {
+	return &Cache{
+		Registry: registry,
+		Size:     DefaultCacheSize,
+		TTL:      DefaultCacheTTL,
+		entries:  make(map[common.Address]*cacheEntry),
+	}
+}
+
+// Graph returns the cached graph hash of the KORD ID, reading it from the
+// registry if it is not cached. The registry is not called with the cache
+// locked, so a slow lookup does not block lookups of other graphs.
+func (c *Cache) Graph(kordID common.Address) (common.Hash, error) {
+	var closing []Subscription
+	defer func() {
+		for _, sub := range closing {
+			sub.Close()
+		}
+	}()
+
+	c.mtx.Lock()
+	entry, ok := c.entries[kordID]
+	if ok && entry.sub != nil {
+		if err := entry.sub.Err(); err != nil {
+			log.Warn("graph subscription failed, resubscribing", "id", kordID, "err", err)
+			delete(c.entries, kordID)
+			closing = append(closing, entry.sub)
+			ok = false
+		}
+	}
+	now := time.Now()
+	if ok && entry.valid && (c.TTL <= 0 || now.Sub(entry.fetched) < c.TTL) {
+		entry.used = now
+		hash := entry.hash
+		c.mtx.Unlock()
+		return hash, nil
+	}
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[kordID] = entry
+		closing = append(closing, c.evict()...)
+	}
+	entry.used = now
+	c.mtx.Unlock()
+
+	if !ok {
+		// subscribe before getting the hash so that no updates
+		// are missed
+		sub, err := c.subscribe(kordID)
+		c.mtx.Lock()
+		current := c.entries[kordID] == entry
+		if err != nil && current {
+			delete(c.entries, kordID)
+		}
+		if err == nil && current {
+			entry.sub = sub
+		}
+		c.mtx.Unlock()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if !current {
+			closing = append(closing, sub)
+		}
+	}
+
+	// only cache the hash if the graph is subscribed to and no update
+	// arrives while it is being read
+	c.mtx.Lock()
+	gen, subscribed := entry.gen, entry.sub != nil
+	c.mtx.Unlock()
+	hash, err := c.Registry.Graph(kordID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	c.mtx.Lock()
+	if subscribed && entry.gen == gen && c.entries[kordID] == entry {
+		entry.hash = hash
+		entry.valid = true
+		entry.fetched = time.Now()
+	}
+	c.mtx.Unlock()
+	return hash, nil
+}
+
+// evict removes the least recently used entries until there are at most
+// Size, returning their subscriptions to be closed once the cache is
+// unlocked. It must be called with mtx held.
+func (c *Cache) evict() []Subscription {
+	var subs []Subscription
+	for c.Size > 0 && len(c.entries) > c.Size {
+		var (
+			oldest   common.Address
+			oldestAt time.Time
+			found    bool
+		)
+		for id, entry := range c.entries {
+			if entry.sub == nil {
+				// still subscribing
+				continue
+			}
+			if !found || entry.used.Before(oldestAt) {
+				oldest, oldestAt, found = id, entry.used, true
+			}
+		}
+		if !found {
+			break
+		}
+		subs = append(subs, c.entries[oldest].sub)
+		delete(c.entries, oldest)
+	}
+	return subs
+}
+
+// SetGraph updates the registry and invalidates the cached hash of the
+// KORD ID which signed the hash.
+func (c *Cache) SetGraph(hash common.Hash, sig []byte) error {
+	if err := c.Registry.SetGraph(hash, sig); err != nil {
+		return err
+	}
+	if kordID, err := RecoverKordID(hash, sig); err == nil {
+		c.invalidate(kordID)
+	}
+	return nil
+}
+
+// SetRecord sets the record in the registry and invalidates the cached hash
+// of its KORD ID.
+func (c *Cache) SetRecord(record *Record) error {
+	if err := SetRecord(c.Registry, record); err != nil {
+		return err
+	}
+	c.invalidate(record.KordID)
+	return nil
+}
+
+func (c *Cache) Close() {
+	c.mtx.Lock()
+	entries := c.entries
+	c.entries = make(map[common.Address]*cacheEntry)
+	c.mtx.Unlock()
+	for _, entry := range entries {
+		if entry.sub != nil {
+			entry.sub.Close()
+		}
+	}
+}
+
+func (c *Cache) subscribe(kordID common.Address) (Subscription, error) {
+	updates := make(chan common.Hash)
+	sub, err := c.Registry.SubscribeGraph(kordID, updates)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for range updates {
+			c.invalidate(kordID)
+		}
+	}()
+	return &cacheSubscription{sub, updates}, nil
+}
+
+func (c *Cache) invalidate(kordID common.Address) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if entry, ok := c.entries[kordID]; ok {
+		log.Debug("invalidating cached graph hash", "id", kordID)
+		entry.valid = false
+		entry.gen++
+	}
+}
+
+type cacheSubscription struct {
+	Subscription
+
+	updates chan common.Hash
+}
+
+func (s *cacheSubscription) Close() error {
+	err := s.Subscription.Close()
+	close(s.updates)
+	return err
+}
@@ -13,10 +13,10 @@ import (
 )
 
 // KORDRegistryABI is the input ABI used to generate the binding from.
-const KORDRegistryABI = "[{\"constant\":false,\"inputs\":[{\"name\":\"hash\",\"type\":\"bytes32\"},{\"name\":\"sig\",\"type\":\"bytes\"}],\"name\":\"setGraph\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"kordID\",\"type\":\"address\"}],\"name\":\"graph\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"}]"
+const KORDRegistryABI = "[{\"constant\":true,\"inputs\":[{\"name\":\"kordID\",\"type\":\"address\"}],\"name\":\"nonce\",\"outputs\":[{\"name\":\"\",\"type\":\"uint64\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"kordID\",\"type\":\"address\"}],\"name\":\"graph\",\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"kordID\",\"type\":\"address\"},{\"name\":\"hash\",\"type\":\"bytes32\"},{\"name\":\"nonce\",\"type\":\"uint64\"},{\"name\":\"sig\",\"type\":\"bytes\"}],\"name\":\"setGraph\",\"outputs\":[],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]"
 
 // KORDRegistryBin is the compiled bytecode used for deploying new contracts.
-const KORDRegistryBin = `0x6060604052341561000f57600080fd5b6102388061001e6000396000f30060606040526004361061004b5763ffffffff7c010000000000000000000000000000000000000000000000000000000060003504166375dfb0e08114610050578063ab2c7776146100a8575b600080fd5b341561005b57600080fd5b6100a6600480359060446024803590810190830135806020601f820181900481020160405190810160405281815292919060208401838380828437509496506100e695505050505050565b005b34156100b357600080fd5b6100d473ffffffffffffffffffffffffffffffffffffffff600435166101e4565b60405190815260200160405180910390f35b60008060008084516041146100fa57600080fd5b6020850151925060408501519150606085015160001a9350601b8460ff16101561012557601b840193505b8360ff16601b1415801561013d57508360ff16601c14155b1561014757600080fd5b6001868585856040516000815260200160405260006040516020015260405193845260ff90921660208085019190915260408085019290925260608401929092526080909201915160208103908084039060008661646e5a03f115156101ac57600080fd5b50506020604051035173ffffffffffffffffffffffffffffffffffffffff166000908152602081905260409020959095555050505050565b73ffffffffffffffffffffffffffffffffffffffff16600090815260208190526040902054905600a165627a7a72305820bc3cd2f1cc7e0da514b92c0bf67d62293da625bb79164b7a48a3b7c7c58cd52c0029`
+const KORDRegistryBin = `0x3461001257610239806100176000396000f35b600080fd346102345760043610610234576000357c01000000000000000000000000000000000000000000000000000000009004806370ae92d21461008d578063ab2c777614610055578063d10173be146100c557610234565b60043573ffffffffffffffffffffffffffffffffffffffff166102005261020051600052600060205260406000205460005260206000f35b60043573ffffffffffffffffffffffffffffffffffffffff166102005261020051600052600160205260406000205460005260206000f35b60043573ffffffffffffffffffffffffffffffffffffffff16801561023457610200526024356102205260443567ffffffffffffffff16610240526064356004016102c0526102c0513560411415610234576102c05160200135610280526102c051604001356102a0526102c0516060013560001a61026052601b61026051101561015657601b6102605101610260525b61026051601b1461026051601c141715610234576102405161011c5261022051610114526102005160f452603c6101002061018052610260516101a052610280516101c0526102a0516101e0526000600052602060006080610180600060015af115610234576000516102005114156102345761020051600052600060205260406000206102e05261020051600052600160205260406000206103005261030051546102405111610220576103005154610240511415610234576102e05154610220511115610234575b610220516102e05155610240516103005155005b600080fd`
 
 // DeployKORDRegistry deploys a new Ethereum contract, binding an instance of KORDRegistry to it.
 func DeployKORDRegistry(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *KORDRegistry, error) {
@@ -199,23 +199,49 @@ func (_KORDRegistry *KORDRegistryCallerSession) Graph(kordID common.Address) ([3
 	return _KORDRegistry.Contract.Graph(&_KORDRegistry.CallOpts, kordID)
 }
 
-// SetGraph is a paid mutator transaction binding the contract method 0x75dfb0e0.
+// Nonce is a free data retrieval call binding the contract method 0x70ae92d2.
 //
-// Solidity: function setGraph(hash bytes32, sig bytes) returns()
-func (_KORDRegistry *KORDRegistryTransactor) SetGraph(opts *bind.TransactOpts, hash [32]byte, sig []byte) (*types.Transaction, error) {
-	return _KORDRegistry.contract.Transact(opts, "setGraph", hash, sig)
+// Solidity: function nonce(kordID address) constant returns(uint64)
+func (_KORDRegistry *KORDRegistryCaller) Nonce(opts *bind.CallOpts, kordID common.Address) (uint64, error) {
+	var (
+		ret0 = new(uint64)
+	)
+	out := ret0
+	err := _KORDRegistry.contract.Call(opts, out, "nonce", kordID)
+	return *ret0, err
+}
+
+// Nonce is a free data retrieval call binding the contract method 0x70ae92d2.
+//
+// Solidity: function nonce(kordID address) constant returns(uint64)
+func (_KORDRegistry *KORDRegistrySession) Nonce(kordID common.Address) (uint64, error) {
+	return _KORDRegistry.Contract.Nonce(&_KORDRegistry.CallOpts, kordID)
+}
+
+// Nonce is a free data retrieval call binding the contract method 0x70ae92d2.
+//
+// Solidity: function nonce(kordID address) constant returns(uint64)
+func (_KORDRegistry *KORDRegistryCallerSession) Nonce(kordID common.Address) (uint64, error) {
+	return _KORDRegistry.Contract.Nonce(&_KORDRegistry.CallOpts, kordID)
+}
+
+// SetGraph is a paid mutator transaction binding the contract method 0xd10173be.
+//
+// Solidity: function setGraph(kordID address, hash bytes32, nonce uint64, sig bytes) returns()
+func (_KORDRegistry *KORDRegistryTransactor) SetGraph(opts *bind.TransactOpts, kordID common.Address, hash [32]byte, nonce uint64, sig []byte) (*types.Transaction, error) {
+	return _KORDRegistry.contract.Transact(opts, "setGraph", kordID, hash, nonce, sig)
 }
 
-// SetGraph is a paid mutator transaction binding the contract method 0x75dfb0e0.
+// SetGraph is a paid mutator transaction binding the contract method 0xd10173be.
 //
-// Solidity: function setGraph(hash bytes32, sig bytes) returns()
-func (_KORDRegistry *KORDRegistrySession) SetGraph(hash [32]byte, sig []byte) (*types.Transaction, error) {
-	return _KORDRegistry.Contract.SetGraph(&_KORDRegistry.TransactOpts, hash, sig)
+// Solidity: function setGraph(kordID address, hash bytes32, nonce uint64, sig bytes) returns()
+func (_KORDRegistry *KORDRegistrySession) SetGraph(kordID common.Address, hash [32]byte, nonce uint64, sig []byte) (*types.Transaction, error) {
+	return _KORDRegistry.Contract.SetGraph(&_KORDRegistry.TransactOpts, kordID, hash, nonce, sig)
 }
 
-// SetGraph is a paid mutator transaction binding the contract method 0x75dfb0e0.
+// SetGraph is a paid mutator transaction binding the contract method 0xd10173be.
 //
-// Solidity: function setGraph(hash bytes32, sig bytes) returns()
-func (_KORDRegistry *KORDRegistryTransactorSession) SetGraph(hash [32]byte, sig []byte) (*types.Transaction, error) {
-	return _KORDRegistry.Contract.SetGraph(&_KORDRegistry.TransactOpts, hash, sig)
+// Solidity: function setGraph(kordID address, hash bytes32, nonce uint64, sig bytes) returns()
+func (_KORDRegistry *KORDRegistryTransactorSession) SetGraph(kordID common.Address, hash [32]byte, nonce uint64, sig []byte) (*types.Transaction, error) {
+	return _KORDRegistry.Contract.SetGraph(&_KORDRegistry.TransactOpts, kordID, hash, nonce, sig)
 }
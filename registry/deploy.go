@@ -23,8 +23,11 @@ import (
 	"context"
 
 	"github.com/ethereum/go-ethereum/common"
+	ens "github.com/ethereum/go-ethereum/contracts/ens/contract"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/kord-network/go-kord/pkg/uri"
 	"github.com/kord-network/go-kord/registry/contract"
 )
 
@@ -56,3 +59,77 @@ func deployRegistry(client *Client, addr common.Address) (common.Address, error)
 	}
 	return receipt.ContractAddress, nil
 }
+
+// DeployNames deploys an ENS registry with a public resolver and a KORD
+// registrar which owns the top-level KORD domain.
+func DeployNames(url string, config Config) (common.Address, error) {
+	c, err := rpc.Dial(url)
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer c.Close()
+
+	client, err := NewClient(c, config)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return deployNames(client, config.ENSAddr)
+}
+
+func deployNames(client *Client, addr common.Address) (common.Address, error) {
+	if data, err := client.CodeAt(context.Background(), addr, nil); err == nil && len(data) > 0 {
+		return addr, nil
+	}
+	receipt, err := client.do(func() (tx *types.Transaction, err error) {
+		_, tx, _, err = ens.DeployENS(client.transactOpts, client)
+		return
+	})
+	if err != nil {
+		return common.Address{}, err
+	}
+	ensAddr := receipt.ContractAddress
+	ensContract, err := ens.NewENSTransactor(ensAddr, client)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	receipt, err = client.do(func() (tx *types.Transaction, err error) {
+		_, tx, _, err = ens.DeployPublicResolver(client.transactOpts, client, ensAddr)
+		return
+	})
+	if err != nil {
+		return common.Address{}, err
+	}
+	resolverAddr := receipt.ContractAddress
+
+	node := NameHash(uri.NameDomain)
+	receipt, err = client.do(func() (tx *types.Transaction, err error) {
+		_, tx, _, err = contract.DeployKORDRegistrar(client.transactOpts, client, ensAddr, node)
+		return
+	})
+	if err != nil {
+		return common.Address{}, err
+	}
+	registrarAddr := receipt.ContractAddress
+
+	// take ownership of the top-level domain to set its default
+	// resolver before handing it over to the registrar
+	label := crypto.Keccak256Hash([]byte(uri.NameDomain))
+	for _, f := range []func() (*types.Transaction, error){
+		func() (*types.Transaction, error) {
+			return ensContract.SetSubnodeOwner(client.transactOpts, common.Hash{}, label, client.transactOpts.From)
+		},
+		func() (*types.Transaction, error) {
+			return ensContract.SetResolver(client.transactOpts, node, resolverAddr)
+		},
+		func() (*types.Transaction, error) {
+			return ensContract.SetSubnodeOwner(client.transactOpts, common.Hash{}, label, registrarAddr)
+		},
+	} {
+		if _, err := client.do(f); err != nil {
+			return common.Address{}, err
+		}
+	}
+	return ensAddr, nil
+}
@@ -0,0 +1,326 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+// Package gossip implements a Registry which receives signed graph updates
+// from peers over a devp2p protocol, so that nodes learn about updates
+// before they are confirmed by the underlying (on-chain) registry.
+package gossip
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/kord-network/go-kord/registry"
+)
+
+// PendingTimeout is how long a gossiped update takes precedence over the
+// underlying registry while waiting for it to be confirmed.
+const PendingTimeout = 10 * time.Minute
+
+// Update is a signed update of the graph hash of a KORD ID.
+type Update struct {
+	KordID common.Address
+	Hash   common.Hash
+
+	// Nonce orders updates from the same KORD ID, and is typically the
+	// time the update was signed
+	Nonce uint64
+
+	Sig []byte
+}
+
+// NewUpdate returns an update of the graph hash of the KORD ID signed
+// using the sign function.
+func NewUpdate(kordID common.Address, hash common.Hash, nonce uint64, sign func(common.Hash) ([]byte, error)) (*Update, error) {
+	u := &Update{
+		KordID: kordID,
+		Hash:   hash,
+		Nonce:  nonce,
+	}
+	sig, err := sign(u.SigHash())
+	if err != nil {
+		return nil, err
+	}
+	u.Sig = sig
+	return u, nil
+}
+
+// SigHash returns the hash signed by the KORD ID, which covers the nonce so
+// that old updates cannot be replayed as new ones, and is the same as the
+// hash signed for a registry.Record.
+func (u *Update) SigHash() common.Hash {
+	return registry.RecordHash(u.KordID, u.Hash, u.Nonce)
+}
+
+// Verify checks the update was signed by its KORD ID.
+func (u *Update) Verify() error {
+	kordID, err := registry.RecoverKordID(u.SigHash(), u.Sig)
+	if err != nil {
+		return err
+	}
+	if kordID != u.KordID {
+		return errors.New("gossip: update not signed by KORD ID")
+	}
+	return nil
+}
+
+// Registry wraps a Registry, merging updates received from peers with those
+// from the underlying registry.
+//
+// An update received from a peer is pending until the underlying registry
+// sends the same hash, with the pending hash taking precedence over the
+// underlying registry for at most PendingTimeout.
+type Registry struct {
+	registry.Registry
+
+	// latest is the latest update received for each KORD ID, which is
+	// kept after being confirmed so that older updates are rejected
+	latest  map[common.Address]*Update
+	pending map[common.Address]time.Time
+	mtx     sync.Mutex
+
+	subs   map[common.Address]map[*subscription]struct{}
+	subMtx sync.Mutex
+
+	peers   map[*peer]struct{}
+	peerMtx sync.RWMutex
+}
+
+func New(registry registry.Registry) *Registry {
+	return &Registry{
+		Registry: registry,
+		latest:   make(map[common.Address]*Update),
+		pending:  make(map[common.Address]time.Time),
+		subs:     make(map[common.Address]map[*subscription]struct{}),
+		peers:    make(map[*peer]struct{}),
+	}
+}
+
+// Graph returns the hash of a pending update of the KORD ID if there is one,
+// or the hash from the underlying registry.
+func (r *Registry) Graph(kordID common.Address) (common.Hash, error) {
+	if u := r.pendingUpdate(kordID); u != nil {
+		return u.Hash, nil
+	}
+	return r.Registry.Graph(kordID)
+}
+
+// SetRecord sets the record in the underlying registry.
+func (r *Registry) SetRecord(record *registry.Record) error {
+	return registry.SetRecord(r.Registry, record)
+}
+
+// Announce verifies and applies a local update, and sends it to peers
+// interested in the graph.
+func (r *Registry) Announce(u *Update) error {
+	if err := u.Verify(); err != nil {
+		return err
+	}
+	if !r.addUpdate(u) {
+		return fmt.Errorf("gossip: update nonce %d is not newer than the latest update", u.Nonce)
+	}
+	r.broadcast(u, nil)
+	return nil
+}
+
+// addUpdate stores the update and notifies subscribers if it is newer than
+// the latest update of the KORD ID, returning whether it was.
+func (r *Registry) addUpdate(u *Update) bool {
+	r.mtx.Lock()
+	if latest, ok := r.latest[u.KordID]; ok && u.Nonce <= latest.Nonce {
+		r.mtx.Unlock()
+		return false
+	}
+	r.latest[u.KordID] = u
+	r.pending[u.KordID] = time.Now()
+	r.mtx.Unlock()
+	log.Debug("received graph update", "id", u.KordID, "hash", u.Hash, "nonce", u.Nonce)
+	r.notify(u.KordID, u.Hash)
+	return true
+}
+
+func (r *Registry) pendingUpdate(kordID common.Address) *Update {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	received, ok := r.pending[kordID]
+	if !ok {
+		return nil
+	}
+	if time.Since(received) > PendingTimeout {
+		log.Warn("gossiped graph update not confirmed", "id", kordID, "hash", r.latest[kordID].Hash)
+		delete(r.pending, kordID)
+		return nil
+	}
+	return r.latest[kordID]
+}
+
+// confirm is called with updates from the underlying registry, and returns
+// whether the update should be sent to subscribers, which it is unless it
+// differs from a pending update (in which case the pending update is
+// assumed to be newer).
+func (r *Registry) confirm(kordID common.Address, hash common.Hash) bool {
+	u := r.pendingUpdate(kordID)
+	if u == nil {
+		return true
+	}
+	if u.Hash != hash {
+		return false
+	}
+	r.mtx.Lock()
+	delete(r.pending, kordID)
+	r.mtx.Unlock()
+	return true
+}
+
+// SubscribeGraph subscribes to updates from both the underlying registry
+// and peers, registering interest in the graph with peers.
+func (r *Registry) SubscribeGraph(kordID common.Address, updates chan common.Hash) (registry.Subscription, error) {
+	sub := &subscription{
+		registry: r,
+		kordID:   kordID,
+		updates:  updates,
+		confirms: make(chan common.Hash),
+		pending:  make(chan common.Hash, 1),
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	inner, err := r.Registry.SubscribeGraph(kordID, sub.confirms)
+	if err != nil {
+		return nil, err
+	}
+	sub.inner = inner
+	r.subMtx.Lock()
+	subs, ok := r.subs[kordID]
+	if !ok {
+		subs = make(map[*subscription]struct{})
+		r.subs[kordID] = subs
+	}
+	subs[sub] = struct{}{}
+	r.subMtx.Unlock()
+	go sub.loop()
+	if !ok {
+		r.sendInterests()
+	}
+	return sub, nil
+}
+
+// interests returns the KORD IDs which have subscribers.
+func (r *Registry) interests() []common.Address {
+	r.subMtx.Lock()
+	defer r.subMtx.Unlock()
+	ids := make([]common.Address, 0, len(r.subs))
+	for id := range r.subs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (r *Registry) interested(kordID common.Address) bool {
+	r.subMtx.Lock()
+	defer r.subMtx.Unlock()
+	_, ok := r.subs[kordID]
+	return ok
+}
+
+func (r *Registry) notify(kordID common.Address, hash common.Hash) {
+	r.subMtx.Lock()
+	defer r.subMtx.Unlock()
+	for sub := range r.subs[kordID] {
+		sub.send(hash)
+	}
+}
+
+// subscription merges updates from the underlying registry with those
+// received from peers, without blocking either on slow subscribers.
+type subscription struct {
+	registry  *Registry
+	kordID    common.Address
+	inner     registry.Subscription
+	updates   chan common.Hash
+	confirms  chan common.Hash
+	pending   chan common.Hash
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+func (s *subscription) send(hash common.Hash) {
+	for {
+		select {
+		case s.pending <- hash:
+			return
+		default:
+		}
+		// drop the stale pending hash in favour of the new one
+		select {
+		case <-s.pending:
+		default:
+		}
+	}
+}
+
+func (s *subscription) loop() {
+	defer close(s.done)
+	for {
+		var hash common.Hash
+		select {
+		case hash = <-s.pending:
+		case hash = <-s.confirms:
+			if !s.registry.confirm(s.kordID, hash) {
+				continue
+			}
+		case <-s.closed:
+			return
+		}
+		select {
+		case s.updates <- hash:
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+func (s *subscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		err = s.inner.Close()
+		r := s.registry
+		r.subMtx.Lock()
+		delete(r.subs[s.kordID], s)
+		last := len(r.subs[s.kordID]) == 0
+		if last {
+			delete(r.subs, s.kordID)
+		}
+		r.subMtx.Unlock()
+		if last {
+			r.sendInterests()
+		}
+	})
+	<-s.done
+	return err
+}
+
+func (s *subscription) Err() error {
+	return s.inner.Err()
+}
@@ -0,0 +1,198 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package gossip
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/kord-network/go-kord/testutil"
+)
+
+func TestGossip(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kordID := crypto.PubkeyToAddress(key.PublicKey)
+
+	// create three nodes connected in a line which share a registry
+	// standing in for the chain, with the second and third nodes
+	// subscribed to the graph
+	chain := testutil.NewTestRegistry()
+	r1, r2, r3 := New(chain), New(chain), New(chain)
+	updates2 := make(chan common.Hash)
+	sub2, err := r2.SubscribeGraph(kordID, updates2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub2.Close()
+	updates3 := make(chan common.Hash)
+	sub3, err := r3.SubscribeGraph(kordID, updates3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub3.Close()
+	defer connect(t, r1, r2)()
+	defer connect(t, r2, r3)()
+	waitForInterest(t, r1, kordID)
+	waitForInterest(t, r2, kordID)
+
+	// check an update announced by the first node reaches the other
+	// nodes ahead of the chain
+	hash1 := common.HexToHash("0x01")
+	if err := r1.Announce(newUpdate(t, key, hash1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	expectUpdate(t, updates2, hash1)
+	expectUpdate(t, updates3, hash1)
+	if hash, err := r3.Graph(kordID); err != nil || hash != hash1 {
+		t.Fatalf("expected graph hash %s, got %s (err: %v)", hash1.Hex(), hash.Hex(), err)
+	}
+
+	// check stale and forged updates are rejected
+	if err := r1.Announce(newUpdate(t, key, common.HexToHash("0x02"), 1)); err == nil {
+		t.Fatal("expected stale update to be rejected")
+	}
+	forged := newUpdate(t, key, common.HexToHash("0x02"), 2)
+	forged.Hash = common.HexToHash("0x03")
+	if err := r1.Announce(forged); err == nil {
+		t.Fatal("expected forged update to be rejected")
+	}
+
+	// check peers sending forged updates are disconnected
+	rw1, rw2 := p2p.MsgPipe()
+	defer rw1.Close()
+	defer rw2.Close()
+	errc := make(chan error, 1)
+	go func() { errc <- r2.runPeer(p2p.NewPeer(discover.NodeID{9}, "forger", nil), rw2) }()
+	if err := p2p.Send(rw1, updatesMsg, []*Update{forged}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Fatal("expected forger to be disconnected with an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for forger to be disconnected")
+	}
+
+	// check a newly interested node receives the latest update when it
+	// connects
+	r4 := New(chain)
+	updates4 := make(chan common.Hash)
+	sub4, err := r4.SubscribeGraph(kordID, updates4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub4.Close()
+	defer connect(t, r2, r4)()
+	expectUpdate(t, updates4, hash1)
+
+	// check the chain confirming the update clears the pending update,
+	// after which the chain takes precedence (closing the other
+	// subscriptions first as the test registry blocks on subscribers)
+	sub3.Close()
+	sub4.Close()
+	if err := chain.SetGraph(hash1, sign(t, key, hash1)); err != nil {
+		t.Fatal(err)
+	}
+	expectUpdate(t, updates2, hash1)
+	if u := r2.pendingUpdate(kordID); u != nil {
+		t.Fatalf("expected pending update to be cleared, got %v", u)
+	}
+	hash4 := common.HexToHash("0x04")
+	if err := chain.SetGraph(hash4, sign(t, key, hash4)); err != nil {
+		t.Fatal(err)
+	}
+	expectUpdate(t, updates2, hash4)
+	if hash, err := r2.Graph(kordID); err != nil || hash != hash4 {
+		t.Fatalf("expected graph hash %s, got %s (err: %v)", hash4.Hex(), hash.Hex(), err)
+	}
+}
+
+// connect runs the protocol between two registries, returning a function
+// which disconnects them.
+func connect(t *testing.T, r1, r2 *Registry) func() {
+	rw1, rw2 := p2p.MsgPipe()
+	go r1.runPeer(p2p.NewPeer(discover.NodeID{1}, "r1", nil), rw1)
+	go r2.runPeer(p2p.NewPeer(discover.NodeID{2}, "r2", nil), rw2)
+	return func() {
+		rw1.Close()
+		rw2.Close()
+	}
+}
+
+// waitForInterest waits for a peer of the registry to register interest in
+// the KORD ID.
+func waitForInterest(t *testing.T, r *Registry, kordID common.Address) {
+	timeout := time.After(5 * time.Second)
+	for {
+		r.peerMtx.RLock()
+		interested := false
+		for p := range r.peers {
+			interested = interested || p.interested(kordID)
+		}
+		r.peerMtx.RUnlock()
+		if interested {
+			return
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("timed out waiting for interest in %s", kordID.Hex())
+		}
+	}
+}
+
+func newUpdate(t *testing.T, key *ecdsa.PrivateKey, hash common.Hash, nonce uint64) *Update {
+	u, err := NewUpdate(crypto.PubkeyToAddress(key.PublicKey), hash, nonce, func(h common.Hash) ([]byte, error) {
+		return crypto.Sign(h[:], key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, hash common.Hash) []byte {
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func expectUpdate(t *testing.T, updates chan common.Hash, expected common.Hash) {
+	select {
+	case hash := <-updates:
+		if hash != expected {
+			t.Fatalf("expected update %s, got %s", expected.Hex(), hash.Hex())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for update %s", expected.Hex())
+	}
+}
@@ -0,0 +1,188 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package gossip
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+const (
+	protocolName    = "kordgsp"
+	protocolVersion = 1
+
+	// interestsMsg contains the KORD IDs whose updates a node wants to
+	// receive, replacing those from any previous interestsMsg
+	interestsMsg = 0x00
+
+	// updatesMsg contains a list of graph updates
+	updatesMsg = 0x01
+
+	protocolLength = 2
+
+	// maxInterests is the maximum number of KORD IDs in an interestsMsg
+	maxInterests = 4096
+)
+
+// Protocol returns the devp2p protocol used to gossip updates, which sends
+// updates to the peers which have registered interest in the graph, and
+// forwards updates received from peers to the other interested peers.
+func (r *Registry) Protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    protocolName,
+		Version: protocolVersion,
+		Length:  protocolLength,
+		Run:     r.runPeer,
+	}
+}
+
+type peer struct {
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	interests    map[common.Address]struct{}
+	interestsMtx sync.RWMutex
+}
+
+func (p *peer) interested(kordID common.Address) bool {
+	p.interestsMtx.RLock()
+	defer p.interestsMtx.RUnlock()
+	_, ok := p.interests[kordID]
+	return ok
+}
+
+func (r *Registry) runPeer(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	peer := &peer{Peer: p, rw: rw}
+	r.peerMtx.Lock()
+	r.peers[peer] = struct{}{}
+	r.peerMtx.Unlock()
+	defer func() {
+		r.peerMtx.Lock()
+		delete(r.peers, peer)
+		r.peerMtx.Unlock()
+	}()
+
+	// send our interests without waiting for the peer to read them, as
+	// it sends its interests at the same time
+	go func(ids []common.Address) {
+		if err := p2p.Send(rw, interestsMsg, ids); err != nil {
+			log.Debug("error sending gossip interests", "peer", p.ID(), "err", err)
+		}
+	}(r.interests())
+
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if err := r.handleMsg(peer, msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Registry) handleMsg(peer *peer, msg p2p.Msg) error {
+	defer msg.Discard()
+	switch msg.Code {
+	case interestsMsg:
+		var ids []common.Address
+		if err := msg.Decode(&ids); err != nil {
+			return fmt.Errorf("error decoding interests: %s", err)
+		}
+		if len(ids) > maxInterests {
+			return fmt.Errorf("too many interests from peer: %d", len(ids))
+		}
+		interests := make(map[common.Address]struct{}, len(ids))
+		for _, id := range ids {
+			interests[id] = struct{}{}
+		}
+		peer.interestsMtx.Lock()
+		peer.interests = interests
+		peer.interestsMtx.Unlock()
+
+		// send the peer the latest updates we have for its interests
+		var updates []*Update
+		r.mtx.Lock()
+		for _, id := range ids {
+			if u, ok := r.latest[id]; ok {
+				updates = append(updates, u)
+			}
+		}
+		r.mtx.Unlock()
+		if len(updates) > 0 {
+			return p2p.Send(peer.rw, updatesMsg, updates)
+		}
+		return nil
+	case updatesMsg:
+		var updates []*Update
+		if err := msg.Decode(&updates); err != nil {
+			return fmt.Errorf("error decoding updates: %s", err)
+		}
+		for _, u := range updates {
+			if err := u.Verify(); err != nil {
+				return fmt.Errorf("invalid update from peer: %s", err)
+			}
+			if !r.interested(u.KordID) {
+				continue
+			}
+			if r.addUpdate(u) {
+				r.broadcast(u, peer)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown message code: %d", msg.Code)
+	}
+}
+
+// sendInterests sends the current interests to all connected peers.
+func (r *Registry) sendInterests() {
+	ids := r.interests()
+	r.peerMtx.RLock()
+	defer r.peerMtx.RUnlock()
+	for p := range r.peers {
+		go func(p *peer) {
+			if err := p2p.Send(p.rw, interestsMsg, ids); err != nil {
+				log.Debug("error sending gossip interests", "peer", p.ID(), "err", err)
+			}
+		}(p)
+	}
+}
+
+// broadcast sends the update to all connected peers interested in it except
+// the one it was received from.
+func (r *Registry) broadcast(u *Update, from *peer) {
+	r.peerMtx.RLock()
+	defer r.peerMtx.RUnlock()
+	for p := range r.peers {
+		if p == from || !p.interested(u.KordID) {
+			continue
+		}
+		go func(p *peer) {
+			if err := p2p.Send(p.rw, updatesMsg, []*Update{u}); err != nil {
+				log.Debug("error sending graph update", "peer", p.ID(), "err", err)
+			}
+		}(p)
+	}
+}
@@ -0,0 +1,77 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package registry
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/kord-network/go-kord/metrics"
+)
+
+var (
+	callTimer  = metrics.NewTimerVec("kord_registry_call_duration_seconds", "Time taken by calls to the KORD registry.", "method")
+	callErrors = metrics.NewCounterVec("kord_registry_call_errors_total", "Number of failed calls to the KORD registry.", "method")
+)
+
+// Metrics is a Registry which records the duration and errors of calls to
+// the underlying registry.
+type Metrics struct {
+	Registry
+}
+
+func NewMetrics(registry Registry) *Metrics {
+	return &Metrics{Registry: registry}
+}
+
+func (m *Metrics) Graph(kordID common.Address) (common.Hash, error) {
+	start := time.Now()
+	hash, err := m.Registry.Graph(kordID)
+	record("graph", start, err)
+	return hash, err
+}
+
+func (m *Metrics) SetGraph(hash common.Hash, sig []byte) error {
+	start := time.Now()
+	err := m.Registry.SetGraph(hash, sig)
+	record("setGraph", start, err)
+	return err
+}
+
+func (m *Metrics) SetRecord(r *Record) error {
+	start := time.Now()
+	err := SetRecord(m.Registry, r)
+	record("setRecord", start, err)
+	return err
+}
+
+func (m *Metrics) SubscribeGraph(kordID common.Address, updates chan common.Hash) (Subscription, error) {
+	start := time.Now()
+	sub, err := m.Registry.SubscribeGraph(kordID, updates)
+	record("subscribeGraph", start, err)
+	return sub, err
+}
+
+func record(method string, start time.Time, err error) {
+	callTimer.With(method).UpdateSince(start)
+	if err != nil {
+		callErrors.With(method).Inc(1)
+	}
+}
@@ -0,0 +1,132 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	ens "github.com/ethereum/go-ethereum/contracts/ens/contract"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/pkg/uri"
+	"github.com/kord-network/go-kord/registry/contract"
+)
+
+// NameRegistry maps human-readable names like "jaak.kord" to KORD IDs.
+type NameRegistry interface {
+	ResolveName(name string) (common.Address, error)
+	RegisterName(name string, kordID common.Address, sig []byte) error
+}
+
+// NameHash returns the ENS namehash of the given name.
+func NameHash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := crypto.Keccak256Hash([]byte(labels[i]))
+		node = crypto.Keccak256Hash(node[:], label[:])
+	}
+	return node
+}
+
+func (c *Client) ResolveName(name string) (common.Address, error) {
+	if !uri.IsName(name) {
+		return common.Address{}, fmt.Errorf("invalid KORD name: %s", name)
+	}
+	kordID, err := c.resolveName(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if kordID == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("KORD name not registered: %s", name)
+	}
+	return kordID, nil
+}
+
+// resolveName returns the KORD ID the name resolves to, or the zero
+// address if it is not registered.
+func (c *Client) resolveName(name string) (common.Address, error) {
+	node := NameHash(name)
+	resolverAddr, err := c.ens.Resolver(node)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if resolverAddr == (common.Address{}) {
+		return common.Address{}, nil
+	}
+	resolver, err := ens.NewPublicResolverCaller(resolverAddr, c)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return resolver.Addr(nil, node)
+}
+
+// nameDomain is hashed with the KORD ID and namehash which a KORD ID signs
+// to register a name, so that the signature cannot be used for anything
+// else.
+var nameDomain = crypto.Keccak256Hash([]byte("KORD name registration"))
+
+// NameSigHash returns the hash which a KORD ID signs to register the name.
+func NameSigHash(name string, kordID common.Address) common.Hash {
+	node := NameHash(name)
+	return crypto.Keccak256Hash(nameDomain[:], kordID[:], node[:])
+}
+
+// RegisterName registers the name with the KORD registrar which owns the
+// top-level KORD domain, which checks that the KORD ID signed the
+// NameSigHash of the name, assigns the name to the KORD ID and points it
+// at the KORD ID using the domain's default resolver.
+//
+// Names are first come first served, so registering a name which already
+// has an owner fails unless it already resolves to the KORD ID.
+func (c *Client) RegisterName(name string, kordID common.Address, sig []byte) error {
+	if !uri.IsName(name) {
+		return fmt.Errorf("invalid KORD name: %s", name)
+	}
+	current, err := c.resolveName(name)
+	if err != nil {
+		return err
+	}
+	if current == kordID {
+		return nil
+	} else if current != (common.Address{}) {
+		return fmt.Errorf("KORD name %s is already registered to %s", name, current.Hex())
+	}
+	registrarAddr, err := c.ens.Owner(NameHash(uri.NameDomain))
+	if err != nil {
+		return err
+	}
+	registrar, err := contract.NewKORDRegistrarTransactor(registrarAddr, c)
+	if err != nil {
+		return err
+	}
+	label := crypto.Keccak256Hash([]byte(strings.TrimSuffix(name, "."+uri.NameDomain)))
+	if _, err := c.do(func() (*types.Transaction, error) {
+		return registrar.Register(c.transactOpts, label, kordID, sig)
+	}); err != nil {
+		return fmt.Errorf("error registering KORD name %s: %s", name, err)
+	}
+	return nil
+}
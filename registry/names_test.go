@@ -0,0 +1,119 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package registry
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ens "github.com/ethereum/go-ethereum/contracts/ens/contract"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kord-network/go-kord/pkg/uri"
+	"github.com/kord-network/go-kord/registry/contract"
+)
+
+// TestRegistrar tests that the KORD registrar assigns names to the KORD IDs
+// which sign them, and only to the first.
+func TestRegistrar(t *testing.T) {
+	auth, backend, do := newSimulatedBackend(t)
+
+	// deploy the ENS registry, resolver and registrar as DeployNames does
+	ensAddr, tx, ensContract, err := ens.DeployENS(auth, backend)
+	if err := do(tx, err); err != nil {
+		t.Fatal(err)
+	}
+	resolverAddr, tx, resolver, err := ens.DeployPublicResolver(auth, backend, ensAddr)
+	if err := do(tx, err); err != nil {
+		t.Fatal(err)
+	}
+	root := NameHash(uri.NameDomain)
+	registrarAddr, tx, registrar, err := contract.DeployKORDRegistrar(auth, backend, ensAddr, root)
+	if err := do(tx, err); err != nil {
+		t.Fatal(err)
+	}
+	rootLabel := crypto.Keccak256Hash([]byte(uri.NameDomain))
+	if err := do(ensContract.SetSubnodeOwner(auth, common.Hash{}, rootLabel, auth.From)); err != nil {
+		t.Fatal(err)
+	}
+	if err := do(ensContract.SetResolver(auth, root, resolverAddr)); err != nil {
+		t.Fatal(err)
+	}
+	if err := do(ensContract.SetSubnodeOwner(auth, common.Hash{}, rootLabel, registrarAddr)); err != nil {
+		t.Fatal(err)
+	}
+
+	name := "jaak." + uri.NameDomain
+	node := NameHash(name)
+	label := crypto.Keccak256Hash([]byte("jaak"))
+	register := func(kordID common.Address, signer *ecdsa.PrivateKey, hash common.Hash) error {
+		sig, err := crypto.Sign(hash[:], signer)
+		if err != nil {
+			return err
+		}
+		return do(registrar.Register(auth, label, kordID, sig))
+	}
+	idKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := crypto.PubkeyToAddress(idKey.PublicKey)
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := crypto.PubkeyToAddress(otherKey.PublicKey)
+
+	// check names are not registered with signatures of other hashes or
+	// by other KORD IDs
+	if err := register(id, idKey, node); err == nil {
+		t.Fatal("expected registering with a signature of the namehash to fail")
+	}
+	if err := register(id, otherKey, NameSigHash(name, id)); err == nil {
+		t.Fatal("expected registering with another KORD ID's signature to fail")
+	}
+
+	// check the name is assigned to the KORD ID and resolves to it
+	if err := register(id, idKey, NameSigHash(name, id)); err != nil {
+		t.Fatal(err)
+	}
+	owner, err := ensContract.Owner(nil, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner != id {
+		t.Fatalf("expected %s to be owned by %s, got %s", name, id.Hex(), owner.Hex())
+	}
+	addr, err := resolver.Addr(nil, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != id {
+		t.Fatalf("expected %s to resolve to %s, got %s", name, id.Hex(), addr.Hex())
+	}
+
+	// check the name cannot be registered again
+	if err := register(other, otherKey, NameSigHash(name, other)); err == nil {
+		t.Fatal("expected registering a taken name to fail")
+	}
+	if err := register(id, idKey, NameSigHash(name, id)); err == nil {
+		t.Fatal("expected registering a name twice to fail")
+	}
+}
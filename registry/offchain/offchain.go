@@ -0,0 +1,253 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+// Package offchain implements a KORD registry which stores signed graph
+// records in a local database and gossips them between nodes rather than
+// storing them in the KORD registry contract.
+package offchain
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/kord-network/go-kord/registry"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+type Registry struct {
+	db *leveldb.DB
+
+	// mtx serialises record updates
+	mtx sync.Mutex
+
+	subs   map[common.Address]map[*subscription]struct{}
+	subMtx sync.Mutex
+
+	peers   map[*peer]struct{}
+	peerMtx sync.RWMutex
+}
+
+// New returns an off-chain registry which stores records in a LevelDB
+// database in the given directory.
+func New(dir string) (*Registry, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Registry{
+		db:    db,
+		subs:  make(map[common.Address]map[*subscription]struct{}),
+		peers: make(map[*peer]struct{}),
+	}, nil
+}
+
+func (r *Registry) Close() error {
+	return r.db.Close()
+}
+
+func (r *Registry) Graph(kordID common.Address) (common.Hash, error) {
+	record, err := r.Record(kordID)
+	if err != nil || record == nil {
+		return common.Hash{}, err
+	}
+	return record.Hash, nil
+}
+
+// SetGraph is not supported, since a record's signature must cover its
+// nonce and a signed graph hash does not, so use SetRecord instead.
+func (r *Registry) SetGraph(hash common.Hash, sig []byte) error {
+	return registry.ErrRecordRequired
+}
+
+// SetRecord stores a record signed by its KORD ID, which must be newer
+// than the current record, and gossips it to connected peers.
+func (r *Registry) SetRecord(record *registry.Record) error {
+	if err := record.Verify(); err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	current, err := r.Record(record.KordID)
+	if err != nil {
+		r.mtx.Unlock()
+		return err
+	}
+	if !record.Newer(current) {
+		r.mtx.Unlock()
+		return fmt.Errorf("offchain: record with nonce %d is not newer than the current record with nonce %d", record.Nonce, current.Nonce)
+	}
+	err = r.putRecord(record)
+	r.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+	r.broadcast(record, nil)
+	return nil
+}
+
+// Record returns the current record for the KORD ID, or nil if there is
+// no record.
+func (r *Registry) Record(kordID common.Address) (*registry.Record, error) {
+	data, err := r.db.Get(kordID[:], nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	record := &registry.Record{}
+	if err := rlp.DecodeBytes(data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Records returns all records stored in the registry.
+func (r *Registry) Records() ([]*registry.Record, error) {
+	iter := r.db.NewIterator(nil, nil)
+	defer iter.Release()
+	var records []*registry.Record
+	for iter.Next() {
+		record := &registry.Record{}
+		if err := rlp.DecodeBytes(iter.Value(), record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, iter.Error()
+}
+
+// AddRecord verifies and stores a record received from another node,
+// returning whether the record superseded the current record.
+func (r *Registry) AddRecord(record *registry.Record) (bool, error) {
+	if err := record.Verify(); err != nil {
+		return false, err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	current, err := r.Record(record.KordID)
+	if err != nil {
+		return false, err
+	}
+	if !record.Newer(current) {
+		return false, nil
+	}
+	return true, r.putRecord(record)
+}
+
+func (r *Registry) putRecord(record *registry.Record) error {
+	data, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return err
+	}
+	if err := r.db.Put(record.KordID[:], data, nil); err != nil {
+		return err
+	}
+	log.Debug("updated off-chain registry record", "id", record.KordID, "hash", record.Hash, "nonce", record.Nonce)
+	r.notify(record)
+	return nil
+}
+
+func (r *Registry) SubscribeGraph(kordID common.Address, updates chan common.Hash) (registry.Subscription, error) {
+	sub := &subscription{
+		registry: r,
+		kordID:   kordID,
+		updates:  updates,
+		pending:  make(chan common.Hash, 1),
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	r.subMtx.Lock()
+	subs, ok := r.subs[kordID]
+	if !ok {
+		subs = make(map[*subscription]struct{})
+		r.subs[kordID] = subs
+	}
+	subs[sub] = struct{}{}
+	r.subMtx.Unlock()
+	go sub.loop()
+	return sub, nil
+}
+
+func (r *Registry) notify(record *registry.Record) {
+	r.subMtx.Lock()
+	defer r.subMtx.Unlock()
+	for sub := range r.subs[record.KordID] {
+		sub.send(record.Hash)
+	}
+}
+
+// subscription delivers the latest hash of a KORD ID to a subscriber
+// without blocking registry updates on slow subscribers.
+type subscription struct {
+	registry  *Registry
+	kordID    common.Address
+	updates   chan common.Hash
+	pending   chan common.Hash
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+func (s *subscription) send(hash common.Hash) {
+	for {
+		select {
+		case s.pending <- hash:
+			return
+		default:
+		}
+		// drop the stale pending hash in favour of the new one
+		select {
+		case <-s.pending:
+		default:
+		}
+	}
+}
+
+func (s *subscription) loop() {
+	defer close(s.done)
+	for {
+		select {
+		case hash := <-s.pending:
+			select {
+			case s.updates <- hash:
+			case <-s.closed:
+				return
+			}
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+func (s *subscription) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.registry.subMtx.Lock()
+		delete(s.registry.subs[s.kordID], s)
+		s.registry.subMtx.Unlock()
+	})
+	<-s.done
+	return nil
+}
+
+func (s *subscription) Err() error {
+	return nil
+}
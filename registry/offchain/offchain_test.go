@@ -0,0 +1,234 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package offchain
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/kord-network/go-kord/registry"
+)
+
+func TestGossip(t *testing.T) {
+	r1, cleanup := newTestRegistry(t)
+	defer cleanup()
+	r2, cleanup := newTestRegistry(t)
+	defer cleanup()
+
+	// set a graph hash before the registries are connected so that it
+	// gets synced when they connect
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kordID := crypto.PubkeyToAddress(key.PublicKey)
+	hash1 := common.HexToHash("0x01")
+	record1 := newRecord(t, key, hash1, 1)
+	if err := r1.SetRecord(record1); err != nil {
+		t.Fatal(err)
+	}
+
+	// subscribe to updates on the second registry
+	updates := make(chan common.Hash)
+	sub, err := r2.SubscribeGraph(kordID, updates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	// connect the registries
+	rw1, rw2 := p2p.MsgPipe()
+	defer rw1.Close()
+	defer rw2.Close()
+	go r1.runPeer(p2p.NewPeer(discover.NodeID{1}, "r1", nil), rw1)
+	go r2.runPeer(p2p.NewPeer(discover.NodeID{2}, "r2", nil), rw2)
+	expectUpdate(t, updates, hash1)
+
+	// check new records are gossiped
+	hash2 := common.HexToHash("0x02")
+	if err := r1.SetRecord(newRecord(t, key, hash2, 2)); err != nil {
+		t.Fatal(err)
+	}
+	expectUpdate(t, updates, hash2)
+	record, err := r2.Record(kordID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Nonce != 2 {
+		t.Fatalf("expected record to have nonce 2, got %d", record.Nonce)
+	}
+
+	// check stale and forged records are rejected
+	if updated, err := r2.AddRecord(record1); err != nil || updated {
+		t.Fatalf("expected stale record to be ignored, got updated=%t err=%v", updated, err)
+	}
+	if err := r1.SetRecord(record1); err == nil {
+		t.Fatal("expected setting a stale record to fail")
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash3 := common.HexToHash("0x03")
+	if _, err := r2.AddRecord(&registry.Record{
+		KordID: kordID,
+		Hash:   hash3,
+		Nonce:  3,
+		Sig:    newRecord(t, other, hash3, 3).Sig,
+	}); err == nil {
+		t.Fatal("expected forged record to be rejected")
+	}
+}
+
+// TestReplay checks that an old record signature cannot be replayed with a
+// higher nonce to roll back the graph hash of a KORD ID.
+func TestReplay(t *testing.T) {
+	r, cleanup := newTestRegistry(t)
+	defer cleanup()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kordID := crypto.PubkeyToAddress(key.PublicKey)
+	hash1 := common.HexToHash("0x01")
+	record1 := newRecord(t, key, hash1, 1)
+	if err := r.SetRecord(record1); err != nil {
+		t.Fatal(err)
+	}
+	hash2 := common.HexToHash("0x02")
+	if err := r.SetRecord(newRecord(t, key, hash2, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// replay the first record with the maximum nonce
+	replayed := &registry.Record{
+		KordID: kordID,
+		Hash:   hash1,
+		Nonce:  math.MaxUint64,
+		Sig:    record1.Sig,
+	}
+	if updated, err := r.AddRecord(replayed); err == nil || updated {
+		t.Fatalf("expected replayed record to be rejected, got updated=%t err=%v", updated, err)
+	}
+	if err := r.SetRecord(replayed); err == nil {
+		t.Fatal("expected setting a replayed record to fail")
+	}
+
+	// a graph hash signed without a nonce is also rejected
+	if err := r.SetGraph(hash1, sign(t, key, hash1)); err == nil {
+		t.Fatal("expected SetGraph to fail")
+	}
+
+	// check the KORD ID can still update its graph
+	hash3 := common.HexToHash("0x03")
+	if err := r.SetRecord(newRecord(t, key, hash3, 3)); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := r.Graph(kordID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != hash3 {
+		t.Fatalf("expected graph hash %s, got %s", hash3.Hex(), hash.Hex())
+	}
+}
+
+// TestSetRecordTieBreak checks that local records are accepted using the
+// same rule as records from peers, so a record with the same nonce and a
+// higher hash replaces the current record.
+func TestSetRecordTieBreak(t *testing.T) {
+	r, cleanup := newTestRegistry(t)
+	defer cleanup()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kordID := crypto.PubkeyToAddress(key.PublicKey)
+	hash1 := common.HexToHash("0x01")
+	hash2 := common.HexToHash("0x02")
+	if err := r.SetRecord(newRecord(t, key, hash2, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetRecord(newRecord(t, key, hash1, 1)); err == nil {
+		t.Fatal("expected record with the same nonce and a lower hash to be rejected")
+	}
+	hash3 := common.HexToHash("0x03")
+	if err := r.SetRecord(newRecord(t, key, hash3, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if hash, err := r.Graph(kordID); err != nil || hash != hash3 {
+		t.Fatalf("expected graph hash %s, got %s (err: %v)", hash3.Hex(), hash.Hex(), err)
+	}
+}
+
+func newTestRegistry(t *testing.T) (*Registry, func()) {
+	dir, err := ioutil.TempDir("", "kord-offchain-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return r, func() {
+		r.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func newRecord(t *testing.T, key *ecdsa.PrivateKey, hash common.Hash, nonce uint64) *registry.Record {
+	record, err := registry.NewRecord(crypto.PubkeyToAddress(key.PublicKey), hash, nonce, func(h common.Hash) ([]byte, error) {
+		return crypto.Sign(h[:], key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, hash common.Hash) []byte {
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func expectUpdate(t *testing.T, updates chan common.Hash, expected common.Hash) {
+	select {
+	case hash := <-updates:
+		if hash != expected {
+			t.Fatalf("expected update %s, got %s", expected.Hex(), hash.Hex())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for update %s", expected.Hex())
+	}
+}
@@ -0,0 +1,137 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package offchain
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/kord-network/go-kord/registry"
+)
+
+const (
+	protocolName    = "kordreg"
+	protocolVersion = 1
+
+	// recordsMsg contains a list of registry records
+	recordsMsg = 0x00
+
+	protocolLength = 1
+
+	// maxRecordsPerMsg is the maximum number of records sent in a
+	// single message when syncing with a new peer
+	maxRecordsPerMsg = 256
+)
+
+// Protocol returns the devp2p protocol used to gossip records between
+// nodes, which syncs all records when a peer connects and then forwards
+// any new records it receives.
+func (r *Registry) Protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    protocolName,
+		Version: protocolVersion,
+		Length:  protocolLength,
+		Run:     r.runPeer,
+	}
+}
+
+type peer struct {
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+}
+
+func (r *Registry) runPeer(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	peer := &peer{p, rw}
+	r.peerMtx.Lock()
+	r.peers[peer] = struct{}{}
+	r.peerMtx.Unlock()
+	defer func() {
+		r.peerMtx.Lock()
+		delete(r.peers, peer)
+		r.peerMtx.Unlock()
+	}()
+
+	// send the peer all our records
+	records, err := r.Records()
+	if err != nil {
+		return err
+	}
+	for len(records) > 0 {
+		n := len(records)
+		if n > maxRecordsPerMsg {
+			n = maxRecordsPerMsg
+		}
+		if err := p2p.Send(rw, recordsMsg, records[:n]); err != nil {
+			return err
+		}
+		records = records[n:]
+	}
+
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if err := r.handleMsg(peer, msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Registry) handleMsg(peer *peer, msg p2p.Msg) error {
+	defer msg.Discard()
+	switch msg.Code {
+	case recordsMsg:
+		var records []*registry.Record
+		if err := msg.Decode(&records); err != nil {
+			return fmt.Errorf("error decoding records: %s", err)
+		}
+		for _, record := range records {
+			updated, err := r.AddRecord(record)
+			if err != nil {
+				return fmt.Errorf("invalid record from peer: %s", err)
+			}
+			if updated {
+				r.broadcast(record, peer)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown message code: %d", msg.Code)
+	}
+}
+
+// broadcast sends the record to all connected peers except the one it was
+// received from.
+func (r *Registry) broadcast(record *registry.Record, from *peer) {
+	r.peerMtx.RLock()
+	defer r.peerMtx.RUnlock()
+	for p := range r.peers {
+		if p == from {
+			continue
+		}
+		go func(p *peer) {
+			if err := p2p.Send(p.rw, recordsMsg, []*registry.Record{record}); err != nil {
+				log.Debug("error sending registry record", "peer", p.ID(), "err", err)
+			}
+		}(p)
+	}
+}
@@ -0,0 +1,159 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// HeaderFunc returns a trusted block header.
+type HeaderFunc func(ctx context.Context) (*types.Header, error)
+
+// ProofVerifier is a Registry which reads graph hashes from the storage of
+// the registry contract using eth_getProof, verifying the returned Merkle
+// proofs against the state root of a trusted block header so that the
+// Ethereum node serving the proofs need not be trusted.
+//
+// The Ethereum node must support eth_getProof as defined in EIP-1186.
+type ProofVerifier struct {
+	Registry
+
+	client       *rpc.Client
+	contractAddr common.Address
+	header       HeaderFunc
+}
+
+func NewProofVerifier(registry Registry, client *rpc.Client, contractAddr common.Address, header HeaderFunc) *ProofVerifier {
+	return &ProofVerifier{
+		Registry:     registry,
+		client:       client,
+		contractAddr: contractAddr,
+		header:       header,
+	}
+}
+
+type accountProof struct {
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	StorageProof []storageProof  `json:"storageProof"`
+}
+
+type storageProof struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+func (p *ProofVerifier) Graph(kordID common.Address) (common.Hash, error) {
+	ctx := context.Background()
+	header, err := p.header(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting trusted header: %s", err)
+	}
+	slot := graphSlot(kordID)
+	var proof accountProof
+	if err := p.client.CallContext(
+		ctx,
+		&proof,
+		"eth_getProof",
+		p.contractAddr,
+		[]string{slot.Hex()},
+		hexutil.EncodeBig(header.Number),
+	); err != nil {
+		return common.Hash{}, err
+	}
+
+	// verify the account proof against the trusted state root
+	value, err := verifyProof(header.Root, crypto.Keccak256(p.contractAddr[:]), proof.AccountProof)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid account proof: %s", err)
+	}
+	if len(value) == 0 {
+		return common.Hash{}, errors.New("invalid account proof: registry contract does not exist")
+	}
+	var account state.Account
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid account proof: %s", err)
+	}
+	if account.Root != proof.StorageHash {
+		return common.Hash{}, errors.New("invalid account proof: storage hash mismatch")
+	}
+
+	// verify the storage proof against the account's storage root
+	if len(proof.StorageProof) != 1 {
+		return common.Hash{}, fmt.Errorf("expected 1 storage proof, got %d", len(proof.StorageProof))
+	}
+	value, err = verifyProof(account.Root, crypto.Keccak256(slot[:]), proof.StorageProof[0].Proof)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid storage proof: %s", err)
+	}
+	var hash common.Hash
+	if len(value) > 0 {
+		var content []byte
+		if err := rlp.DecodeBytes(value, &content); err != nil {
+			return common.Hash{}, fmt.Errorf("invalid storage proof: %s", err)
+		}
+		hash = common.BytesToHash(content)
+	}
+	if claimed := proof.StorageProof[0].Value; claimed != nil && (*big.Int)(claimed).Cmp(hash.Big()) != 0 {
+		return common.Hash{}, errors.New("invalid storage proof: value mismatch")
+	}
+	return hash, nil
+}
+
+// graphSlot returns the storage slot of the KORD ID's entry in the graphs
+// mapping, which is the first state variable of the registry contract.
+func graphSlot(kordID common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		common.LeftPadBytes(kordID[:], 32),
+		common.LeftPadBytes(nil, 32),
+	)
+}
+
+// emptyRoot is the root hash of an empty trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+func verifyProof(root common.Hash, key []byte, proof []hexutil.Bytes) ([]byte, error) {
+	if root == emptyRoot {
+		return nil, nil
+	}
+	db, _ := ethdb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	value, err, _ := trie.VerifyProof(root, key, db)
+	return value, err
+}
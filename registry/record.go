@@ -0,0 +1,137 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package registry
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrRecordsUnsupported is returned when setting a signed record in a
+// registry which only supports signed graph hashes.
+var ErrRecordsUnsupported = errors.New("registry: signed records are not supported")
+
+// ErrRecordRequired is returned when setting a signed graph hash in a
+// registry which only accepts records, whose signature covers a nonce.
+var ErrRecordRequired = errors.New("registry: graph hashes must be set using records signed with a nonce")
+
+// Record is a signed update of the graph hash of a KORD ID.
+type Record struct {
+	KordID common.Address
+	Hash   common.Hash
+	Nonce  uint64
+	Sig    []byte
+}
+
+// NewRecord returns a record of the graph hash of the KORD ID signed using
+// the sign function.
+func NewRecord(kordID common.Address, hash common.Hash, nonce uint64, sign func(common.Hash) ([]byte, error)) (*Record, error) {
+	r := &Record{
+		KordID: kordID,
+		Hash:   hash,
+		Nonce:  nonce,
+	}
+	sig, err := sign(r.SigHash())
+	if err != nil {
+		return nil, err
+	}
+	r.Sig = sig
+	return r, nil
+}
+
+// SigHash returns the hash signed by the KORD ID, which covers the nonce so
+// that old records cannot be replayed with a different nonce.
+func (r *Record) SigHash() common.Hash {
+	return RecordHash(r.KordID, r.Hash, r.Nonce)
+}
+
+// RecordHash returns the hash which a KORD ID signs to set its graph hash
+// with the given nonce.
+func RecordHash(kordID common.Address, hash common.Hash, nonce uint64) common.Hash {
+	n := make([]byte, 8)
+	binary.BigEndian.PutUint64(n, nonce)
+	return crypto.Keccak256Hash(kordID[:], hash[:], n)
+}
+
+// Verify checks the record signature is a signature of the record's
+// SigHash by the record's KORD ID.
+func (r *Record) Verify() error {
+	kordID, err := RecoverKordID(r.SigHash(), r.Sig)
+	if err != nil {
+		return err
+	}
+	if kordID != r.KordID {
+		return errors.New("registry: record not signed by KORD ID")
+	}
+	return nil
+}
+
+// Newer reports whether the record supersedes the given record, with ties
+// between equal nonces broken by comparing hashes so that nodes gossiping
+// records converge on the same value. Both the off-chain registry and the
+// KORD registry contract only accept records which are newer than the
+// current record.
+func (r *Record) Newer(other *Record) bool {
+	if other == nil || r.Nonce != other.Nonce {
+		return other == nil || r.Nonce > other.Nonce
+	}
+	return r.Hash.Big().Cmp(other.Hash.Big()) > 0
+}
+
+// RecordSetter is implemented by registries which store signed records
+// rather than signed graph hashes.
+type RecordSetter interface {
+	SetRecord(record *Record) error
+}
+
+// SetRecord sets the record in the registry, returning
+// ErrRecordsUnsupported if the registry does not store signed records.
+func SetRecord(r Registry, record *Record) error {
+	if setter, ok := r.(RecordSetter); ok {
+		return setter.SetRecord(record)
+	}
+	return ErrRecordsUnsupported
+}
+
+// RecoverKordID recovers the KORD ID which signed the given graph hash,
+// applying the same signature rules as the KORD registry contract.
+func RecoverKordID(hash common.Hash, sig []byte) (common.Address, error) {
+	if len(sig) != 65 {
+		return common.Address{}, errors.New("registry: invalid signature length")
+	}
+	v := sig[64]
+	if v < 27 {
+		v += 27
+	}
+	if v != 27 && v != 28 {
+		return common.Address{}, errors.New("registry: invalid signature recovery id")
+	}
+	normalized := make([]byte, 65)
+	copy(normalized, sig)
+	normalized[64] = v - 27
+	pub, err := crypto.SigToPub(hash[:], normalized)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -24,26 +24,32 @@ import (
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"math/big"
 	"sync"
+	"time"
 
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	ens "github.com/ethereum/go-ethereum/contracts/ens/contract"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/kord-network/go-kord/registry/contract"
 )
 
 //go:generate abigen --sol ../contracts/KORDRegistry.sol --pkg contract --out contract/registry.go
+//go:generate abigen --sol ../contracts/KORDRegistrar.sol --pkg contract --out contract/registrar.go
 
 var (
 	DevKey          = mustKey("476e921a198fd2744f270da0bb80dce2dab24e9105473d9bb19e540fcbd04bb0")
 	DevAddr         = crypto.PubkeyToAddress(DevKey.PublicKey)
 	DevContractAddr = common.HexToAddress("0x241be96854Fc2f0172dAA660EE7A14410957C15d")
+	DevENSAddr      = common.HexToAddress("0xD277b08f085121d287878A991e0C496488AAaEc6")
 )
 
 type Registry interface {
@@ -60,17 +66,20 @@ type Subscription interface {
 type Config struct {
 	Key          *ecdsa.PrivateKey
 	ContractAddr common.Address
+	ENSAddr      common.Address
 }
 
 var DefaultConfig = Config{
 	Key:          DevKey,
 	ContractAddr: DevContractAddr,
+	ENSAddr:      DevENSAddr,
 }
 
 type Client struct {
 	*ethclient.Client
 
 	registry     *contract.KORDRegistrySession
+	ens          *ens.ENSSession
 	blocks       event.Feed
 	transactOpts *bind.TransactOpts
 	closed       chan struct{}
@@ -100,9 +109,19 @@ func NewClient(client *rpc.Client, config Config) (*Client, error) {
 		TransactOpts: *transactOpts,
 	}
 
+	ensContract, err := ens.NewENS(config.ENSAddr, ethClient)
+	if err != nil {
+		return nil, err
+	}
+	ensSession := &ens.ENSSession{
+		Contract:     ensContract,
+		TransactOpts: *transactOpts,
+	}
+
 	c := &Client{
 		Client:       ethClient,
 		registry:     session,
+		ens:          ensSession,
 		transactOpts: transactOpts,
 		closed:       make(chan struct{}),
 	}
@@ -117,24 +136,98 @@ func (c *Client) Graph(kordID common.Address) (common.Hash, error) {
 	return c.registry.Graph(kordID)
 }
 
+// SetGraph is not supported, since the registry contract requires the
+// signature to cover a nonce, so use SetRecord instead.
 func (c *Client) SetGraph(graph common.Hash, sig []byte) error {
-	return c.setGraph(graph, sig)
+	return ErrRecordRequired
 }
 
+// SetRecord sets the graph hash of the record's KORD ID in the registry
+// contract, which rejects records which are not newer than the current
+// record of the KORD ID.
+func (c *Client) SetRecord(record *Record) error {
+	if err := record.Verify(); err != nil {
+		return err
+	}
+	_, err := c.do(func() (*types.Transaction, error) {
+		return c.registry.SetGraph(record.KordID, record.Hash, record.Nonce, record.Sig)
+	})
+	return err
+}
+
+// SubscribeGraph sends updates of the KORD ID's graph hash to the updates
+// channel, checking the registry contract for changes on each new block
+// since the contract does not emit events.
 func (c *Client) SubscribeGraph(kordID common.Address, updates chan common.Hash) (Subscription, error) {
-	// TODO
-	return nil, nil
+	current, err := c.Graph(kordID)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(chan *types.Header)
+	blockSub := c.blocks.Subscribe(heads)
+	sub := &graphSubscription{
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(sub.done)
+		defer blockSub.Unsubscribe()
+		for {
+			select {
+			case <-heads:
+				hash, err := c.Graph(kordID)
+				if err != nil {
+					log.Warn("error getting graph from registry", "id", kordID, "err", err)
+					continue
+				}
+				if hash == current {
+					continue
+				}
+				current = hash
+				select {
+				case updates <- hash:
+				case <-sub.closed:
+					return
+				case <-c.closed:
+					return
+				}
+			case err := <-blockSub.Err():
+				sub.err = err
+				return
+			case <-sub.closed:
+				return
+			case <-c.closed:
+				return
+			}
+		}
+	}()
+	return sub, nil
 }
 
-func (c *Client) Close() {
-	c.closeOnce.Do(func() { close(c.closed) })
+type graphSubscription struct {
+	err       error
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
 }
 
-func (c *Client) setGraph(hash common.Hash, sig []byte) error {
-	_, err := c.do(func() (*types.Transaction, error) {
-		return c.registry.SetGraph(hash, sig)
-	})
-	return err
+func (s *graphSubscription) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	<-s.done
+	return nil
+}
+
+func (s *graphSubscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+func (c *Client) Close() {
+	c.closeOnce.Do(func() { close(c.closed) })
 }
 
 const blockTimeout = 50
@@ -173,10 +266,20 @@ func (c *Client) do(f func() (*types.Transaction, error)) (*types.Receipt, error
 	}
 }
 
+// blockPollInterval is how often the latest block is requested from
+// Ethereum endpoints which do not support subscriptions, such as HTTP
+// endpoints.
+var blockPollInterval = time.Second
+
+// subscribeBlocks sends new blocks to the blocks feed, polling for them if
+// the Ethereum endpoint does not support subscriptions.
 func (c *Client) subscribeBlocks() error {
 	heads := make(chan *types.Header)
 	sub, err := c.SubscribeNewHead(context.Background(), heads)
-	if err != nil {
+	if err == rpc.ErrNotificationsUnsupported {
+		go c.pollBlocks()
+		return nil
+	} else if err != nil {
 		return err
 	}
 	go func() {
@@ -196,6 +299,28 @@ func (c *Client) subscribeBlocks() error {
 	return nil
 }
 
+// pollBlocks sends the latest block to the blocks feed each time it
+// changes until the client is closed.
+func (c *Client) pollBlocks() {
+	ticker := time.NewTicker(blockPollInterval)
+	defer ticker.Stop()
+	var number *big.Int
+	for {
+		head, err := c.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			log.Warn("error getting latest block", "err", err)
+		} else if number == nil || head.Number.Cmp(number) > 0 {
+			number = head.Number
+			c.blocks.Send(head)
+		}
+		select {
+		case <-ticker.C:
+		case <-c.closed:
+			return
+		}
+	}
+}
+
 func mustKey(hex string) *ecdsa.PrivateKey {
 	key, err := crypto.HexToECDSA(hex)
 	if err != nil {
@@ -0,0 +1,459 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/kord-network/go-kord/registry/contract"
+)
+
+func TestCache(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kordID := crypto.PubkeyToAddress(key.PublicKey)
+	reg := newTestRegistry()
+	cache := NewCache(reg)
+	defer cache.Close()
+
+	// check the hash is only read from the registry once
+	hash := common.HexToHash("0x01")
+	reg.setGraph(kordID, hash, false)
+	for i := 0; i < 3; i++ {
+		got, err := cache.Graph(kordID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != hash {
+			t.Fatalf("expected hash %s, got %s", hash.Hex(), got.Hex())
+		}
+	}
+	if reg.calls != 1 {
+		t.Fatalf("expected 1 registry call, got %d", reg.calls)
+	}
+
+	// check an update event invalidates the cache, which happens
+	// asynchronously
+	hash = common.HexToHash("0x02")
+	reg.setGraph(kordID, hash, true)
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		got, err := cache.Graph(kordID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == hash {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("timed out waiting for hash %s, got %s", hash.Hex(), got.Hex())
+		}
+	}
+
+	// check setting the graph through the cache invalidates it
+	hash = common.HexToHash("0x03")
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SetGraph(hash, sig); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.Graph(kordID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != hash {
+		t.Fatalf("expected hash %s, got %s", hash.Hex(), got.Hex())
+	}
+
+	// check a failed subscription drops the cached hash and resubscribes
+	hash = common.HexToHash("0x04")
+	reg.setGraph(kordID, hash, false)
+	reg.failSubscriptions(kordID, errors.New("subscription failed"))
+	if got, err := cache.Graph(kordID); err != nil || got != hash {
+		t.Fatalf("expected hash %s, got %s (err: %v)", hash.Hex(), got.Hex(), err)
+	}
+	if reg.subscribes != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", reg.subscribes)
+	}
+
+	// check expired hashes are read from the registry again
+	cache.TTL = time.Nanosecond
+	hash = common.HexToHash("0x05")
+	reg.setGraph(kordID, hash, false)
+	time.Sleep(time.Millisecond)
+	if got, err := cache.Graph(kordID); err != nil || got != hash {
+		t.Fatalf("expected hash %s, got %s (err: %v)", hash.Hex(), got.Hex(), err)
+	}
+	cache.TTL = DefaultCacheTTL
+
+	// check the least recently used entries are evicted once the cache
+	// is full
+	cache.Size = 1
+	other := common.HexToAddress("0x01")
+	if _, err := cache.Graph(other); err != nil {
+		t.Fatal(err)
+	}
+	cache.mtx.Lock()
+	_, cached := cache.entries[kordID]
+	size := len(cache.entries)
+	cache.mtx.Unlock()
+	if cached || size != 1 {
+		t.Fatalf("expected %s to be evicted leaving 1 entry, got %d entries", kordID.Hex(), size)
+	}
+}
+
+func TestProofVerifier(t *testing.T) {
+	kordID := common.HexToAddress("0xba9CA0f65Fb0D4B77ae8c44cCaC7D92EC0D55e88")
+	contractAddr := common.HexToAddress("0x241be96854Fc2f0172dAA660EE7A14410957C15d")
+	hash := crypto.Keccak256Hash([]byte("graph"))
+
+	// build a state containing the registry contract storage
+	diskdb, _ := ethdb.NewMemDatabase()
+	triedb := trie.NewDatabase(diskdb)
+	storage, err := trie.NewSecure(common.Hash{}, triedb, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot := graphSlot(kordID)
+	value, err := rlp.EncodeToBytes(hash.Big().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Update(slot[:], value)
+	storageRoot, err := storage.Commit(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := trie.NewSecure(common.Hash{}, triedb, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := rlp.EncodeToBytes(&state.Account{
+		Balance:  new(big.Int),
+		Root:     storageRoot,
+		CodeHash: crypto.Keccak256([]byte("code")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts.Update(contractAddr[:], account)
+	stateRoot, err := accounts.Commit(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// serve proofs from a fake Ethereum node
+	proofs := &FakeEthAPI{
+		proof: &accountProof{
+			Balance:      (*hexutil.Big)(new(big.Int)),
+			StorageHash:  storageRoot,
+			AccountProof: prove(t, accounts, contractAddr[:]),
+			StorageProof: []storageProof{{
+				Key:   slot.Hex(),
+				Value: (*hexutil.Big)(hash.Big()),
+				Proof: prove(t, storage, slot[:]),
+			}},
+		},
+	}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", proofs); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(srv)
+	defer client.Close()
+
+	root := stateRoot
+	verifier := NewProofVerifier(newTestRegistry(), client, contractAddr, func(context.Context) (*types.Header, error) {
+		return &types.Header{Number: big.NewInt(1), Root: root}, nil
+	})
+	got, err := verifier.Graph(kordID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != hash {
+		t.Fatalf("expected hash %s, got %s", hash.Hex(), got.Hex())
+	}
+
+	// check a proof against an untrusted state root fails
+	root = common.HexToHash("0x01")
+	if _, err := verifier.Graph(kordID); err == nil {
+		t.Fatal("expected proof against an untrusted root to fail")
+	}
+
+	// check a tampered value fails
+	root = stateRoot
+	proofs.proof.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(1))
+	if _, err := verifier.Graph(kordID); err == nil {
+		t.Fatal("expected tampered proof value to fail")
+	}
+}
+
+// TestRegistryContract tests that the KORD registry contract applies the
+// same rules as the off-chain registry when setting records.
+func TestRegistryContract(t *testing.T) {
+	auth, backend, do := newSimulatedBackend(t)
+	addr, tx, kordRegistry, err := contract.DeployKORDRegistry(auth, backend)
+	if err := do(tx, err); err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kordID := crypto.PubkeyToAddress(key.PublicKey)
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRecord := func(key *ecdsa.PrivateKey, hash common.Hash, nonce uint64) *Record {
+		record, err := NewRecord(kordID, hash, nonce, func(h common.Hash) ([]byte, error) {
+			return crypto.Sign(h[:], key)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return record
+	}
+	setRecord := func(record *Record) error {
+		return do(kordRegistry.SetGraph(auth, record.KordID, record.Hash, record.Nonce, record.Sig))
+	}
+	expect := func(hash common.Hash, nonce uint64) {
+		t.Helper()
+		if got, err := kordRegistry.Graph(nil, kordID); err != nil || common.Hash(got) != hash {
+			t.Fatalf("expected graph hash %s, got %s (err: %v)", hash.Hex(), common.Hash(got).Hex(), err)
+		}
+		if got, err := kordRegistry.Nonce(nil, kordID); err != nil || got != nonce {
+			t.Fatalf("expected nonce %d, got %d (err: %v)", nonce, got, err)
+		}
+	}
+
+	// check a record sets the graph hash in the slot read by the proof
+	// verifier
+	hash2 := common.HexToHash("0x02")
+	record := newRecord(key, hash2, 1)
+	if err := setRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	expect(hash2, 1)
+	value, err := backend.StorageAt(context.Background(), addr, graphSlot(kordID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if common.BytesToHash(value) != hash2 {
+		t.Fatalf("expected graph slot to contain %s, got %x", hash2.Hex(), value)
+	}
+
+	// check records which are not newer, or not signed by the KORD ID with
+	// the nonce, are rejected
+	hash1 := common.HexToHash("0x01")
+	hash3 := common.HexToHash("0x03")
+	for name, record := range map[string]*Record{
+		"replayed":    record,
+		"lower nonce": newRecord(key, hash3, 0),
+		"lower hash":  newRecord(key, hash1, 1),
+		"other key":   newRecord(otherKey, hash3, 2),
+		"short sig":   {KordID: kordID, Hash: hash3, Nonce: 2, Sig: newRecord(key, hash3, 2).Sig[:64]},
+	} {
+		if err := setRecord(record); err == nil {
+			t.Fatalf("expected %s record to be rejected", name)
+		}
+	}
+	sig, err := crypto.Sign(hash3[:], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setRecord(&Record{KordID: kordID, Hash: hash3, Nonce: 2, Sig: sig}); err == nil {
+		t.Fatal("expected record signed without its nonce to be rejected")
+	}
+	expect(hash2, 1)
+
+	// check an equal nonce with a higher hash wins, as it does for
+	// Record.Newer
+	if err := setRecord(newRecord(key, hash3, 1)); err != nil {
+		t.Fatal(err)
+	}
+	expect(hash3, 1)
+	if err := setRecord(newRecord(key, hash1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	expect(hash1, 2)
+}
+
+func prove(t *testing.T, tr *trie.SecureTrie, key []byte) []hexutil.Bytes {
+	db, _ := ethdb.NewMemDatabase()
+	if err := tr.Prove(key, 0, db); err != nil {
+		t.Fatal(err)
+	}
+	var proof []hexutil.Bytes
+	for _, k := range db.Keys() {
+		node, _ := db.Get(k)
+		proof = append(proof, node)
+	}
+	return proof
+}
+
+type FakeEthAPI struct {
+	proof *accountProof
+}
+
+func (api *FakeEthAPI) GetProof(addr common.Address, keys []string, block string) (json.RawMessage, error) {
+	return json.Marshal(api.proof)
+}
+
+type testRegistry struct {
+	mtx        sync.Mutex
+	hashes     map[common.Address]common.Hash
+	subs       map[common.Address][]*testSubscription
+	calls      int
+	subscribes int
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{
+		hashes: make(map[common.Address]common.Hash),
+		subs:   make(map[common.Address][]*testSubscription),
+	}
+}
+
+func (r *testRegistry) setGraph(kordID common.Address, hash common.Hash, notify bool) {
+	r.mtx.Lock()
+	r.hashes[kordID] = hash
+	subs := r.subs[kordID]
+	r.mtx.Unlock()
+	if notify {
+		for _, sub := range subs {
+			sub.updates <- hash
+		}
+	}
+}
+
+// failSubscriptions makes the subscriptions to the KORD ID's graph fail
+// without sending any more updates.
+func (r *testRegistry) failSubscriptions(kordID common.Address, err error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, sub := range r.subs[kordID] {
+		sub.err = err
+	}
+	delete(r.subs, kordID)
+}
+
+func (r *testRegistry) Graph(kordID common.Address) (common.Hash, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.calls++
+	return r.hashes[kordID], nil
+}
+
+func (r *testRegistry) SetGraph(hash common.Hash, sig []byte) error {
+	kordID, err := RecoverKordID(hash, sig)
+	if err != nil {
+		return err
+	}
+	r.setGraph(kordID, hash, false)
+	return nil
+}
+
+func (r *testRegistry) SubscribeGraph(kordID common.Address, updates chan common.Hash) (Subscription, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	sub := &testSubscription{registry: r, kordID: kordID, updates: updates}
+	r.subs[kordID] = append(r.subs[kordID], sub)
+	r.subscribes++
+	return sub, nil
+}
+
+type testSubscription struct {
+	registry *testRegistry
+	kordID   common.Address
+	updates  chan common.Hash
+	err      error
+}
+
+func (s *testSubscription) Close() error {
+	r := s.registry
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	subs := r.subs[s.kordID]
+	for i, sub := range subs {
+		if sub == s {
+			r.subs[s.kordID] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *testSubscription) Err() error {
+	s.registry.mtx.Lock()
+	defer s.registry.mtx.Unlock()
+	return s.err
+}
+
+// newSimulatedBackend returns a simulated backend with a funded account and
+// a function which commits a transaction and checks it succeeded.
+func newSimulatedBackend(t *testing.T) (*bind.TransactOpts, *backends.SimulatedBackend, func(*types.Transaction, error) error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := bind.NewKeyedTransactor(key)
+	auth.GasLimit = 4000000
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		auth.From: {Balance: big.NewInt(1e18)},
+	})
+	do := func(tx *types.Transaction, err error) error {
+		if err != nil {
+			return err
+		}
+		backend.Commit()
+		receipt, err := backend.TransactionReceipt(context.Background(), tx.Hash())
+		if err != nil {
+			return err
+		}
+		if receipt.Status == types.ReceiptStatusFailed {
+			return errors.New("transaction failed")
+		}
+		return nil
+	}
+	return auth, backend, do
+}
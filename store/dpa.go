@@ -0,0 +1,71 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package store
+
+import (
+	"io"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// DPA is a ContentStore which uses the DPA of a local Swarm node.
+type DPA struct {
+	dpa *storage.DPA
+}
+
+func NewDPA(dpa *storage.DPA) *DPA {
+	return &DPA{dpa: dpa}
+}
+
+func (d *DPA) Store(r io.Reader) (common.Hash, error) {
+	r, size, cleanup, err := spool(r)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer cleanup()
+	key, err := d.dpa.Store(r, size, &sync.WaitGroup{}, &sync.WaitGroup{})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(key), nil
+}
+
+func (d *DPA) Retrieve(hash common.Hash) (ReadSeekCloser, error) {
+	reader := d.dpa.Retrieve(storage.Key(hash[:]))
+
+	// check the root chunk exists by retrieving the content's size, and
+	// limit reads to that size as the chunk reader does not handle reads
+	// past the end of the content
+	size, err := reader.Size(nil)
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{io.NewSectionReader(reader, 0, size)}, nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
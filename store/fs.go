@@ -0,0 +1,78 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package store
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FS is a ContentStore which stores content as files in a local directory,
+// named by the content's Swarm hash.
+type FS struct {
+	dir string
+}
+
+func NewFS(dir string) (*FS, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FS{dir: dir}, nil
+}
+
+func (f *FS) Store(r io.Reader) (common.Hash, error) {
+	tmp, err := ioutil.TempFile(f.dir, ".tmp-")
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return common.Hash{}, err
+	}
+	hash, err := Hash(tmp, size)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return common.Hash{}, err
+	}
+	if err := os.Rename(tmp.Name(), f.path(hash)); err != nil {
+		return common.Hash{}, err
+	}
+	return hash, nil
+}
+
+func (f *FS) Retrieve(hash common.Hash) (ReadSeekCloser, error) {
+	return os.Open(f.path(hash))
+}
+
+func (f *FS) path(hash common.Hash) string {
+	return filepath.Join(f.dir, strings.TrimPrefix(hash.Hex(), "0x"))
+}
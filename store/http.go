@@ -0,0 +1,170 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package store
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/swarm/api/client"
+)
+
+// HTTP is a ContentStore which uses a Swarm HTTP gateway.
+type HTTP struct {
+	gateway string
+	client  *client.Client
+}
+
+func NewHTTP(gateway string) *HTTP {
+	gateway = strings.TrimSuffix(gateway, "/")
+	return &HTTP{
+		gateway: gateway,
+		client:  client.NewClient(gateway),
+	}
+}
+
+func (h *HTTP) Store(r io.Reader) (common.Hash, error) {
+	r, size, cleanup, err := spool(r)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer cleanup()
+	hash, err := h.client.UploadRaw(r, size)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.HexToHash(hash), nil
+}
+
+// Retrieve retrieves the content from the gateway, which is not trusted to
+// return the correct content, so callers should verify it using Hash.
+func (h *HTTP) Retrieve(hash common.Hash) (ReadSeekCloser, error) {
+	url := h.gateway + "/bzz-raw:/" + strings.TrimPrefix(hash.Hex(), "0x")
+	return newRangeReader(func(offset int64) (*http.Response, error) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		setRange(req, offset)
+		return http.DefaultClient.Do(req)
+	})
+}
+
+// rangeReader is a ReadSeekCloser which reads content over HTTP, making a
+// range request to continue reading from the new offset after a seek.
+type rangeReader struct {
+	get    func(offset int64) (*http.Response, error)
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+// newRangeReader returns a rangeReader which makes requests using get,
+// making the first request immediately so that missing content is
+// reported by Retrieve.
+func newRangeReader(get func(offset int64) (*http.Response, error)) (*rangeReader, error) {
+	r := &rangeReader{get: get}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rangeReader) open() error {
+	res, err := r.get(r.offset)
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.StatusCode == http.StatusPartialContent:
+	case res.StatusCode == http.StatusOK:
+		// the server ignored the range, so skip to the offset
+		if _, err := io.CopyN(ioutil.Discard, res.Body, r.offset); err != nil {
+			res.Body.Close()
+			return err
+		}
+	default:
+		res.Body.Close()
+		return fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	if r.offset == 0 {
+		r.size = res.ContentLength
+	}
+	r.body = res.Body
+	return nil
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.body == nil {
+		if r.size >= 0 && r.offset >= r.size {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		if r.size < 0 {
+			return 0, fmt.Errorf("seek: unknown content size")
+		}
+		offset += r.size
+	default:
+		return 0, fmt.Errorf("seek: invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("seek: negative offset %d", offset)
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// setRange sets the Range header of the request to read from the offset.
+func setRange(req *http.Request, offset int64) {
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+}
@@ -0,0 +1,220 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// S3Config is the configuration of an S3 compatible object store.
+type S3Config struct {
+	// Endpoint is the base URL of the object store, for example
+	// https://s3.amazonaws.com or http://localhost:9000
+	Endpoint string
+
+	// Bucket is the bucket to store content in, which must already exist
+	Bucket string
+
+	// Prefix is prepended to the hex encoded hash of the content to form
+	// object keys
+	Prefix string
+
+	// Region is the region used to sign requests, defaulting to us-east-1
+	Region string
+
+	AccessKey string
+	SecretKey string
+}
+
+// S3 is a ContentStore which stores content as objects in an S3 compatible
+// object store, keyed by the content's Swarm hash and accessed using
+// path-style URLs and AWS Signature Version 4 signed requests.
+type S3 struct {
+	config S3Config
+	client *http.Client
+}
+
+func NewS3(config S3Config) *S3 {
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return &S3{config: config, client: http.DefaultClient}
+}
+
+func (s *S3) Store(r io.Reader) (common.Hash, error) {
+	// spool the content to a temporary file to determine its size and
+	// hashes before uploading it
+	tmp, err := ioutil.TempFile("", "kord-s3")
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	sha := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, sha), r)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return common.Hash{}, err
+	}
+	hash, err := Hash(tmp, size)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return common.Hash{}, err
+	}
+
+	var body io.Reader = tmp
+	if size == 0 {
+		body = http.NoBody
+	}
+	req, err := http.NewRequest("PUT", s.url(hash), body)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	req.ContentLength = size
+	s.sign(req, hex.EncodeToString(sha.Sum(nil)), time.Now())
+	res, err := s.client.Do(req)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return common.Hash{}, s3Error(res)
+	}
+	return hash, nil
+}
+
+func (s *S3) Retrieve(hash common.Hash) (ReadSeekCloser, error) {
+	url := s.url(hash)
+	return newRangeReader(func(offset int64) (*http.Response, error) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		setRange(req, offset)
+		s.sign(req, emptySHA256, time.Now())
+		res, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+			defer res.Body.Close()
+			return nil, s3Error(res)
+		}
+		return res, nil
+	})
+}
+
+func (s *S3) url(hash common.Hash) string {
+	return fmt.Sprintf("%s/%s/%s%s", s.config.Endpoint, s.config.Bucket, s.config.Prefix, strings.TrimPrefix(hash.Hex(), "0x"))
+}
+
+// s3Error returns an error including the body of an S3 error response,
+// which describes the error in an XML document.
+func s3Error(res *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("unexpected S3 response: %s: %s", res.Status, strings.TrimSpace(string(body)))
+}
+
+// emptySHA256 is the hex encoded SHA256 hash of an empty payload.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign signs the request using AWS Signature Version 4, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *S3) sign(req *http.Request, payloadHash string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// sign the host, range and x-amz-* headers
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for key, values := range req.Header {
+		key = strings.ToLower(key)
+		if key == "range" || strings.HasPrefix(key, "x-amz-") {
+			headers[key] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders string
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.Replace(req.URL.Query().Encode(), "+", "%20", -1),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, s.config.Region, "s3", "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + s.config.SecretKey)
+	for _, part := range []string{date, s.config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,97 @@
+// This file is part of the go-kord library.
+//
+// Copyright (C) 2018 JAAK MUSIC LTD
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//
+// If you have any questions please contact yo@jaak.io
+
+// Package store implements content stores which store and retrieve graph
+// databases by their Swarm hash.
+package store
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// ContentStore stores and retrieves content addressed by its Swarm hash,
+// so that graph databases can be stored in Swarm or elsewhere while still
+// being identified in the registry by their Swarm hash.
+type ContentStore interface {
+	// Store stores the content read from r, returning its Swarm hash.
+	Store(r io.Reader) (common.Hash, error)
+
+	// Retrieve returns a reader for the content with the given Swarm
+	// hash.
+	Retrieve(hash common.Hash) (ReadSeekCloser, error)
+}
+
+// ReadSeekCloser is the interface of readers returned by Retrieve.
+type ReadSeekCloser interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// Hash returns the Swarm hash of size bytes of content read from r without
+// storing it, so that content retrieved from a store can be verified.
+func Hash(r io.Reader, size int64) (common.Hash, error) {
+	chunker := storage.NewTreeChunker(storage.NewChunkerParams())
+	key, err := chunker.Split(r, size, nil, nil, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(key), nil
+}
+
+// spool returns a reader for the remaining content of r along with its
+// size, buffering the content in a temporary file if r cannot seek. The
+// returned function removes any temporary file.
+func spool(r io.Reader) (io.Reader, int64, func(), error) {
+	if s, ok := r.(io.Seeker); ok {
+		offset, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		end, err := s.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return nil, 0, nil, err
+		}
+		return r, end - offset, func() {}, nil
+	}
+	tmp, err := ioutil.TempFile("", "kord-store")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	size, err := io.Copy(tmp, r)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return tmp, size, cleanup, nil
+}
@@ -22,49 +22,38 @@ package testutil
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/swarm/storage"
 	"github.com/kord-network/go-kord/registry"
+	"github.com/kord-network/go-kord/store"
 )
 
-type TestDPA struct {
-	*storage.DPA
+// TestStore is a content store which stores content in a temporary
+// directory, with graph databases expected to be opened in the same
+// directory.
+type TestStore struct {
+	*store.FS
 
 	Dir string
 }
 
-func NewTestDPA() (*TestDPA, error) {
+func NewTestStore() (*TestStore, error) {
 	dir, err := ioutil.TempDir("", "kord-testutil")
 	if err != nil {
 		return nil, err
 	}
-	localStore, err := storage.NewLocalStore(
-		storage.MakeHashFunc("SHA3"),
-		&storage.StoreParams{
-			ChunkDbPath:   dir,
-			DbCapacity:    5000000,
-			CacheCapacity: 5000,
-			Radius:        0,
-		},
-	)
+	fs, err := store.NewFS(filepath.Join(dir, "content"))
 	if err != nil {
 		os.RemoveAll(dir)
 		return nil, err
 	}
-	chunker := storage.NewTreeChunker(storage.NewChunkerParams())
-	dpa := &storage.DPA{
-		Chunker:    chunker,
-		ChunkStore: localStore,
-	}
-	dpa.Start()
-	return &TestDPA{dpa, dir}, nil
+	return &TestStore{fs, dir}, nil
 }
 
-func (t *TestDPA) Cleanup() {
-	t.Stop()
+func (t *TestStore) Cleanup() {
 	os.RemoveAll(t.Dir)
 }
 